@@ -1,16 +1,19 @@
 package rabbitmq
 
 import (
+	"crypto/tls"
 	"fmt"
+	"github.com/csby/database/mqdb"
 	"net/url"
 )
 
 type Connection struct {
-	Server      string `json:"server"`   // 服务器名称或IP, 默认127.0.0.1
-	Port        int    `json:"port"`     // 服务器端口, 默认5672
-	User        string `json:"user"`     // 登录名
-	Password    string `json:"password"` // 登陆密码
-	VirtualHost string `json:"vh"`       // 虚拟主机
+	Server      string      `json:"server"`   // 服务器名称或IP, 默认127.0.0.1
+	Port        int         `json:"port"`     // 服务器端口, 默认5672
+	User        string      `json:"user"`     // 登录名
+	Password    string      `json:"password"` // 登陆密码
+	VirtualHost string      `json:"vh"`       // 虚拟主机
+	Tls         *mqdb.MqTls `json:"tls"`      // TLS加密设置(amqps), 为空时不加密, 服务器端口一般为5671
 }
 
 func (s *Connection) Connection() string {
@@ -19,8 +22,12 @@ func (s *Connection) Connection() string {
 	//	s.Server, s.Port,
 	//	s.VirtualHost)
 
+	scheme := "amqp"
+	if s.Tls.Enabled() {
+		scheme = "amqps"
+	}
 	u := &url.URL{
-		Scheme: "amqp",
+		Scheme: scheme,
 		User:   url.UserPassword(s.User, s.Password),
 		Host:   fmt.Sprintf("%s:%d", s.Server, s.Port),
 		Path:   s.VirtualHost,
@@ -28,3 +35,7 @@ func (s *Connection) Connection() string {
 
 	return u.String()
 }
+
+func (s *Connection) TlsConfig() (*tls.Config, error) {
+	return s.Tls.Config()
+}
@@ -16,7 +16,7 @@ func NewDatabase(conn mqdb.MqConnection) mqdb.MqDatabase {
 }
 
 func (s *rabbitMq) Test() (string, error) {
-	conn, err := amqp.Dial(s.connection.Connection())
+	conn, err := s.dial()
 	if err != nil {
 		mqErr, ok := err.(*amqp.Error)
 		if ok {
@@ -41,7 +41,7 @@ func (s *rabbitMq) Test() (string, error) {
 }
 
 func (s *rabbitMq) NewAccess() (mqdb.MqAccess, error) {
-	conn, err := amqp.Dial(s.connection.Connection())
+	conn, err := s.dial()
 	if err != nil {
 		mqErr, ok := err.(*amqp.Error)
 		if ok {
@@ -52,3 +52,17 @@ func (s *rabbitMq) NewAccess() (mqdb.MqAccess, error) {
 
 	return &access{connection: conn}, nil
 }
+
+func (s *rabbitMq) dial() (*amqp.Connection, error) {
+	if c, ok := s.connection.(mqdb.MqTlsConnection); ok {
+		config, err := c.TlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		if config != nil {
+			return amqp.DialTLS(s.connection.Connection(), config)
+		}
+	}
+
+	return amqp.Dial(s.connection.Connection())
+}
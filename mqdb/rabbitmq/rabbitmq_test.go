@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/csby/database/mqdb"
+	"strings"
 	"testing"
 )
 
@@ -74,6 +75,29 @@ func testAccess_Consume(t *testing.T) {
 	}
 }
 
+func TestConnection_Tls(t *testing.T) {
+	connection := testConnection()
+	if u := connection.Connection(); !strings.HasPrefix(u, "amqp://") {
+		t.Errorf("unexpected url %s", u)
+	}
+	config, err := connection.TlsConfig()
+	if err != nil || config != nil {
+		t.Errorf("unexpected config %v, %v", config, err)
+	}
+
+	connection.Tls = &mqdb.MqTls{Mode: mqdb.TlsVerifyFull, ServerName: "mq.internal"}
+	if u := connection.Connection(); !strings.HasPrefix(u, "amqps://") {
+		t.Errorf("unexpected url %s", u)
+	}
+	config, err = connection.TlsConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config == nil || config.ServerName != "mq.internal" || config.InsecureSkipVerify {
+		t.Errorf("unexpected config %+v", config)
+	}
+}
+
 func testConnection() *Connection {
 	return &Connection{
 		Server:      "192.168.123.5",
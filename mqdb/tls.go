@@ -0,0 +1,23 @@
+package mqdb
+
+import (
+	"crypto/tls"
+	"github.com/csby/database/tlsconf"
+)
+
+const (
+	TlsDisable    = tlsconf.Disable
+	TlsRequire    = tlsconf.Require
+	TlsVerifyCA   = tlsconf.VerifyCA
+	TlsVerifyFull = tlsconf.VerifyFull
+)
+
+// MqTls is the TLS settings of connection
+type MqTls = tlsconf.Settings
+
+// MqTlsConnection is implemented by the connections over TLS
+type MqTlsConnection interface {
+	MqConnection
+	// TlsConfig returns the config of TLS, nil if not encrypted
+	TlsConfig() (*tls.Config, error)
+}
@@ -0,0 +1,997 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+type access struct {
+	dialect Dialect
+	ctx     context.Context
+}
+
+// NewAccess creates the access over db, which is closed when the access is closed.
+// The access runs in a transaction if transactional, and rollbacks on close unless committed.
+func NewAccess(dialect Dialect, db *sql.DB, transactional bool) (SqlAccess, error) {
+	if transactional {
+		tx, err := db.Begin()
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+
+		return &transaction{access: access{dialect: dialect, ctx: context.Background()}, db: db, tx: tx}, nil
+	}
+
+	return &normal{access: access{dialect: dialect, ctx: context.Background()}, db: db}, nil
+}
+
+// NewSnapshotAccess returns the read-only transaction reading a consistent snapshot,
+// the options of transaction are given by the dialect (see SnapshotReader).
+func NewSnapshotAccess(dialect Dialect, db *sql.DB) (SqlAccess, error) {
+	opts := &sql.TxOptions{ReadOnly: true}
+	if reader, ok := dialect.(SnapshotReader); ok {
+		opts = reader.SnapshotOptions()
+	}
+	tx, err := db.BeginTx(context.Background(), opts)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &transaction{access: access{dialect: dialect, ctx: context.Background()}, db: db, tx: tx}, nil
+}
+
+func (s *access) NewFilter(entity interface{}, fieldOr, groupOr bool) SqlFilter {
+	return newFilter(entity, fieldOr, groupOr)
+}
+
+func (s *access) isNoRows(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	return s.dialect.IsNoRows(err)
+}
+
+func (s *access) newEntity() *entity {
+	return &entity{dialect: s.dialect}
+}
+
+// tableName returns the quoted name of table qualified by the schema
+func (s *access) tableName(table *SqlTable) string {
+	if namer, ok := s.dialect.(TableNamer); ok {
+		return namer.TableName(table)
+	}
+	if table.Schema == "" {
+		return s.dialect.Quote(table.Name)
+	}
+
+	return fmt.Sprint(s.dialect.Quote(table.Schema), ".", s.dialect.Quote(table.Name))
+}
+
+func (s *access) newBuilder() *builder {
+	sqlBuilder := &builder{dialect: s.dialect}
+	sqlBuilder.Reset()
+
+	return sqlBuilder
+}
+
+// getFilterFields returns the fields of filter with values, which are converted by the converters (conv tag) as written,
+// the filter of encrypted field is invalid as the values are not deterministic.
+func (s *access) getFilterFields(dbFilter interface{}) ([]SqlField, error) {
+	fields := make([]SqlField, 0)
+	if dbFilter == nil {
+		return fields, nil
+	}
+	sqlFields, ok := dbFilter.([]SqlField)
+	if ok {
+		return sqlFields, nil
+	}
+
+	filterEntity := s.newEntity()
+	err := filterEntity.ParseFilter(dbFilter)
+	if err != nil {
+		return fields, nil
+	}
+	for i, fm := range filterEntity.meta.fields {
+		f := filterEntity.fields[i]
+		if f.ValueEmpty() {
+			continue
+		}
+		filterSymbol := strings.ToLower(f.filter)
+		if fm.converter != nil && filterSymbol != "in" && filterSymbol != "custom" {
+			if _, encrypted := fm.converter.(*encryptConverter); encrypted {
+				return nil, newError("invalid filter of ", fm.name, ": encrypted field not comparable")
+			}
+			value, err := fm.converter.Value(f.value)
+			if err != nil {
+				return nil, newError("invalid filter of ", fm.name, ": ", err)
+			}
+			f.value = value
+		}
+		fields = append(fields, f)
+	}
+
+	return fields, nil
+}
+
+func (s *access) fillWhereField(sqlBuilder SqlBuilder, fields []SqlField, or bool) {
+	if sqlBuilder == nil {
+		return
+	}
+
+	fieldCount := len(fields)
+	if fieldCount > 0 {
+		sqlBuilder.AppendFormat("(")
+		for fieldIndex := 0; fieldIndex < fieldCount; fieldIndex++ {
+			f := fields[fieldIndex]
+			filterSymbol := f.Filter()
+
+			if strings.ToLower(filterSymbol) == "in" {
+				if fieldIndex == 0 {
+					sqlBuilder.WhereFormat("%s %s %s", f.Name(), filterSymbol, f.Value())
+				} else if or {
+					sqlBuilder.WhereFormatOr("%s %s %s", f.Name(), filterSymbol, f.Value())
+				} else {
+					sqlBuilder.WhereFormatAnd("%s %s %s", f.Name(), filterSymbol, f.Value())
+				}
+			} else if strings.ToLower(filterSymbol) == "custom" {
+				if fieldIndex == 0 {
+					sqlBuilder.Where(fmt.Sprintf("%s %s", f.Name(), f.Value()))
+				} else if or {
+					sqlBuilder.WhereOr(fmt.Sprintf("%s %s", f.Name(), f.Value()))
+				} else {
+					sqlBuilder.WhereAnd(fmt.Sprintf("%s %s", f.Name(), f.Value()))
+				}
+			} else {
+				if fieldIndex == 0 {
+					sqlBuilder.Where(fmt.Sprintf("%s %s %s", f.Name(), filterSymbol, sqlBuilder.ArgName()), f.Value())
+				} else if or {
+					sqlBuilder.WhereOr(fmt.Sprintf("%s %s %s", f.Name(), filterSymbol, sqlBuilder.ArgName()), f.Value())
+				} else {
+					sqlBuilder.WhereAnd(fmt.Sprintf("%s %s %s", f.Name(), filterSymbol, sqlBuilder.ArgName()), f.Value())
+				}
+			}
+		}
+		sqlBuilder.AppendFormat(")")
+	}
+}
+
+func (s *access) fillWhereFilter(sqlBuilder SqlBuilder, filters []SqlFilter) error {
+	filterCount := len(filters)
+	if filterCount < 1 {
+		return nil
+	}
+
+	groupCount := 0
+	for filterIndex := 0; filterIndex < filterCount; filterIndex++ {
+		f := filters[filterIndex]
+		if f == nil {
+			continue
+		}
+		fields, err := s.getFilterFields(f.Fields())
+		if err != nil {
+			return err
+		}
+		if len(fields) < 1 {
+			continue
+		}
+
+		groupCount++
+		if groupCount == 1 {
+			sqlBuilder.Where("")
+		} else if f.GroupOr() {
+			sqlBuilder.WhereOr("")
+		} else {
+			sqlBuilder.WhereAnd("")
+		}
+
+		s.fillWhereField(sqlBuilder, fields, f.FieldOr())
+	}
+
+	return nil
+}
+
+func (s *access) fillWhere(sqlBuilder SqlBuilder, filters ...SqlFilter) error {
+	return s.fillWhereFilter(sqlBuilder, filters)
+}
+
+// fillScopedWhere excludes the soft deleted rows (if any) in addition to the filters, unless unscoped:
+// WHERE <not deleted> AND (<filters>)
+func (s *access) fillScopedWhere(sqlBuilder SqlBuilder, sqlEntity *entity, filters ...SqlFilter) error {
+	softDelete := sqlEntity.meta.softDelete
+	if softDelete == nil || isUnscoped(filters) {
+		return s.fillWhereFilter(sqlBuilder, filters)
+	}
+
+	sqlBuilder.Where(softDelete.notDeletedCondition())
+	for _, f := range filters {
+		if f == nil {
+			continue
+		}
+		fields, err := s.getFilterFields(f.Fields())
+		if err != nil {
+			return err
+		}
+		if len(fields) > 0 {
+			sqlBuilder.Append("AND (")
+			err = s.fillWhereFilter(sqlBuilder, filters)
+			sqlBuilder.Append(")")
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *access) fillOrder(sqlBuilder SqlBuilder, order interface{}) {
+	if order == nil {
+		return
+	}
+	if reflect.ValueOf(order).IsNil() {
+		return
+	}
+
+	sqlEntity := s.newEntity()
+	err := sqlEntity.Parse(order)
+	if err != nil {
+		return
+	}
+
+	count := len(sqlEntity.fields)
+	if count < 1 {
+		return
+	}
+
+	orders := make(OrderCollection, 0)
+	fields := make([]orderField, 0)
+	for i := 0; i < count; i++ {
+		f := sqlEntity.fields[i]
+		o := strings.ToUpper(f.order)
+		if o == sqlFieldOrderValueCustom {
+			if f.value == nil {
+				continue
+			}
+			v, ok := f.value.(int)
+			if ok {
+				if v > 0 {
+					fields = append(fields, orderField{Name: f.name, Value: sqlFieldOrderValueAsc})
+				} else if v < 0 {
+					fields = append(fields, orderField{Name: f.name, Value: sqlFieldOrderValueDesc})
+				}
+			} else {
+				ov, ok := f.value.(Order)
+				if ok {
+					ov.Name = f.name
+					orders = append(orders, ov)
+				}
+			}
+		} else {
+			ov, ok := f.value.(Order)
+			if ok {
+				ov.Name = f.name
+				orders = append(orders, ov)
+			} else {
+				fields = append(fields, orderField{Name: f.name, Value: o})
+			}
+		}
+	}
+
+	count = len(orders)
+	if count > 0 {
+		sort.Sort(orders)
+		for i := 0; i < count; i++ {
+			o := orders[i]
+			if o.Sort > 0 {
+				fields = append(fields, orderField{Name: o.Name, Value: sqlFieldOrderValueAsc})
+			} else if o.Sort < 0 {
+				fields = append(fields, orderField{Name: o.Name, Value: sqlFieldOrderValueDesc})
+			}
+		}
+	}
+
+	count = len(fields)
+	if count < 1 {
+		return
+	}
+
+	sqlBuilder.Append(fmt.Sprintf("order by %s %s", fields[0].Name, fields[0].Value))
+
+	for i := 1; i < count; i++ {
+		sqlBuilder.Append(fmt.Sprintf(", %s %s", fields[i].Name, fields[i].Value))
+	}
+}
+
+func (s *access) insert(sqlAccess SqlAccess, selective bool, dbEntity interface{}, fields ...SqlField) (uint64, error) {
+	err := beforeInsert(sqlAccess, dbEntity)
+	if err != nil {
+		return 0, err
+	}
+
+	sqlEntity := s.newEntity()
+	err = sqlEntity.Parse(dbEntity)
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.fillAudit(sqlEntity, true)
+	if err != nil {
+		return 0, err
+	}
+	err = sqlEntity.convert()
+	if err != nil {
+		return 0, err
+	}
+
+	var autoField SqlField = nil
+	sqlBuilder := s.newBuilder()
+	sqlBuilder.Insert(sqlEntity.Name())
+	fieldCount := sqlEntity.FieldCount()
+	for fieldIndex := 0; fieldIndex < fieldCount; fieldIndex++ {
+		f := sqlEntity.Field(fieldIndex)
+		if f.AutoIncrement() {
+			autoField = f
+			continue
+		}
+		if selective {
+			if f.ValueEmpty() {
+				continue
+			}
+		}
+
+		sqlBuilder.Value(f.Name(), f.Value())
+	}
+	ec := len(fields)
+	for ei := 0; ei < ec; ei++ {
+		ef := fields[ei]
+		if ef == nil {
+			continue
+		}
+		if selective {
+			if ef.ValueEmpty() {
+				continue
+			}
+		}
+
+		sqlBuilder.Value(ef.Name(), ef.Value())
+	}
+
+	id, err := s.dialect.Insert(sqlAccess, sqlBuilder, autoField)
+	if err != nil {
+		return 0, s.dialect.Error(err)
+	}
+	if id > 0 && autoField != nil {
+		err = setAutoIncrement(autoField, id)
+		if err != nil {
+			return id, err
+		}
+	}
+
+	err = afterInsert(sqlAccess, dbEntity)
+	if err != nil {
+		return id, err
+	}
+
+	return id, nil
+}
+
+// setAutoIncrement sets the auto increment field of entity by the generated id
+func setAutoIncrement(f SqlField, id uint64) error {
+	v := reflect.ValueOf(f.Address()).Elem()
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(int64(id))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(id)
+	default:
+		return newError("invalid auto increment field ", f.Name(), ": ", v.Kind(), " is not integer")
+	}
+
+	return nil
+}
+
+// delete marks the rows as deleted if the entity defines soft delete field, unless hard or unscoped
+func (s *access) delete(sqlAccess SqlAccess, hard bool, dbEntity interface{}, sqlFilters ...SqlFilter) (uint64, error) {
+	err := beforeDelete(sqlAccess, dbEntity)
+	if err != nil {
+		return 0, err
+	}
+
+	sqlEntity := s.newEntity()
+	err = sqlEntity.Parse(dbEntity)
+	if err != nil {
+		return 0, err
+	}
+
+	sqlBuilder := s.newBuilder()
+	softDelete := sqlEntity.meta.softDelete
+	if softDelete != nil && !hard && !isUnscoped(sqlFilters) {
+		sqlBuilder.Update(sqlEntity.Name())
+		sqlBuilder.Set(softDelete.name, softDelete.deletedValue())
+		err = s.fillScopedWhere(sqlBuilder, sqlEntity, sqlFilters...)
+	} else {
+		sqlBuilder.Delete(sqlEntity.Name())
+		err = s.fillWhere(sqlBuilder, sqlFilters...)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return s.exec(sqlAccess, sqlBuilder)
+}
+
+func (s *access) update(sqlAccess SqlAccess, selective bool, dbEntity interface{}, sqlFilters ...SqlFilter) (uint64, error) {
+	err := beforeUpdate(sqlAccess, dbEntity)
+	if err != nil {
+		return 0, err
+	}
+
+	sqlEntity := s.newEntity()
+	err = sqlEntity.Parse(dbEntity)
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.fillAudit(sqlEntity, false)
+	if err != nil {
+		return 0, err
+	}
+	err = sqlEntity.convert()
+	if err != nil {
+		return 0, err
+	}
+
+	sqlBuilder := s.newBuilder()
+	sqlBuilder.Update(sqlEntity.Name())
+	fieldCount := sqlEntity.FieldCount()
+	for fieldIndex := 0; fieldIndex < fieldCount; fieldIndex++ {
+		f := sqlEntity.Field(fieldIndex)
+		if f.AutoIncrement() {
+			continue
+		}
+		if sqlEntity.isCreatedAudit(fieldIndex) {
+			continue
+		}
+		if selective {
+			if f.ValueEmpty() {
+				continue
+			}
+		}
+
+		sqlBuilder.Set(f.Name(), f.Value())
+	}
+	err = s.fillWhere(sqlBuilder, sqlFilters...)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := s.exec(sqlAccess, sqlBuilder)
+	if err != nil {
+		return 0, err
+	}
+
+	err = afterUpdate(sqlAccess, dbEntity)
+	if err != nil {
+		return rowsAffected, err
+	}
+
+	return rowsAffected, nil
+}
+
+// updateFields updates the columns of entity only, even if the values are empty
+func (s *access) updateFields(sqlAccess SqlAccess, dbEntity interface{}, columns []string, sqlFilters ...SqlFilter) (uint64, error) {
+	if len(columns) < 1 {
+		return 0, fmt.Errorf("no column to update")
+	}
+
+	err := beforeUpdate(sqlAccess, dbEntity)
+	if err != nil {
+		return 0, err
+	}
+
+	sqlEntity := s.newEntity()
+	err = sqlEntity.Parse(dbEntity)
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.fillAudit(sqlEntity, false)
+	if err != nil {
+		return 0, err
+	}
+	err = sqlEntity.convert()
+	if err != nil {
+		return 0, err
+	}
+
+	sqlBuilder := s.newBuilder()
+	sqlBuilder.Update(sqlEntity.Name())
+	updated := make(map[int]bool)
+	for _, column := range columns {
+		fieldIndex := sqlEntity.fieldByColumn(column)
+		if fieldIndex < 0 {
+			return 0, newError("entity (", sqlEntity.Name(), "): column ", column, " not defined")
+		}
+		if updated[fieldIndex] {
+			continue
+		}
+		updated[fieldIndex] = true
+
+		f := sqlEntity.fields[fieldIndex]
+		sqlBuilder.Set(f.Name(), f.Value())
+	}
+	s.fillUpdatedAudit(sqlBuilder, sqlEntity, updated)
+	err = s.fillWhere(sqlBuilder, sqlFilters...)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := s.exec(sqlAccess, sqlBuilder)
+	if err != nil {
+		return 0, err
+	}
+
+	err = afterUpdate(sqlAccess, dbEntity)
+	if err != nil {
+		return rowsAffected, err
+	}
+
+	return rowsAffected, nil
+}
+
+// updateMap updates the columns (key of values) of the table of entity,
+// the values are converted by the conv tag of the fields, unless they are Expression.
+// The entity provides the table and columns only, the hooks are not called.
+func (s *access) updateMap(sqlAccess SqlAccess, dbEntity interface{}, values map[string]interface{}, sqlFilters ...SqlFilter) (uint64, error) {
+	if len(values) < 1 {
+		return 0, fmt.Errorf("no column to update")
+	}
+
+	sqlEntity := s.newEntity()
+	err := sqlEntity.Parse(dbEntity)
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.fillAudit(sqlEntity, false)
+	if err != nil {
+		return 0, err
+	}
+
+	columns := make([]string, 0, len(values))
+	for column := range values {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	sqlBuilder := s.newBuilder()
+	sqlBuilder.Update(sqlEntity.Name())
+	updated := make(map[int]bool)
+	for _, column := range columns {
+		fieldIndex := sqlEntity.fieldByColumn(column)
+		if fieldIndex < 0 {
+			return 0, newError("entity (", sqlEntity.Name(), "): column ", column, " not defined")
+		}
+		updated[fieldIndex] = true
+
+		fm := sqlEntity.meta.fields[fieldIndex]
+		value := values[column]
+		_, isExpr := value.(*Expression)
+		if fm.converter != nil && !isExpr {
+			value, err = fm.converter.Value(value)
+			if err != nil {
+				return 0, newError("invalid value of ", fm.name, ": ", err)
+			}
+		}
+		sqlBuilder.Set(fm.name, value)
+	}
+	s.fillUpdatedAudit(sqlBuilder, sqlEntity, updated)
+	err = s.fillWhere(sqlBuilder, sqlFilters...)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.exec(sqlAccess, sqlBuilder)
+}
+
+// fillUpdatedAudit sets the updated time (and user if any) fields which are not updated explicitly
+func (s *access) fillUpdatedAudit(sqlBuilder SqlBuilder, sqlEntity *entity, updated map[int]bool) {
+	_, hasUser := UserFromContext(s.ctx)
+	for i, fm := range sqlEntity.meta.fields {
+		if updated[i] {
+			continue
+		}
+		if fm.audit == auditUpdated || (fm.audit == auditUpdatedBy && hasUser) {
+			sqlBuilder.Set(fm.name, sqlEntity.fields[i].value)
+		}
+	}
+}
+
+func (s *access) updateByPrimaryKey(sqlAccess SqlAccess, selective bool, dbEntity interface{}) (uint64, error) {
+	err := beforeUpdate(sqlAccess, dbEntity)
+	if err != nil {
+		return 0, err
+	}
+
+	sqlEntity := s.newEntity()
+	err = sqlEntity.Parse(dbEntity)
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.fillAudit(sqlEntity, false)
+	if err != nil {
+		return 0, err
+	}
+	err = sqlEntity.convert()
+	if err != nil {
+		return 0, err
+	}
+
+	sqlBuilder := s.newBuilder()
+	sqlBuilder.Update(sqlEntity.Name())
+	fieldCount := sqlEntity.FieldCount()
+	primaryFields := make([]SqlField, 0)
+	var versionField *field = nil
+	var version interface{} = nil
+	for fieldIndex := 0; fieldIndex < fieldCount; fieldIndex++ {
+		f := sqlEntity.fields[fieldIndex]
+		if f.PrimaryKey() {
+			primaryFields = append(primaryFields, f)
+			continue
+		}
+		if f.AutoIncrement() {
+			continue
+		}
+		if sqlEntity.isCreatedAudit(fieldIndex) {
+			continue
+		}
+		if sqlEntity.meta.fields[fieldIndex] == sqlEntity.meta.version {
+			versionField = f
+			version = nextVersion(f.Value())
+			sqlBuilder.Set(f.Name(), version)
+			continue
+		}
+		if selective {
+			if f.ValueEmpty() {
+				continue
+			}
+		}
+
+		sqlBuilder.Set(f.Name(), f.Value())
+	}
+
+	primaryCount := len(primaryFields)
+	if primaryCount < 1 {
+		return 0, fmt.Errorf("no primary key")
+	}
+	s.fillWherePrimaryKey(sqlBuilder, primaryFields)
+
+	if versionField != nil {
+		// the row is updated only if the version is not changed by others
+		sqlBuilder.WhereAnd(fmt.Sprintf("%s = %s", versionField.Name(), sqlBuilder.ArgName()), versionField.Value())
+	}
+
+	rowsAffected, err := s.exec(sqlAccess, sqlBuilder)
+	if err != nil {
+		return 0, err
+	}
+
+	if versionField != nil {
+		if rowsAffected == 0 {
+			return 0, ErrStaleEntity
+		}
+		setVersion(versionField.Address(), version)
+	} else if rowsAffected == 0 {
+		// some databases report the changed rows only, check whether the row exists
+		sqlBuilder.Reset()
+		sqlBuilder.Select("COUNT(*)", false).From(sqlEntity.Name())
+		s.fillWherePrimaryKey(sqlBuilder, primaryFields)
+
+		query := sqlBuilder.Query()
+		row := sqlAccess.QueryRow(query, sqlBuilder.Args()...)
+		err := row.Scan(&rowsAffected)
+		if err != nil {
+			return 0, s.dialect.Error(err)
+		}
+	}
+
+	err = afterUpdate(sqlAccess, dbEntity)
+	if err != nil {
+		return rowsAffected, err
+	}
+
+	return rowsAffected, nil
+}
+
+func (s *access) fillWherePrimaryKey(sqlBuilder SqlBuilder, primaryFields []SqlField) {
+	primaryCount := len(primaryFields)
+	for fieldIndex := 0; fieldIndex < primaryCount; fieldIndex++ {
+		f := primaryFields[fieldIndex]
+		if fieldIndex == 0 {
+			sqlBuilder.Where(fmt.Sprintf("%s = %s", f.Name(), sqlBuilder.ArgName()), f.Value())
+		} else {
+			sqlBuilder.WhereAnd(fmt.Sprintf("%s = %s", f.Name(), sqlBuilder.ArgName()), f.Value())
+		}
+	}
+}
+
+func (s *access) exec(sqlAccess SqlAccess, sqlBuilder SqlBuilder) (uint64, error) {
+	result, err := sqlAccess.Exec(sqlBuilder.Query(), sqlBuilder.Args()...)
+	if err != nil {
+		return 0, s.dialect.Error(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, s.dialect.Error(err)
+	}
+
+	return uint64(rowsAffected), nil
+}
+
+func (s *access) selectCount(sqlAccess SqlAccess, dbEntity interface{}, sqlFilters ...SqlFilter) (uint64, error) {
+	sqlEntity := s.newEntity()
+	err := sqlEntity.Parse(dbEntity)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.selectTableCount(sqlAccess, sqlEntity, sqlFilters...)
+}
+
+func (s *access) selectTableCount(sqlAccess SqlAccess, sqlEntity *entity, sqlFilters ...SqlFilter) (uint64, error) {
+	sqlBuilder := s.newBuilder()
+	sqlBuilder.Select("COUNT(*)", false).From(sqlEntity.Name())
+	err := s.fillScopedWhere(sqlBuilder, sqlEntity, sqlFilters...)
+	if err != nil {
+		return 0, err
+	}
+
+	if !sqlBuilder.hasWhere {
+		counter, ok := s.dialect.(RowsCounter)
+		if ok {
+			c, e := counter.TableRows(sqlAccess, sqlEntity.Name())
+			if e == nil {
+				return c, nil
+			}
+		}
+	}
+
+	count := uint64(0)
+	query := sqlBuilder.Query()
+	row := sqlAccess.QueryRow(query, sqlBuilder.Args()...)
+	err = row.Scan(&count)
+	if err != nil {
+		return 0, s.dialect.Error(err)
+	}
+
+	return count, nil
+}
+
+func (s *access) selectOne(sqlAccess SqlAccess, dbEntity interface{}, sqlFilters ...SqlFilter) error {
+	sqlEntity := s.newEntity()
+	err := sqlEntity.Parse(dbEntity)
+	if err != nil {
+		return err
+	}
+	err = sqlEntity.project(sqlFilters)
+	if err != nil {
+		return err
+	}
+
+	sqlBuilder := s.newBuilder()
+	sqlBuilder.Select(sqlEntity.ScanFields(), false).From(sqlEntity.Name())
+	err = s.fillScopedWhere(sqlBuilder, sqlEntity, sqlFilters...)
+	if err != nil {
+		return err
+	}
+
+	query := sqlBuilder.Query()
+	row := sqlAccess.QueryRow(query, sqlBuilder.Args()...)
+	err = row.Scan(sqlEntity.ScanArgs()...)
+	if err != nil {
+		return s.dialect.Error(err)
+	}
+	err = afterScan(sqlAccess, dbEntity)
+	if err != nil {
+		return err
+	}
+
+	preloads := getPreloads(sqlFilters)
+	if len(preloads) < 1 {
+		return nil
+	}
+
+	return s.preload(sqlAccess, sqlEntity.meta, []reflect.Value{reflect.ValueOf(dbEntity).Elem()}, preloads)
+}
+
+func (s *access) selectList(sqlAccess SqlAccess, distinct bool, dbEntity interface{}, row func(index uint64, evt SqlEvent), dbOrder interface{}, sqlFilters ...SqlFilter) error {
+	sqlEntity := s.newEntity()
+	err := sqlEntity.Parse(dbEntity)
+	if err != nil {
+		return err
+	}
+	err = sqlEntity.project(sqlFilters)
+	if err != nil {
+		return err
+	}
+
+	sqlBuilder := s.newBuilder()
+	sqlBuilder.Select(sqlEntity.ScanFields(), distinct).From(sqlEntity.Name())
+	err = s.fillScopedWhere(sqlBuilder, sqlEntity, sqlFilters...)
+	if err != nil {
+		return err
+	}
+	s.fillOrder(sqlBuilder, dbOrder)
+
+	return s.scanRows(sqlAccess, sqlBuilder, dbEntity, sqlEntity, row, getPreloads(sqlFilters))
+}
+
+func (s *access) selectPage(sqlAccess SqlAccess, dbEntity interface{}, page func(total, page, size, index uint64), row func(index uint64, evt SqlEvent), size, index uint64, dbOrder interface{}, sqlFilters ...SqlFilter) error {
+	sqlEntity := s.newEntity()
+	err := sqlEntity.Parse(dbEntity)
+	if err != nil {
+		return err
+	}
+	err = sqlEntity.project(sqlFilters)
+	if err != nil {
+		return err
+	}
+	total, err := s.selectTableCount(sqlAccess, sqlEntity, sqlFilters...)
+	if err != nil {
+		return err
+	}
+	if size < 1 {
+		size = 1
+	}
+	pageCount := total / size
+	if (total % size) != 0 {
+		pageCount++
+	}
+	pageIndex := index
+	if pageIndex > pageCount {
+		pageIndex = pageCount
+	}
+	if pageIndex < 1 {
+		pageIndex = 1
+	}
+	if page != nil {
+		page(total, pageCount, size, pageIndex)
+	}
+	if total < 1 {
+		return nil
+	}
+
+	sqlBuilderOrder := s.newBuilder()
+	s.fillOrder(sqlBuilderOrder, dbOrder)
+	if len(sqlBuilderOrder.Query()) < 1 {
+		// paging without order is not stable, order by primary key (or the first field) by default
+		orderFieldName := ""
+		fieldCount := sqlEntity.FieldCount()
+		for i := 0; i < fieldCount; i++ {
+			f := sqlEntity.Field(i)
+			if orderFieldName == "" {
+				orderFieldName = f.Name()
+			}
+			if f.PrimaryKey() {
+				orderFieldName = f.Name()
+				break
+			}
+		}
+		if orderFieldName != "" {
+			sqlBuilderOrder.Append(fmt.Sprintf("order by %s", orderFieldName))
+		}
+	}
+
+	paging := &SqlPaging{
+		Fields: sqlEntity.ScanFields(),
+		Table:  sqlEntity.Name(),
+		Order:  sqlBuilderOrder.Query(),
+		Offset: (pageIndex - 1) * size,
+		Size:   size,
+		// the filters are checked by the count above
+		where: func(sqlBuilder SqlBuilder) {
+			s.fillScopedWhere(sqlBuilder, sqlEntity, sqlFilters...)
+		},
+	}
+	sqlBuilder := s.newBuilder()
+	s.dialect.Page(sqlAccess, sqlBuilder, paging)
+
+	return s.scanRows(sqlAccess, sqlBuilder, dbEntity, sqlEntity, row, getPreloads(sqlFilters))
+}
+
+func (s *access) scanRows(sqlAccess SqlAccess, sqlBuilder SqlBuilder, dbEntity interface{}, sqlEntity *entity, row func(index uint64, evt SqlEvent), preloads []string) error {
+	if len(preloads) > 0 {
+		return s.scanPreloadRows(sqlAccess, sqlBuilder, dbEntity, sqlEntity, row, preloads)
+	}
+
+	query := sqlBuilder.Query()
+	args := sqlBuilder.Args()
+	rows, err := sqlAccess.Query(query, args...)
+	if err != nil {
+		return s.dialect.Error(err)
+	}
+	defer rows.Close()
+
+	idx := uint64(0)
+	evt := &event{canceled: false, err: nil}
+	for rows.Next() {
+		err = rows.Scan(sqlEntity.ScanArgs()...)
+		if err != nil {
+			return s.dialect.Error(err)
+		}
+		err = afterScan(sqlAccess, dbEntity)
+		if err != nil {
+			return err
+		}
+
+		if row != nil {
+			row(idx, evt)
+			idx++
+		}
+
+		if evt.canceled {
+			return evt.err
+		}
+	}
+
+	return s.dialect.Error(rows.Err())
+}
+
+// scanPreloadRows loads all the rows and the related entities first, then calls row for each of them
+func (s *access) scanPreloadRows(sqlAccess SqlAccess, sqlBuilder SqlBuilder, dbEntity interface{}, sqlEntity *entity, row func(index uint64, evt SqlEvent), preloads []string) error {
+	rows, err := sqlAccess.Query(sqlBuilder.Query(), sqlBuilder.Args()...)
+	if err != nil {
+		return s.dialect.Error(err)
+	}
+
+	v := reflect.ValueOf(dbEntity).Elem()
+	values := make([]reflect.Value, 0)
+	for rows.Next() {
+		err = rows.Scan(sqlEntity.ScanArgs()...)
+		if err != nil {
+			rows.Close()
+			return s.dialect.Error(err)
+		}
+		err = afterScan(sqlAccess, dbEntity)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		value := reflect.New(v.Type()).Elem()
+		value.Set(v)
+		values = append(values, value)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return s.dialect.Error(err)
+	}
+
+	// the rows are closed before preloading, so that it works in transaction
+	err = s.preload(sqlAccess, sqlEntity.meta, values, preloads)
+	if err != nil {
+		return err
+	}
+
+	evt := &event{canceled: false, err: nil}
+	for idx, value := range values {
+		v.Set(value)
+		if row != nil {
+			row(uint64(idx), evt)
+		}
+
+		if evt.canceled {
+			return evt.err
+		}
+	}
+
+	return nil
+}
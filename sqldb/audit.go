@@ -0,0 +1,107 @@
+package sqldb
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+const (
+	auditCreated   = 1 // created:"true", the time of inserting
+	auditUpdated   = 2 // updated:"true", the time of inserting or updating
+	auditCreatedBy = 3 // createdBy:"true", the user of inserting
+	auditUpdatedBy = 4 // updatedBy:"true", the user of inserting or updating
+)
+
+// Now is the clock of the created, updated and soft delete time, replaceable in tests
+var Now = time.Now
+
+type userContextKey struct {
+}
+
+// WithUser returns the context carrying the current user,
+// which is filled into createdBy and updatedBy fields by the access bound to the context (see SqlAccess.WithContext).
+func WithUser(ctx context.Context, user interface{}) context.Context {
+	return context.WithValue(ctx, userContextKey{}, user)
+}
+
+func UserFromContext(ctx context.Context) (interface{}, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	user := ctx.Value(userContextKey{})
+	if user == nil {
+		return nil, false
+	}
+
+	return user, true
+}
+
+func checkAuditMeta(t reflect.Type, fields []*fieldMeta) error {
+	timeType := reflect.TypeOf(time.Time{})
+	for _, fm := range fields {
+		if fm.audit != auditCreated && fm.audit != auditUpdated {
+			continue
+		}
+		if fm.typ != timeType && fm.typ != reflect.PtrTo(timeType) {
+			return newError("invalid entity (", t.Name(), "): created or updated field ", fm.name, " should be time.Time or *time.Time")
+		}
+	}
+
+	return nil
+}
+
+// fillAudit sets the created/updated time and user of the entity before inserting (or updating),
+// the created ones are only set when empty, so that the values given by caller (e.g. importing) are kept.
+func (s *access) fillAudit(sqlEntity *entity, inserting bool) error {
+	user, hasUser := UserFromContext(s.ctx)
+	now := Now()
+	for i, fm := range sqlEntity.meta.fields {
+		if fm.audit == 0 {
+			continue
+		}
+
+		f := sqlEntity.fields[i]
+		v := reflect.ValueOf(f.address).Elem()
+		var value interface{} = nil
+		switch fm.audit {
+		case auditCreated:
+			if inserting && v.IsZero() {
+				value = now
+			}
+		case auditUpdated:
+			value = now
+		case auditCreatedBy:
+			if inserting && hasUser && v.IsZero() {
+				value = user
+			}
+		case auditUpdatedBy:
+			if hasUser {
+				value = user
+			}
+		}
+		if value == nil {
+			continue
+		}
+
+		uv := reflect.ValueOf(value)
+		if v.Kind() == reflect.Ptr && uv.Type().AssignableTo(v.Type().Elem()) {
+			pv := reflect.New(v.Type().Elem())
+			pv.Elem().Set(uv)
+			uv = pv
+		} else if !uv.Type().AssignableTo(v.Type()) {
+			return newError("invalid value of ", fm.name, ": ", uv.Type(), " is not assignable to ", v.Type())
+		}
+		v.Set(uv)
+		f.value = v.Interface()
+	}
+
+	return nil
+}
+
+// isCreatedAudit reports whether the field at index is kept as inserted when updating
+func (s *entity) isCreatedAudit(index int) bool {
+	audit := s.meta.fields[index].audit
+
+	return audit == auditCreated || audit == auditCreatedBy
+}
@@ -1,12 +1,13 @@
-package mssql
+package sqldb
 
 import (
 	"fmt"
-	"github.com/csby/database/sqldb"
 	"strings"
 )
 
 type builder struct {
+	dialect Dialect
+
 	query              []string
 	args               []interface{}
 	insertFields       []string
@@ -16,7 +17,14 @@ type builder struct {
 	hasSet             bool
 }
 
-func (s *builder) Reset() sqldb.SqlBuilder {
+func NewBuilder(dialect Dialect) SqlBuilder {
+	instance := &builder{dialect: dialect}
+	instance.Reset()
+
+	return instance
+}
+
+func (s *builder) Reset() SqlBuilder {
 	s.query = make([]string, 0)
 	s.args = make([]interface{}, 0)
 	s.insertFields = make([]string, 0)
@@ -28,7 +36,7 @@ func (s *builder) Reset() sqldb.SqlBuilder {
 	return s
 }
 
-func (s *builder) Select(query string, distinct bool) sqldb.SqlBuilder {
+func (s *builder) Select(query string, distinct bool) SqlBuilder {
 	s.query = make([]string, 1)
 	if distinct {
 		s.query[0] = fmt.Sprint("SELECT DISTINCT ", query)
@@ -39,28 +47,28 @@ func (s *builder) Select(query string, distinct bool) sqldb.SqlBuilder {
 	return s
 }
 
-func (s *builder) Insert(query string) sqldb.SqlBuilder {
+func (s *builder) Insert(query string) SqlBuilder {
 	s.query = make([]string, 1)
-	s.query[0] = fmt.Sprint("INSERT ", query)
+	s.query[0] = fmt.Sprint("INSERT INTO ", query)
 
 	return s
 }
 
-func (s *builder) Delete(query string) sqldb.SqlBuilder {
+func (s *builder) Delete(query string) SqlBuilder {
 	s.query = make([]string, 1)
 	s.query[0] = fmt.Sprint("DELETE FROM ", query)
 
 	return s
 }
 
-func (s *builder) Update(query string) sqldb.SqlBuilder {
+func (s *builder) Update(query string) SqlBuilder {
 	s.query = make([]string, 1)
 	s.query[0] = fmt.Sprint("UPDATE ", query)
 
 	return s
 }
 
-func (s *builder) From(query string) sqldb.SqlBuilder {
+func (s *builder) From(query string) SqlBuilder {
 	if s.query == nil {
 		s.query = make([]string, 0)
 	}
@@ -69,7 +77,7 @@ func (s *builder) From(query string) sqldb.SqlBuilder {
 	return s
 }
 
-func (s *builder) Value(filed string, value interface{}) sqldb.SqlBuilder {
+func (s *builder) Value(filed string, value interface{}) SqlBuilder {
 	s.insertFields = append(s.insertFields, filed)
 	s.insertPlaceholders = append(s.insertPlaceholders, s.argName())
 	s.args = append(s.args, value)
@@ -77,23 +85,33 @@ func (s *builder) Value(filed string, value interface{}) sqldb.SqlBuilder {
 	return s
 }
 
-func (s *builder) Set(filed string, value interface{}) sqldb.SqlBuilder {
-	if s.hasSet {
-		s.query = append(s.query, fmt.Sprint(", ", filed, " = "), s.argName())
-	} else {
-		s.hasSet = true
-		s.query = append(s.query, fmt.Sprint("SET ", filed, " = ", s.argName()))
-	}
-
+func (s *builder) Set(filed string, value interface{}) SqlBuilder {
 	if s.args == nil {
 		s.args = make([]interface{}, 0)
 	}
+
+	prefix := "SET "
+	if s.hasSet {
+		prefix = ", "
+	}
+	s.hasSet = true
+
+	expr, ok := value.(*Expression)
+	if ok {
+		query := expr.expand(s.argName, func(arg interface{}) {
+			s.args = append(s.args, arg)
+		})
+		s.query = append(s.query, fmt.Sprint(prefix, filed, " = ", query))
+		return s
+	}
+
+	s.query = append(s.query, fmt.Sprint(prefix, filed, " = ", s.argName()))
 	s.args = append(s.args, value)
 
 	return s
 }
 
-func (s *builder) WhereFormatAnd(format string, a ...interface{}) sqldb.SqlBuilder {
+func (s *builder) WhereFormatAnd(format string, a ...interface{}) SqlBuilder {
 	if s.query == nil {
 		s.query = make([]string, 0)
 	}
@@ -110,7 +128,7 @@ func (s *builder) WhereFormatAnd(format string, a ...interface{}) sqldb.SqlBuild
 	return s
 }
 
-func (s *builder) WhereFormatOr(format string, a ...interface{}) sqldb.SqlBuilder {
+func (s *builder) WhereFormatOr(format string, a ...interface{}) SqlBuilder {
 	if s.query == nil {
 		s.query = make([]string, 0)
 	}
@@ -127,7 +145,7 @@ func (s *builder) WhereFormatOr(format string, a ...interface{}) sqldb.SqlBuilde
 	return s
 }
 
-func (s *builder) WhereFormat(format string, a ...interface{}) sqldb.SqlBuilder {
+func (s *builder) WhereFormat(format string, a ...interface{}) SqlBuilder {
 	if s.query == nil {
 		s.query = make([]string, 0)
 	}
@@ -144,7 +162,7 @@ func (s *builder) WhereFormat(format string, a ...interface{}) sqldb.SqlBuilder
 	return s
 }
 
-func (s *builder) WhereAnd(query string, args ...interface{}) sqldb.SqlBuilder {
+func (s *builder) WhereAnd(query string, args ...interface{}) SqlBuilder {
 	if s.query == nil {
 		s.query = make([]string, 0)
 	}
@@ -164,7 +182,7 @@ func (s *builder) WhereAnd(query string, args ...interface{}) sqldb.SqlBuilder {
 	return s
 }
 
-func (s *builder) WhereOr(query string, args ...interface{}) sqldb.SqlBuilder {
+func (s *builder) WhereOr(query string, args ...interface{}) SqlBuilder {
 	if s.query == nil {
 		s.query = make([]string, 0)
 	}
@@ -184,7 +202,7 @@ func (s *builder) WhereOr(query string, args ...interface{}) sqldb.SqlBuilder {
 	return s
 }
 
-func (s *builder) Where(query string, args ...interface{}) sqldb.SqlBuilder {
+func (s *builder) Where(query string, args ...interface{}) SqlBuilder {
 	if s.query == nil {
 		s.query = make([]string, 0)
 	}
@@ -204,7 +222,7 @@ func (s *builder) Where(query string, args ...interface{}) sqldb.SqlBuilder {
 	return s
 }
 
-func (s *builder) Order(query string) sqldb.SqlBuilder {
+func (s *builder) Order(query string) SqlBuilder {
 	if s.query == nil {
 		s.query = make([]string, 0)
 	}
@@ -218,7 +236,7 @@ func (s *builder) Order(query string) sqldb.SqlBuilder {
 	return s
 }
 
-func (s *builder) Append(query string, args ...interface{}) sqldb.SqlBuilder {
+func (s *builder) Append(query string, args ...interface{}) SqlBuilder {
 	if s.query == nil {
 		s.query = make([]string, 0)
 	}
@@ -232,7 +250,7 @@ func (s *builder) Append(query string, args ...interface{}) sqldb.SqlBuilder {
 	return s
 }
 
-func (s *builder) AppendFormat(format string, a ...interface{}) sqldb.SqlBuilder {
+func (s *builder) AppendFormat(format string, a ...interface{}) SqlBuilder {
 	if s.query == nil {
 		s.query = make([]string, 0)
 	}
@@ -286,7 +304,7 @@ func (s *builder) formatArgs(args []interface{}) []interface{} {
 }
 
 func (s *builder) argName() string {
-	return fmt.Sprintf("@p%d", len(s.args)+1)
+	return s.dialect.Placeholder(len(s.args) + 1)
 }
 
 func (s *builder) ArgName() string {
@@ -0,0 +1,147 @@
+package sqldb
+
+import (
+	"strconv"
+	"strings"
+)
+
+// the generic kinds of column types, which are mapped to the native types by TableCreator
+const (
+	ColumnString   = "string"
+	ColumnText     = "text"
+	ColumnSmallInt = "smallint"
+	ColumnInt      = "int"
+	ColumnBigInt   = "bigint"
+	ColumnBool     = "bool"
+	ColumnFloat    = "float"
+	ColumnDouble   = "double"
+	ColumnDecimal  = "decimal"
+	ColumnDate     = "date"
+	ColumnTime     = "time"
+	ColumnDateTime = "datetime"
+	ColumnBinary   = "binary"
+	ColumnBlob     = "blob"
+	ColumnGuid     = "guid"
+)
+
+type SqlColumnType struct {
+	Kind      string `json:"kind" note:"通用类型"`
+	Length    int    `json:"length" note:"字符或字节长度(string, binary)"`
+	Precision int    `json:"precision" note:"精度(decimal)"`
+	Scale     int    `json:"scale" note:"小数位数(decimal)"`
+}
+
+// ColumnTypeOf returns the generic type of column read by Columns of any database,
+// the unknown types are text.
+func ColumnTypeOf(column *SqlColumn) *SqlColumnType {
+	dataType := strings.ToLower(strings.TrimSpace(column.DataType))
+	if index := strings.IndexAny(dataType, "( "); index > 0 {
+		dataType = dataType[:index]
+	}
+	args := columnTypeArgs(column.Type)
+
+	t := &SqlColumnType{Kind: ColumnText}
+	switch dataType {
+	case "char", "varchar", "nchar", "nvarchar", "varchar2", "nvarchar2", "character":
+		if len(args) < 1 || args[0] <= 0 {
+			break
+		}
+		// the length of national characters is bytes in sql server and oracle, which is larger than enough
+		t.Kind, t.Length = ColumnString, args[0]
+	case "tinyint":
+		t.Kind = ColumnSmallInt
+		if len(args) > 0 && args[0] == 1 {
+			// tinyint(1) of mysql
+			t.Kind = ColumnBool
+		}
+	case "smallint":
+		t.Kind = ColumnSmallInt
+	case "int", "integer", "mediumint":
+		t.Kind = ColumnInt
+	case "bigint":
+		t.Kind = ColumnBigInt
+	case "bit", "bool", "boolean":
+		t.Kind = ColumnBool
+		if dataType == "bit" && len(args) > 0 && args[0] > 1 {
+			t.Kind = ColumnBigInt
+		}
+	case "real", "binary_float":
+		t.Kind = ColumnFloat
+	case "float", "double", "binary_double":
+		t.Kind = ColumnDouble
+	case "money":
+		t.Kind, t.Precision, t.Scale = ColumnDecimal, 19, 4
+	case "smallmoney":
+		t.Kind, t.Precision, t.Scale = ColumnDecimal, 10, 4
+	case "decimal", "dec", "numeric", "number":
+		t.Kind, t.Precision, t.Scale = ColumnDecimal, 38, 10
+		if column.Precision != nil && *column.Precision > 0 {
+			t.Precision, t.Scale = *column.Precision, 0
+			if column.Scale != nil {
+				t.Scale = *column.Scale
+			}
+		} else if len(args) > 1 && dataType != "number" {
+			t.Precision, t.Scale = args[0], args[1]
+		}
+		if t.Precision > 38 {
+			// the most of sql server and oracle
+			t.Precision = 38
+		}
+		if t.Scale > t.Precision {
+			t.Scale = t.Precision
+		}
+		if t.Scale == 0 && t.Precision < 19 && dataType == "number" {
+			// the integers of oracle
+			switch {
+			case t.Precision < 5:
+				t.Kind = ColumnSmallInt
+			case t.Precision < 10:
+				t.Kind = ColumnInt
+			default:
+				t.Kind = ColumnBigInt
+			}
+		}
+	case "date":
+		t.Kind = ColumnDate
+	case "time":
+		t.Kind = ColumnTime
+	case "datetime", "datetime2", "smalldatetime", "datetimeoffset", "timestamp":
+		t.Kind = ColumnDateTime
+	case "binary", "varbinary", "raw":
+		t.Kind = ColumnBlob
+		if len(args) > 0 && args[0] > 0 {
+			t.Kind, t.Length = ColumnBinary, args[0]
+		}
+	case "blob", "tinyblob", "mediumblob", "longblob", "image", "bytea":
+		t.Kind = ColumnBlob
+	case "uniqueidentifier", "uuid":
+		t.Kind = ColumnGuid
+	}
+
+	return t
+}
+
+// columnTypeArgs returns the numbers in parentheses of type, e.g. 10 and 2 of "decimal(10, 2)", -1 for max
+func columnTypeArgs(typeName string) []int {
+	begin := strings.Index(typeName, "(")
+	end := strings.LastIndex(typeName, ")")
+	if begin < 0 || end < begin {
+		return nil
+	}
+
+	args := make([]int, 0, 2)
+	for _, item := range strings.Split(typeName[begin+1:end], ",") {
+		item = strings.TrimSpace(item)
+		if strings.EqualFold(item, "max") {
+			args = append(args, -1)
+			continue
+		}
+		n, err := strconv.Atoi(item)
+		if err != nil {
+			return args
+		}
+		args = append(args, n)
+	}
+
+	return args
+}
@@ -0,0 +1,53 @@
+package sqldb
+
+import (
+	"testing"
+)
+
+func TestColumnTypeOf(t *testing.T) {
+	precision, scale := 10, 2
+	integer := 9
+	tests := []struct {
+		column *SqlColumn
+		expect SqlColumnType
+	}{
+		{&SqlColumn{DataType: "varchar", Type: "varchar(32)"}, SqlColumnType{Kind: ColumnString, Length: 32}},
+		{&SqlColumn{DataType: "nvarchar", Type: "nvarchar(max)"}, SqlColumnType{Kind: ColumnText}},
+		{&SqlColumn{DataType: "tinyint", Type: "tinyint(1)"}, SqlColumnType{Kind: ColumnBool}},
+		{&SqlColumn{DataType: "bit", Type: "bit"}, SqlColumnType{Kind: ColumnBool}},
+		{&SqlColumn{DataType: "decimal", Type: "decimal(10, 2)", Precision: &precision, Scale: &scale}, SqlColumnType{Kind: ColumnDecimal, Precision: 10, Scale: 2}},
+		{&SqlColumn{DataType: "decimal", Type: "decimal(12,3)"}, SqlColumnType{Kind: ColumnDecimal, Precision: 12, Scale: 3}},
+		{&SqlColumn{DataType: "money", Type: "money"}, SqlColumnType{Kind: ColumnDecimal, Precision: 19, Scale: 4}},
+		{&SqlColumn{DataType: "NUMBER", Type: "NUMBER(9, 0)", Precision: &integer, Scale: new(int)}, SqlColumnType{Kind: ColumnInt, Precision: 9}},
+		{&SqlColumn{DataType: "TIMESTAMP(6)", Type: "TIMESTAMP(6)(11)"}, SqlColumnType{Kind: ColumnDateTime}},
+		{&SqlColumn{DataType: "varbinary", Type: "varbinary(16)"}, SqlColumnType{Kind: ColumnBinary, Length: 16}},
+		{&SqlColumn{DataType: "uniqueidentifier", Type: "uniqueidentifier"}, SqlColumnType{Kind: ColumnGuid}},
+		{&SqlColumn{DataType: "xml", Type: "xml"}, SqlColumnType{Kind: ColumnText}},
+	}
+	for _, test := range tests {
+		actual := ColumnTypeOf(test.column)
+		if *actual != test.expect {
+			t.Errorf("%s: expect=%+v, actual=%+v", test.column.Type, test.expect, *actual)
+		}
+	}
+}
+
+func TestChecksumText(t *testing.T) {
+	decimal := &SqlColumnType{Kind: ColumnDecimal, Precision: 10, Scale: 2}
+	if checksumText(decimal, "12.30") != checksumText(decimal, 12.3) {
+		t.Error("decimal: text and float should be the same")
+	}
+	if checksumText(decimal, "12345678901234567.10") == checksumText(decimal, "12345678901234567.11") {
+		t.Error("decimal: the last digit beyond float64 should be different")
+	}
+	if actual := checksumText(decimal, []byte("-0012.500")); actual != "-12.5" {
+		t.Errorf("decimal: expect -12.5, actual %s", actual)
+	}
+	boolean := &SqlColumnType{Kind: ColumnBool}
+	if checksumText(boolean, true) != checksumText(boolean, int64(1)) {
+		t.Error("bool: true and 1 should be the same")
+	}
+	if checksumText(boolean, nil) == checksumText(boolean, int64(0)) {
+		t.Error("bool: NULL and 0 should be different")
+	}
+}
@@ -0,0 +1,298 @@
+package sqldb
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Converter converts the value of field which is not supported by driver natively,
+// e.g. `sql:"attrs" conv:"json"`, `sql:"tags" conv:"csv"`, `sql:"status" conv:"enum:draft,published,deleted"`.
+type Converter interface {
+	// Value converts the field value into the value written to database
+	Value(value interface{}) (interface{}, error)
+	// Scan converts the value read from database (src) into the field (address)
+	Scan(src interface{}, address interface{}) error
+}
+
+// ConverterFactory creates the converter for the field of type t,
+// args is the text after ':' of the tag, e.g. "draft,published,deleted" of `conv:"enum:draft,published,deleted"`.
+// It is called once per entity type and field.
+type ConverterFactory func(t reflect.Type, args string) (Converter, error)
+
+var converterFactories = &sync.Map{}
+
+func init() {
+	RegisterConverter("json", newJsonConverter)
+	RegisterConverter("csv", newCsvConverter)
+	RegisterConverter("enum", newEnumConverter)
+}
+
+// RegisterConverter registers (or replaces) the converter of name used in conv tag,
+// it should be called before parsing the entities using it.
+func RegisterConverter(name string, factory ConverterFactory) {
+	converterFactories.Store(name, factory)
+}
+
+func newConverter(t reflect.Type, tag string) (Converter, error) {
+	name, args := tag, ""
+	if index := strings.Index(tag, ":"); index >= 0 {
+		name, args = tag[:index], tag[index+1:]
+	}
+
+	v, ok := converterFactories.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("converter '%s' not registered", name)
+	}
+
+	return v.(ConverterFactory)(t, args)
+}
+
+// converterScanner scans the value of database by the converter
+type converterScanner struct {
+	converter Converter
+	address   interface{}
+}
+
+func (s *converterScanner) Scan(src interface{}) error {
+	return s.converter.Scan(src, s.address)
+}
+
+func scanText(src interface{}) (string, bool) {
+	switch v := src.(type) {
+	case nil:
+		return "", false
+	case []byte:
+		return string(v), true
+	case string:
+		return v, true
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
+// jsonConverter saves the field as json text
+type jsonConverter struct {
+}
+
+func newJsonConverter(t reflect.Type, args string) (Converter, error) {
+	return &jsonConverter{}, nil
+}
+
+func (s *jsonConverter) Value(value interface{}) (interface{}, error) {
+	if isNilValue(value) {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+func (s *jsonConverter) Scan(src interface{}, address interface{}) error {
+	v := reflect.ValueOf(address).Elem()
+	text, ok := scanText(src)
+	if !ok || text == "" {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	// reset first, otherwise the keys of map scanned by the previous row are kept
+	v.Set(reflect.Zero(v.Type()))
+	return json.Unmarshal([]byte(text), address)
+}
+
+// csvConverter saves the slice of string, number or bool as separated text, "," by default
+type csvConverter struct {
+	separator string
+}
+
+func newCsvConverter(t reflect.Type, args string) (Converter, error) {
+	if t.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("csv converter: %s is not slice", t)
+	}
+	switch t.Elem().Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+	default:
+		return nil, fmt.Errorf("csv converter: element of %s is not string, number or bool", t)
+	}
+
+	separator := args
+	if separator == "" {
+		separator = ","
+	}
+
+	return &csvConverter{separator: separator}, nil
+}
+
+func (s *csvConverter) Value(value interface{}) (interface{}, error) {
+	if isNilValue(value) {
+		return nil, nil
+	}
+
+	v := reflect.ValueOf(value)
+	items := make([]string, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		items[i] = fmt.Sprint(v.Index(i).Interface())
+	}
+
+	return strings.Join(items, s.separator), nil
+}
+
+func (s *csvConverter) Scan(src interface{}, address interface{}) error {
+	v := reflect.ValueOf(address).Elem()
+	text, ok := scanText(src)
+	if !ok || text == "" {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	items := strings.Split(text, s.separator)
+	slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+	for i, item := range items {
+		err := setText(slice.Index(i), item)
+		if err != nil {
+			return fmt.Errorf("csv converter: %v", err)
+		}
+	}
+	v.Set(slice)
+
+	return nil
+}
+
+func setText(v reflect.Value, text string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(text)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(text, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(text, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(text, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return fmt.Errorf("%s not supported", v.Type())
+	}
+
+	return nil
+}
+
+// enumConverter saves the string field as integer,
+// the names are numbered from 0 in order, or by the given number, e.g. "low=1,middle=5,high=9".
+// The empty string is saved as NULL unless it is one of the names.
+type enumConverter struct {
+	values map[string]int64
+	names  map[int64]string
+}
+
+func newEnumConverter(t reflect.Type, args string) (Converter, error) {
+	if t.Kind() != reflect.String {
+		return nil, fmt.Errorf("enum converter: %s is not string", t)
+	}
+	if args == "" {
+		return nil, fmt.Errorf("enum converter: names not defined, e.g. enum:low,middle,high")
+	}
+
+	converter := &enumConverter{
+		values: make(map[string]int64),
+		names:  make(map[int64]string),
+	}
+	next := int64(0)
+	for _, item := range strings.Split(args, ",") {
+		name := strings.TrimSpace(item)
+		value := next
+		if index := strings.Index(name, "="); index >= 0 {
+			n, err := strconv.ParseInt(strings.TrimSpace(name[index+1:]), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("enum converter: invalid value of '%s': %v", item, err)
+			}
+			name, value = strings.TrimSpace(name[:index]), n
+		}
+		if _, ok := converter.values[name]; ok {
+			return nil, fmt.Errorf("enum converter: duplicate name '%s'", name)
+		}
+		if _, ok := converter.names[value]; ok {
+			return nil, fmt.Errorf("enum converter: duplicate value %d", value)
+		}
+		converter.values[name] = value
+		converter.names[value] = name
+		next = value + 1
+	}
+
+	return converter, nil
+}
+
+func (s *enumConverter) Value(value interface{}) (interface{}, error) {
+	name := reflect.ValueOf(value).String()
+	v, ok := s.values[name]
+	if ok {
+		return v, nil
+	}
+	if name == "" {
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("enum converter: invalid name '%s'", name)
+}
+
+func (s *enumConverter) Scan(src interface{}, address interface{}) error {
+	v := reflect.ValueOf(address).Elem()
+	text, ok := scanText(src)
+	if !ok {
+		v.SetString("")
+		return nil
+	}
+
+	n, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return fmt.Errorf("enum converter: invalid value '%s'", text)
+	}
+	name, ok := s.names[n]
+	if !ok {
+		return fmt.Errorf("enum converter: invalid value %d", n)
+	}
+	v.SetString(name)
+
+	return nil
+}
+
+func isNilValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Map, reflect.Ptr, reflect.Interface, reflect.Slice:
+		return v.IsNil()
+	}
+
+	return false
+}
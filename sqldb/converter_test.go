@@ -0,0 +1,97 @@
+package sqldb
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type tabConverted struct {
+	TabEntityBase
+
+	ID     uint64   `sql:"id" primary:"true"`
+	Scores []int    `sql:"scores" conv:"csv:|"`
+	Code   string   `sql:"code" conv:"upper"`
+	Flags  []bool   `sql:"flags" conv:"csv"`
+	Status string   `sql:"status" conv:"enum:draft,published,deleted=9"`
+	Extra  struct{} `sql:"extra" conv:"json"`
+}
+
+type tabConvertedUnknown struct {
+	TabEntityBase
+
+	ID uint64 `sql:"id" conv:"unknown"`
+}
+
+type upperConverter struct {
+}
+
+func (s upperConverter) Value(value interface{}) (interface{}, error) {
+	return strings.ToUpper(value.(string)), nil
+}
+
+func (s upperConverter) Scan(src interface{}, address interface{}) error {
+	text, _ := scanText(src)
+	*(address.(*string)) = strings.ToLower(text)
+	return nil
+}
+
+func TestConverter(t *testing.T) {
+	RegisterConverter("upper", func(t reflect.Type, args string) (Converter, error) {
+		return upperConverter{}, nil
+	})
+
+	entity := &entity{dialect: testDialect{}}
+	err := entity.Parse(&tabConvertedUnknown{})
+	if err == nil {
+		t.Error("unknown converter should be error")
+	}
+
+	dbEntity := &tabConverted{
+		Scores: []int{1, 2, 3},
+		Code:   "abc",
+		Status: "deleted",
+	}
+	err = entity.Parse(dbEntity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values := entity.Values(); values[2] != "abc" {
+		t.Errorf("values should not be converted on parsing, actual=%v", values)
+	}
+	err = entity.convert()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := entity.Values()
+	expect := []interface{}{uint64(0), "1|2|3", "ABC", nil, int64(9), "{}"}
+	if !reflect.DeepEqual(expect, values) {
+		t.Errorf("values: expect=%v, actual=%v", expect, values)
+	}
+
+	sources := []interface{}{int64(1), []byte("4|5"), "XYZ", "true,false", int64(1), nil}
+	args := entity.ScanArgs()
+	for i, arg := range args {
+		if i == 0 {
+			continue
+		}
+		err = arg.(interface{ Scan(src interface{}) error }).Scan(sources[i])
+		if err != nil {
+			t.Fatal("scan fail:", err)
+		}
+	}
+	if !reflect.DeepEqual(dbEntity.Scores, []int{4, 5}) || dbEntity.Code != "xyz" ||
+		!reflect.DeepEqual(dbEntity.Flags, []bool{true, false}) || dbEntity.Status != "published" {
+		t.Errorf("scan: unexpected %+v", dbEntity)
+	}
+
+	dbEntity.Status = "archived"
+	err = entity.Parse(dbEntity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = entity.convert()
+	if err == nil {
+		t.Error("invalid enum should be error")
+	}
+}
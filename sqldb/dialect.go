@@ -0,0 +1,122 @@
+package sqldb
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	ErrDuplicateKey = errors.New("duplicate key")
+	// ErrStaleEntity is returned when updating by primary key with version but the row was changed (or deleted) by others
+	ErrStaleEntity = errors.New("stale entity")
+)
+
+// Dialect is the difference between databases which the generic access relies on,
+// everything else (entity parsing, filters, orders, CRUD) is shared.
+type Dialect interface {
+	// Quote returns the quoted identifier, e.g. `name`, [name] or "name"
+	Quote(name string) string
+	// Placeholder returns the placeholder of the argument at index (1-based), e.g. ?, @p1 or :1
+	Placeholder(index int) string
+	// Version returns the version number of the database server, 0 if unknown
+	Version(sqlAccess SqlAccess) int
+	// Page builds the statement selecting one page of rows into sqlBuilder
+	Page(sqlAccess SqlAccess, sqlBuilder SqlBuilder, paging *SqlPaging)
+	// Insert executes the insert statement of sqlBuilder and returns the value generated
+	// for autoField, autoField is nil if the table has no auto increment field
+	Insert(sqlAccess SqlAccess, sqlBuilder SqlBuilder, autoField SqlField) (uint64, error)
+	// IsNoRows reports whether err means no rows in result set
+	IsNoRows(err error) bool
+	// Error maps the error of driver to the errors of sqldb, e.g. ErrDuplicateKey, nil stays nil
+	Error(err error) error
+}
+
+// RowsCounter is implemented by the dialects which can get the rows count of a table
+// from statistics instead of scanning it, it is used when counting without filters.
+type RowsCounter interface {
+	TableRows(sqlAccess SqlAccess, tableName string) (uint64, error)
+}
+
+// ResultValuer is implemented by the dialects which convert the values of driver into readable values in QueryResult,
+// e.g. the bytes of BIT or UNIQUEIDENTIFIER, the values not converted are returned as they are.
+type ResultValuer interface {
+	ResultValue(column *SqlSelectColumn, value interface{}) interface{}
+}
+
+// BatchInserter is implemented by the dialects which insert multiple rows in one statement,
+// e.g. INSERT INTO t (a, b) VALUES (?, ?), (?, ?), it returns the limits of rows and arguments (0 if unlimited) in one statement.
+// The rows are inserted one by one otherwise.
+type BatchInserter interface {
+	BatchLimit() (rows, args int)
+}
+
+// TableCreator is implemented by the dialects which create the tables copied from other databases
+type TableCreator interface {
+	// ColumnType returns the native type of the generic type, e.g. nvarchar(32) of string with length 32
+	ColumnType(t *SqlColumnType) string
+	// AutoIncrement returns the definition following the type of auto increment column, e.g. AUTO_INCREMENT,
+	// the primary key is not declared again if it contains PRIMARY KEY.
+	AutoIncrement() string
+}
+
+// IdentityInserter is implemented by the dialects which should be told before inserting
+// the values of auto increment column, e.g. SET IDENTITY_INSERT of sql server.
+// It is called in the transaction of inserting, on before and off after.
+type IdentityInserter interface {
+	IdentityInsert(sqlAccess SqlAccess, tableName string, on bool) error
+}
+
+// TableNamer is implemented by the dialects which qualify the table name differently,
+// the name is quoted and qualified by the schema (if not empty) by default.
+type TableNamer interface {
+	TableName(table *SqlTable) string
+}
+
+// SnapshotReader is implemented by the dialects which read a consistent snapshot by other than
+// the default read-only transaction, e.g. the snapshot isolation of sql server.
+type SnapshotReader interface {
+	SnapshotOptions() *sql.TxOptions
+}
+
+// LiteralFormatter is implemented by the dialects which write the literal of value differently,
+// it returns false for the values formatted by default: NULL, 1 and 0 of bool, numbers, 'text' (quotes doubled),
+// 'yyyy-MM-dd HH:mm:ss.SSSSSS' of time and X'hex' of bytes.
+type LiteralFormatter interface {
+	Literal(value interface{}) (string, bool)
+}
+
+// ChunkHasher is implemented by the dialects which hash the rows in database, it returns the aggregate expression
+// of the hash of quoted fields, e.g. BIT_XOR(CRC32(...)), which is compared with the same dialect only.
+type ChunkHasher interface {
+	ChunkHash(fields []string) string
+}
+
+// ReplicaLagger is implemented by the dialects which get the replication lag of replica (see SqlRouter),
+// it returns 0 if the database is not a replica.
+type ReplicaLagger interface {
+	ReplicaLag(sqlAccess SqlAccess) (time.Duration, error)
+}
+
+// PrimaryDetector is implemented by the dialects which tell the writable primary from the standby or read-only hosts,
+// e.g. the old master of mysql set read only after failover. The hosts are considered primary otherwise.
+type PrimaryDetector interface {
+	IsPrimary(sqlAccess SqlAccess) (bool, error)
+}
+
+type SqlPaging struct {
+	Fields string // quoted fields to select
+	Table  string // quoted table name
+	Order  string // order clause, always present, e.g. "order by `id` ASC"
+	Offset uint64
+	Size   uint64
+
+	where func(sqlBuilder SqlBuilder)
+}
+
+// Where appends the where clause of the select to sqlBuilder
+func (s *SqlPaging) Where(sqlBuilder SqlBuilder) {
+	if s.where != nil {
+		s.where(sqlBuilder)
+	}
+}
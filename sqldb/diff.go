@@ -0,0 +1,574 @@
+package sqldb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+	DiffUpdate = "update"
+
+	diffMaxRows = 1000
+)
+
+var (
+	// ErrDiffOrder is returned when the rows of source and target are ordered by the key differently,
+	// e.g. the text keys in case-insensitive collation
+	ErrDiffOrder = errors.New("rows not ordered by key")
+)
+
+type DiffOptions struct {
+	Columns   []string `json:"columns" note:"比较的列(主键除外), 为空时比较两边共有的全部列"`
+	ChunkSize int      `json:"chunkSize" note:"分块比较的每块行数, 先在数据库中计算每块的行数和哈希, 只逐行比较不一致的块; 仅用于同类数据库(方言支持ChunkHasher)且单列主键, 为0时逐行比较"`
+	MaxRows   int      `json:"maxRows" note:"结果中保留的差异行数, 默认1000, 小于0时不保留"`
+
+	// Script receives the statements (separated by ";\n") making target same as source
+	Script io.Writer `json:"-"`
+}
+
+type DiffRow struct {
+	Kind    string        `json:"kind" note:"差异类型: insert(目标缺少), delete(目标多余), update(值不同)"`
+	Key     []interface{} `json:"key" note:"主键值"`
+	Columns []string      `json:"columns,omitempty" note:"值不同的列(update)"`
+	Source  []interface{} `json:"source,omitempty" note:"源行的值, 与结果的列对应"`
+	Target  []interface{} `json:"target,omitempty" note:"目标行的值, 与结果的列对应"`
+}
+
+type DiffResult struct {
+	Table          string     `json:"table" note:"表名"`
+	Keys           []string   `json:"keys" note:"主键列"`
+	Columns        []string   `json:"columns" note:"比较的列(包括主键)"`
+	SourceRows     uint64     `json:"sourceRows" note:"源表行数"`
+	TargetRows     uint64     `json:"targetRows" note:"目标表行数"`
+	Inserted       uint64     `json:"inserted" note:"目标缺少的行数"`
+	Deleted        uint64     `json:"deleted" note:"目标多余的行数"`
+	Changed        uint64     `json:"changed" note:"值不同的行数"`
+	Chunks         uint64     `json:"chunks" note:"块数(分块比较时)"`
+	ChunksDiffered uint64     `json:"chunksDiffered" note:"不一致的块数(分块比较时)"`
+	Rows           []*DiffRow `json:"rows" note:"差异行, 最多MaxRows行"`
+}
+
+// Equal reports whether no differences found
+func (s *DiffResult) Equal() bool {
+	return s.Inserted == 0 && s.Deleted == 0 && s.Changed == 0
+}
+
+// DiffTable compares the rows of table in source and target (the table of same name), which can be different databases,
+// the rows of both are read in snapshot (see NewSnapshotAccess) ordered by the primary key and merged.
+// The values are compared by the generic types (see ColumnTypeOf), e.g. 1.50 of decimal equals to 1.5.
+func DiffTable(source, target SqlDatabase, table *SqlTable, options *DiffOptions) (*DiffResult, error) {
+	if options == nil {
+		options = &DiffOptions{}
+	}
+	if table == nil {
+		return nil, newError("table is nil")
+	}
+	name := copyTableName(table)
+	result := &DiffResult{Table: name, Keys: make([]string, 0), Columns: make([]string, 0), Rows: make([]*DiffRow, 0)}
+
+	sourceColumns, err := source.Columns(table)
+	if err != nil {
+		return nil, err
+	}
+	targetTables, err := target.Tables()
+	if err != nil {
+		return nil, err
+	}
+	var targetTable *SqlTable = nil
+	for _, item := range targetTables {
+		if strings.EqualFold(copyTableName(item), name) {
+			targetTable = item
+			break
+		}
+	}
+	if targetTable == nil {
+		return nil, fmt.Errorf("diff table %s: not found in target", name)
+	}
+	targetColumns, err := target.Columns(targetTable)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &differ{options: options, result: result, maxRows: options.MaxRows}
+	if d.maxRows == 0 {
+		d.maxRows = diffMaxRows
+	}
+	err = d.columns(sourceColumns, targetColumns)
+	if err != nil {
+		return nil, fmt.Errorf("diff table %s: %w", name, err)
+	}
+
+	d.sourceAccess, err = source.NewSnapshotAccess()
+	if err != nil {
+		return nil, err
+	}
+	defer d.sourceAccess.Close()
+	d.targetAccess, err = target.NewSnapshotAccess()
+	if err != nil {
+		return nil, err
+	}
+	defer d.targetAccess.Close()
+	d.src, err = accessOf(d.sourceAccess)
+	if err != nil {
+		return nil, err
+	}
+	d.dst, err = accessOf(d.targetAccess)
+	if err != nil {
+		return nil, err
+	}
+	d.sourceName = d.src.tableName(table)
+	d.targetName = d.dst.tableName(targetTable)
+
+	_, hashed := d.src.dialect.(ChunkHasher)
+	if options.ChunkSize > 0 && hashed && len(result.Keys) == 1 && dialectName(d.src.dialect) == dialectName(d.dst.dialect) {
+		err = d.diffChunks()
+	} else {
+		err = d.diffRange("", nil, true)
+	}
+	if err != nil {
+		return result, fmt.Errorf("diff table %s: %w", name, err)
+	}
+
+	return result, nil
+}
+
+type differ struct {
+	options *DiffOptions
+	result  *DiffResult
+	maxRows int
+
+	sourceAccess  SqlAccess
+	targetAccess  SqlAccess
+	src           *access
+	dst           *access
+	sourceName    string
+	targetName    string
+	sourceColumns []string // the compared columns, keys first
+	targetColumns []string
+	types         []*SqlColumnType
+	keys          int
+}
+
+// columns selects the compared columns: the primary keys of source, and the columns (in both) of options
+func (s *differ) columns(sourceColumns, targetColumns []*SqlColumn) error {
+	targets := make(map[string]*SqlColumn, len(targetColumns))
+	for _, column := range targetColumns {
+		targets[strings.ToLower(column.Name)] = column
+	}
+	selected := make(map[string]bool, len(s.options.Columns))
+	for _, name := range s.options.Columns {
+		selected[strings.ToLower(name)] = true
+	}
+
+	add := func(column *SqlColumn) error {
+		target, ok := targets[strings.ToLower(column.Name)]
+		if !ok {
+			return fmt.Errorf("column %s: not found in target", column.Name)
+		}
+		s.sourceColumns = append(s.sourceColumns, column.Name)
+		s.targetColumns = append(s.targetColumns, target.Name)
+		s.types = append(s.types, ColumnTypeOf(column))
+		s.result.Columns = append(s.result.Columns, column.Name)
+		return nil
+	}
+	for _, column := range sourceColumns {
+		if !column.PrimaryKey {
+			continue
+		}
+		err := add(column)
+		if err != nil {
+			return err
+		}
+		s.result.Keys = append(s.result.Keys, column.Name)
+	}
+	s.keys = len(s.result.Keys)
+	if s.keys < 1 {
+		return newError("no primary key")
+	}
+
+	for _, column := range sourceColumns {
+		name := strings.ToLower(column.Name)
+		if column.PrimaryKey {
+			delete(selected, name)
+			continue
+		}
+		if len(s.options.Columns) > 0 {
+			if !selected[name] {
+				continue
+			}
+			delete(selected, name)
+		} else if _, ok := targets[name]; !ok {
+			continue
+		}
+		err := add(column)
+		if err != nil {
+			return err
+		}
+	}
+	for name := range selected {
+		return fmt.Errorf("column %s: not found", name)
+	}
+
+	return nil
+}
+
+// diffChunks compares the rows count and hash of chunks split by the keys of source, then the rows of differed chunks
+func (s *differ) diffChunks() error {
+	bounds := make([]interface{}, 0)
+	reader, err := s.src.diffRead(s.sourceAccess, s.sourceName, s.sourceColumns[:1], s.types[:1], 1, "", nil)
+	if err != nil {
+		return err
+	}
+	for {
+		err = reader.next()
+		if err != nil || reader.row == nil {
+			break
+		}
+		if reader.count%uint64(s.options.ChunkSize) == 0 {
+			bounds = append(bounds, reader.row[0])
+		}
+	}
+	reader.close()
+	if err != nil {
+		return err
+	}
+	if len(bounds) > 0 && reader.count%uint64(s.options.ChunkSize) == 0 {
+		// the last chunk is not bounded for the rows of target only
+		bounds = bounds[:len(bounds)-1]
+	}
+
+	var lower interface{} = nil
+	for i := 0; i <= len(bounds); i++ {
+		var upper interface{} = nil
+		if i < len(bounds) {
+			upper = bounds[i]
+		}
+		conditions := make([]string, 0, 2)
+		args := make([]interface{}, 0, 2)
+		if i > 0 {
+			args = append(args, lower)
+			conditions = append(conditions, fmt.Sprintf("%s > %s", s.src.dialect.Quote(s.sourceColumns[0]), s.src.dialect.Placeholder(len(args))))
+		}
+		if i < len(bounds) {
+			args = append(args, upper)
+			conditions = append(conditions, fmt.Sprintf("%s <= %s", s.src.dialect.Quote(s.sourceColumns[0]), s.src.dialect.Placeholder(len(args))))
+		}
+		where := strings.Join(conditions, " AND ")
+		lower = upper
+
+		sourceCount, sourceHash, err := s.src.chunkHash(s.sourceAccess, s.sourceName, s.sourceColumns, where, args)
+		if err != nil {
+			return err
+		}
+		targetCount, targetHash, err := s.dst.chunkHash(s.targetAccess, s.targetName, s.targetColumns, where, args)
+		if err != nil {
+			return err
+		}
+		s.result.Chunks++
+		s.result.SourceRows += sourceCount
+		s.result.TargetRows += targetCount
+		if sourceCount == targetCount && sourceHash == targetHash {
+			continue
+		}
+		s.result.ChunksDiffered++
+		err = s.diffRange(where, args, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *access) chunkHash(sqlAccess SqlAccess, tableName string, columns []string, where string, args []interface{}) (uint64, string, error) {
+	fields := make([]string, len(columns))
+	for i, column := range columns {
+		fields[i] = s.dialect.Quote(column)
+	}
+	query := fmt.Sprint("SELECT COUNT(*), ", s.dialect.(ChunkHasher).ChunkHash(fields), " FROM ", tableName)
+	if where != "" {
+		query = fmt.Sprint(query, " WHERE ", where)
+	}
+
+	count := uint64(0)
+	hash := sql.NullString{}
+	err := sqlAccess.QueryRow(query, args...).Scan(&count, &hash)
+	if err != nil {
+		return 0, "", s.dialect.Error(err)
+	}
+
+	return count, hash.String, nil
+}
+
+// diffRange merges the rows of source and target in range of where ordered by keys
+func (s *differ) diffRange(where string, args []interface{}, counting bool) error {
+	src, err := s.src.diffRead(s.sourceAccess, s.sourceName, s.sourceColumns, s.types, s.keys, where, args)
+	if err != nil {
+		return err
+	}
+	defer src.close()
+	dst, err := s.dst.diffRead(s.targetAccess, s.targetName, s.targetColumns, s.types, s.keys, where, args)
+	if err != nil {
+		return err
+	}
+	defer dst.close()
+
+	err = src.next()
+	if err != nil {
+		return err
+	}
+	err = dst.next()
+	if err != nil {
+		return err
+	}
+	for src.row != nil || dst.row != nil {
+		c := 0
+		switch {
+		case src.row == nil:
+			c = 1
+		case dst.row == nil:
+			c = -1
+		default:
+			c = diffCompareKeys(s.types[:s.keys], src.row, dst.row)
+		}
+
+		switch {
+		case c < 0:
+			err = s.insert(src.row)
+			if err == nil {
+				err = src.next()
+			}
+		case c > 0:
+			err = s.delete(dst.row)
+			if err == nil {
+				err = dst.next()
+			}
+		default:
+			err = s.update(src.row, dst.row)
+			if err == nil {
+				err = src.next()
+			}
+			if err == nil {
+				err = dst.next()
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if counting {
+		s.result.SourceRows += src.count
+		s.result.TargetRows += dst.count
+	}
+
+	return nil
+}
+
+func (s *differ) add(row *DiffRow) {
+	if s.maxRows < 0 || len(s.result.Rows) >= s.maxRows {
+		return
+	}
+	s.result.Rows = append(s.result.Rows, row)
+}
+
+func (s *differ) insert(row []interface{}) error {
+	s.result.Inserted++
+	s.add(&DiffRow{Kind: DiffInsert, Key: row[:s.keys], Source: row})
+	if s.options.Script == nil {
+		return nil
+	}
+
+	fields := make([]string, len(s.targetColumns))
+	for i, column := range s.targetColumns {
+		fields[i] = s.dst.dialect.Quote(column)
+	}
+
+	return s.script(s.dst.insertStatements(s.targetName, fields, [][]interface{}{row}, 1)[0])
+}
+
+func (s *differ) delete(row []interface{}) error {
+	s.result.Deleted++
+	s.add(&DiffRow{Kind: DiffDelete, Key: row[:s.keys], Target: row})
+	if s.options.Script == nil {
+		return nil
+	}
+
+	return s.script(fmt.Sprint("DELETE FROM ", s.targetName, " WHERE ", s.keyCondition(row)))
+}
+
+func (s *differ) update(source, target []interface{}) error {
+	columns := make([]string, 0)
+	sets := make([]string, 0)
+	formatter, _ := s.dst.dialect.(LiteralFormatter)
+	for i := s.keys; i < len(s.types); i++ {
+		if checksumText(s.types[i], source[i]) == checksumText(s.types[i], target[i]) {
+			continue
+		}
+		columns = append(columns, s.result.Columns[i])
+		sets = append(sets, fmt.Sprintf("%s = %s", s.dst.dialect.Quote(s.targetColumns[i]), literal(formatter, source[i])))
+	}
+	if len(columns) < 1 {
+		return nil
+	}
+
+	s.result.Changed++
+	s.add(&DiffRow{Kind: DiffUpdate, Key: source[:s.keys], Columns: columns, Source: source, Target: target})
+	if s.options.Script == nil {
+		return nil
+	}
+
+	return s.script(fmt.Sprint("UPDATE ", s.targetName, " SET ", strings.Join(sets, ", "), " WHERE ", s.keyCondition(target)))
+}
+
+func (s *differ) keyCondition(row []interface{}) string {
+	formatter, _ := s.dst.dialect.(LiteralFormatter)
+	conditions := make([]string, s.keys)
+	for i := 0; i < s.keys; i++ {
+		conditions[i] = fmt.Sprintf("%s = %s", s.dst.dialect.Quote(s.targetColumns[i]), literal(formatter, row[i]))
+	}
+
+	return strings.Join(conditions, " AND ")
+}
+
+func (s *differ) script(statement string) error {
+	_, err := fmt.Fprint(s.options.Script, statement, ";\n")
+	return err
+}
+
+type diffReader struct {
+	rows   *sql.Rows
+	result *SqlSelectResult
+	types  []*SqlColumnType
+	keys   int
+	row    []interface{} // the current row, nil at the end
+	count  uint64
+}
+
+// diffRead queries the columns of table in range of where ordered by the keys, which are the first keys columns
+func (s *access) diffRead(sqlAccess SqlAccess, tableName string, columns []string, types []*SqlColumnType, keys int, where string, args []interface{}) (*diffReader, error) {
+	fields := make([]string, len(columns))
+	for i, column := range columns {
+		fields[i] = s.dialect.Quote(column)
+	}
+
+	query := fmt.Sprint("SELECT ", strings.Join(fields, ", "), " FROM ", tableName)
+	if where != "" {
+		query = fmt.Sprint(query, " WHERE ", where)
+	}
+	query = fmt.Sprint(query, " ORDER BY ", strings.Join(fields[:keys], ", "))
+	rows, err := sqlAccess.Query(query, args...)
+	if err != nil {
+		return nil, s.dialect.Error(err)
+	}
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		rows.Close()
+		return nil, s.dialect.Error(err)
+	}
+	result := &SqlSelectResult{}
+	result.Init(columnTypes)
+	s.fillResultValuer(result)
+
+	return &diffReader{rows: rows, result: result, types: types, keys: keys}, nil
+}
+
+// next reads the next row, it fails if the row is not after the previous one
+func (s *diffReader) next() error {
+	previous := s.row
+	s.row = nil
+	if !s.rows.Next() {
+		return s.rows.Err()
+	}
+	err := s.rows.Scan(s.result.Scans()...)
+	if err != nil {
+		return err
+	}
+
+	row := make([]interface{}, len(s.result.Columns))
+	for i, column := range s.result.Columns {
+		_, value := column.GetValue()
+		row[i] = copyValue(s.types[i], value)
+	}
+	if previous != nil && diffCompareKeys(s.types[:s.keys], previous, row) >= 0 {
+		return ErrDiffOrder
+	}
+	s.row = row
+	s.count++
+
+	return nil
+}
+
+func (s *diffReader) close() {
+	s.rows.Close()
+}
+
+func diffCompareKeys(types []*SqlColumnType, a, b []interface{}) int {
+	for i, t := range types {
+		c := diffCompare(t, a[i], b[i])
+		if c != 0 {
+			return c
+		}
+	}
+
+	return 0
+}
+
+// diffCompare compares the values of key, the numbers by value (the decimals exactly) and the others by text
+func diffCompare(t *SqlColumnType, a, b interface{}) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		default:
+			return 1
+		}
+	}
+
+	switch t.Kind {
+	case ColumnDecimal:
+		x, xok := decimalRat(a)
+		y, yok := decimalRat(b)
+		if xok && yok {
+			return x.Cmp(y)
+		}
+	case ColumnBool, ColumnSmallInt, ColumnInt, ColumnBigInt, ColumnFloat, ColumnDouble:
+		x, xok := a.(int64)
+		y, yok := b.(int64)
+		if xok && yok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			default:
+				return 0
+			}
+		}
+		fx, xok := copyNumber(a)
+		fy, yok := copyNumber(b)
+		if xok && yok {
+			return diffSign(fx - fy)
+		}
+	}
+
+	return strings.Compare(checksumText(t, a), checksumText(t, b))
+}
+
+func diffSign(f float64) int {
+	switch {
+	case f < 0:
+		return -1
+	case f > 0:
+		return 1
+	default:
+		return 0
+	}
+}
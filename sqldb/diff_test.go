@@ -0,0 +1,30 @@
+package sqldb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiff_Compare(t *testing.T) {
+	at := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	for i, item := range []struct {
+		kind     string
+		a, b     interface{}
+		expected int
+	}{
+		{ColumnBigInt, int64(9007199254740993), int64(9007199254740992), 1},
+		{ColumnInt, int64(2), "10", -1},
+		{ColumnDecimal, "1.50", 1.5, 0},
+		{ColumnDecimal, "12345678901234567.11", []byte("12345678901234567.1"), 1},
+		{ColumnDecimal, 0.1, "0.10", 0},
+		{ColumnString, "b", "a ", 1},
+		{ColumnString, nil, "a", -1},
+		{ColumnDateTime, at, "2024-05-06 07:08:09", 0},
+		{ColumnGuid, "ABC", "abc", 0},
+	} {
+		actual := diffCompare(&SqlColumnType{Kind: item.kind}, item.a, item.b)
+		if actual != item.expected {
+			t.Errorf("%d: expect %d, actual %d", i, item.expected, actual)
+		}
+	}
+}
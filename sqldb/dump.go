@@ -0,0 +1,680 @@
+package sqldb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DumpNdjson = "ndjson"
+	DumpInsert = "insert"
+
+	dumpVersion   = 1
+	dumpBatchSize = 500
+
+	dumpHeader = "header"
+	dumpTable  = "table"
+	dumpRows   = "rows"
+	dumpSql    = "sql"
+	dumpView   = "view"
+	dumpEnd    = "end"
+)
+
+var (
+	// ErrDumpTruncated is returned by Restore if the archive is not ended, e.g. the dumping was interrupted
+	ErrDumpTruncated = errors.New("dump archive truncated")
+)
+
+type DumpOptions struct {
+	Include   []string `json:"include" note:"包含的表和视图名称模式(通配符*和?, 不区分大小写), 为空时包含全部"`
+	Exclude   []string `json:"exclude" note:"排除的表和视图名称模式(通配符*和?, 不区分大小写)"`
+	Data      string   `json:"data" note:"数据格式: ndjson(默认, 可恢复到其它类型的数据库), insert(批量INSERT语句, 只能恢复到同类数据库)"`
+	SkipData  bool     `json:"skipData" note:"只导出表结构"`
+	SkipViews bool     `json:"skipViews" note:"不导出视图"`
+	BatchSize int      `json:"batchSize" note:"每条记录(或INSERT语句)的行数, 默认500"`
+}
+
+type RestoreOptions struct {
+	DropExisting bool `json:"dropExisting" note:"删除已存在的同名表和视图后恢复, 否则已存在时返回错误"`
+}
+
+// dumpRecord is one line (json) of the archive:
+// header, then table followed by its rows (or sql) for each table, view for each view, and end.
+type dumpRecord struct {
+	Type       string           `json:"type"`
+	Version    int              `json:"version,omitempty"`
+	Dialect    string           `json:"dialect,omitempty"`
+	Created    *time.Time       `json:"created,omitempty"`
+	Name       string           `json:"name,omitempty"`
+	Definition string           `json:"definition,omitempty"`
+	Columns    []*SqlColumn     `json:"columns,omitempty"`
+	Types      []*SqlColumnType `json:"types,omitempty"`
+	Rows       [][]interface{}  `json:"rows,omitempty"`
+	Statements []string         `json:"statements,omitempty"`
+	Tables     int              `json:"tables,omitempty"`
+	Views      int              `json:"views,omitempty"`
+	Count      uint64           `json:"count,omitempty"`
+}
+
+// dialectName identifies the dialect of archive, e.g. mysql.dialect
+func dialectName(dialect Dialect) string {
+	return reflect.TypeOf(dialect).String()
+}
+
+// Dump writes the tables (definition and data) and views of db into w as a json lines archive,
+// the data is read in one read-only transaction (see NewSnapshotAccess).
+// The definition is generated by TableDefinition if db is SqlDefiner, and the generic columns are kept too,
+// so that the tables can be restored into the other kinds of databases (ndjson data only).
+func Dump(db SqlDatabase, w io.Writer, options *DumpOptions) error {
+	if options == nil {
+		options = &DumpOptions{}
+	}
+	batchSize := options.BatchSize
+	if batchSize <= 0 {
+		batchSize = dumpBatchSize
+	}
+	switch options.Data {
+	case DumpNdjson, "":
+	case DumpInsert:
+	default:
+		return fmt.Errorf("dump data format '%s' not supported", options.Data)
+	}
+
+	sqlAccess, err := db.NewSnapshotAccess()
+	if err != nil {
+		return err
+	}
+	defer sqlAccess.Close()
+	a, err := accessOf(sqlAccess)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	encoder := json.NewEncoder(bw)
+	encoder.SetEscapeHTML(false)
+	now := time.Now()
+	err = encoder.Encode(&dumpRecord{Type: dumpHeader, Version: dumpVersion, Dialect: dialectName(a.dialect), Created: &now})
+	if err != nil {
+		return err
+	}
+
+	definer, _ := db.(SqlDefiner)
+	tables, err := db.Tables()
+	if err != nil {
+		return err
+	}
+	end := &dumpRecord{Type: dumpEnd}
+	for _, table := range tables {
+		if !dumpIncluded(table.Name, options) {
+			continue
+		}
+		columns, err := db.Columns(table)
+		if err != nil {
+			return fmt.Errorf("dump table %s: %w", table.Name, err)
+		}
+		record := &dumpRecord{Type: dumpTable, Name: table.Name, Columns: columns, Types: make([]*SqlColumnType, len(columns))}
+		for i, column := range columns {
+			record.Types[i] = ColumnTypeOf(column)
+		}
+		if definer != nil {
+			definition, err := definer.TableDefinition(table)
+			if err == nil {
+				// the generic columns are used if not supported, e.g. oracle
+				record.Definition = definition
+			}
+		}
+		err = encoder.Encode(record)
+		if err != nil {
+			return err
+		}
+		end.Tables++
+
+		if options.SkipData {
+			continue
+		}
+		count, err := a.dumpRows(sqlAccess, encoder, table, record, options.Data, batchSize)
+		if err != nil {
+			return fmt.Errorf("dump table %s: %w", table.Name, err)
+		}
+		end.Count += count
+	}
+
+	if !options.SkipViews {
+		views, err := db.Views()
+		if err != nil {
+			return err
+		}
+		for _, view := range views {
+			if !dumpIncluded(view.Name, options) {
+				continue
+			}
+			if definer == nil {
+				return fmt.Errorf("dump view %s: definition not supported", view.Name)
+			}
+			definition, err := definer.ViewDefinition(view.Name)
+			if err != nil {
+				return fmt.Errorf("dump view %s: %w", view.Name, err)
+			}
+			err = encoder.Encode(&dumpRecord{Type: dumpView, Name: view.Name, Definition: definition})
+			if err != nil {
+				return err
+			}
+			end.Views++
+		}
+	}
+
+	err = encoder.Encode(end)
+	if err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+func dumpIncluded(name string, options *DumpOptions) bool {
+	name = strings.ToLower(name)
+	match := func(patterns []string) bool {
+		for _, pattern := range patterns {
+			ok, _ := path.Match(strings.ToLower(pattern), name)
+			if ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(options.Include) > 0 && !match(options.Include) {
+		return false
+	}
+
+	return !match(options.Exclude)
+}
+
+// dumpRows writes the rows of table in records of batchSize rows
+func (s *access) dumpRows(sqlAccess SqlAccess, encoder *json.Encoder, table *SqlTable, record *dumpRecord, format string, batchSize int) (uint64, error) {
+	fields := make([]string, len(record.Columns))
+	for i, column := range record.Columns {
+		fields[i] = s.dialect.Quote(column.Name)
+	}
+	tableName := s.tableName(table)
+	rows, err := sqlAccess.Query(fmt.Sprint("SELECT ", strings.Join(fields, ", "), " FROM ", tableName))
+	if err != nil {
+		return 0, s.dialect.Error(err)
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, s.dialect.Error(err)
+	}
+	result := &SqlSelectResult{}
+	result.Init(columnTypes)
+	s.fillResultValuer(result)
+
+	maxRows := batchSize
+	if format == DumpInsert {
+		maxRows = 1
+		if inserter, ok := s.dialect.(BatchInserter); ok {
+			maxRows, _ = inserter.BatchLimit()
+		}
+		if maxRows > batchSize {
+			maxRows = batchSize
+		}
+	}
+
+	count := uint64(0)
+	batch := &dumpRecord{Type: dumpRows, Name: record.Name}
+	flush := func() error {
+		if len(batch.Rows) < 1 {
+			return nil
+		}
+		if format == DumpInsert {
+			batch.Type = dumpSql
+			batch.Statements = s.insertStatements(tableName, fields, batch.Rows, maxRows)
+			batch.Rows = nil
+		}
+		err := encoder.Encode(batch)
+		batch = &dumpRecord{Type: dumpRows, Name: record.Name}
+		return err
+	}
+	for rows.Next() {
+		err = rows.Scan(result.Scans()...)
+		if err != nil {
+			return count, s.dialect.Error(err)
+		}
+		values := make([]interface{}, len(result.Columns))
+		for i, column := range result.Columns {
+			_, value := column.GetValue()
+			if format == DumpInsert {
+				values[i] = copyValue(record.Types[i], value)
+			} else {
+				values[i] = dumpValue(record.Types[i], value)
+			}
+		}
+		batch.Rows = append(batch.Rows, values)
+		count++
+		if len(batch.Rows) >= batchSize {
+			err = flush()
+			if err != nil {
+				return count, err
+			}
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return count, s.dialect.Error(err)
+	}
+
+	return count, flush()
+}
+
+// dumpValue returns the value written in json: the time as RFC 3339 and the bytes as base64
+func dumpValue(t *SqlColumnType, value interface{}) interface{} {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	case string:
+		if t.Kind == ColumnBinary || t.Kind == ColumnBlob {
+			return base64.StdEncoding.EncodeToString([]byte(v))
+		}
+	}
+
+	return value
+}
+
+// restoreValue returns the value of json read by the generic type
+func restoreValue(t *SqlColumnType, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		switch t.Kind {
+		case ColumnBinary, ColumnBlob:
+			return base64.StdEncoding.DecodeString(v)
+		case ColumnDate, ColumnDateTime:
+			at, err := time.Parse(time.RFC3339Nano, v)
+			if err == nil {
+				return at, nil
+			}
+		}
+		return v, nil
+	case json.Number:
+		switch t.Kind {
+		case ColumnBool:
+			f, err := v.Float64()
+			return f != 0, err
+		case ColumnSmallInt, ColumnInt, ColumnBigInt:
+			return v.Int64()
+		case ColumnFloat, ColumnDouble:
+			return v.Float64()
+		}
+		return v.String(), nil
+	}
+
+	return value, nil
+}
+
+// insertStatements returns the INSERT statements of rows with literal values, maxRows rows in one statement
+func (s *access) insertStatements(tableName string, fields []string, rows [][]interface{}, maxRows int) []string {
+	if maxRows < 1 {
+		maxRows = 1
+	}
+	formatter, _ := s.dialect.(LiteralFormatter)
+
+	statements := make([]string, 0)
+	for start := 0; start < len(rows); start += maxRows {
+		end := start + maxRows
+		if end > len(rows) {
+			end = len(rows)
+		}
+		values := make([]string, 0, end-start)
+		for _, row := range rows[start:end] {
+			literals := make([]string, len(row))
+			for i, value := range row {
+				literals[i] = literal(formatter, value)
+			}
+			values = append(values, fmt.Sprint("(", strings.Join(literals, ", "), ")"))
+		}
+		statements = append(statements, fmt.Sprint("INSERT INTO ", tableName, " (", strings.Join(fields, ", "), ") VALUES ", strings.Join(values, ", ")))
+	}
+
+	return statements
+}
+
+func literal(formatter LiteralFormatter, value interface{}) string {
+	if value == nil {
+		return "NULL"
+	}
+	if formatter != nil {
+		text, ok := formatter.Literal(value)
+		if ok {
+			return text
+		}
+	}
+
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case time.Time:
+		return fmt.Sprintf("'%s'", v.Format("2006-01-02 15:04:05.999999"))
+	case []byte:
+		return fmt.Sprintf("X'%s'", hex.EncodeToString(v))
+	default:
+		return fmt.Sprintf("'%s'", strings.ReplaceAll(fmt.Sprint(v), "'", "''"))
+	}
+}
+
+// Restore replays the archive of Dump into db in one transaction (the definitions may be committed implicitly, e.g. mysql).
+// The tables are created by the definitions if the archive is dumped from the same kind of database,
+// or by the generic columns (see TableCreator) otherwise, in which case the views are skipped.
+// The existing tables and views are kept and the restoring fails, unless options.DropExisting is set.
+func Restore(db SqlDatabase, r io.Reader, options *RestoreOptions) error {
+	if options == nil {
+		options = &RestoreOptions{}
+	}
+	sqlAccess, err := db.NewAccess(true)
+	if err != nil {
+		return err
+	}
+	defer sqlAccess.Close()
+	a, err := accessOf(sqlAccess)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool)
+	tables, err := db.Tables()
+	if err != nil {
+		return err
+	}
+	for _, table := range tables {
+		existing[strings.ToLower(table.Name)] = true
+	}
+	existingViews := make(map[string]bool)
+	views, err := db.Views()
+	if err != nil {
+		return err
+	}
+	for _, view := range views {
+		existingViews[strings.ToLower(view.Name)] = true
+	}
+
+	reader := bufio.NewReader(r)
+	native := false
+	ended := false
+	var table *dumpRecord = nil
+	identity := ""
+	inserter, _ := a.dialect.(IdentityInserter)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(data) == 0) {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+		record := &dumpRecord{}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(record)
+		if err != nil {
+			return fmt.Errorf("restore line %d: %v", line, err)
+		}
+		if line == 1 && record.Type != dumpHeader {
+			return newError("restore: not a dump archive")
+		}
+
+		// the identity insert is on for one table at most
+		if identity != "" && (record.Type != dumpRows && record.Type != dumpSql || record.Name != table.Name) {
+			err = inserter.IdentityInsert(sqlAccess, identity, false)
+			if err != nil {
+				return a.dialect.Error(err)
+			}
+			identity = ""
+		}
+
+		switch record.Type {
+		case dumpHeader:
+			if record.Version > dumpVersion {
+				return fmt.Errorf("restore: archive version %d not supported", record.Version)
+			}
+			native = record.Dialect == dialectName(a.dialect)
+		case dumpTable:
+			table = record
+			err = a.restoreTable(sqlAccess, record, native, existing[strings.ToLower(copyTableName(&SqlTable{Name: record.Name}))], options)
+		case dumpRows, dumpSql:
+			if table == nil || table.Name != record.Name {
+				return fmt.Errorf("restore line %d: table %s not defined", line, record.Name)
+			}
+			if record.Type == dumpSql && !native {
+				return fmt.Errorf("restore line %d: insert statements of other database", line)
+			}
+			tableName := a.tableName(&SqlTable{Name: copyTableName(&SqlTable{Name: table.Name})})
+			if identity == "" && inserter != nil && restoreIdentity(table.Columns) {
+				err = inserter.IdentityInsert(sqlAccess, tableName, true)
+				if err != nil {
+					return a.dialect.Error(err)
+				}
+				identity = tableName
+			}
+			if record.Type == dumpSql {
+				for _, statement := range record.Statements {
+					_, err = sqlAccess.Exec(statement)
+					if err != nil {
+						break
+					}
+				}
+			} else {
+				err = a.restoreRows(sqlAccess, tableName, table, record.Rows)
+			}
+		case dumpView:
+			if native {
+				err = a.restoreView(sqlAccess, record, existingViews[strings.ToLower(record.Name)], options)
+			}
+		case dumpEnd:
+			ended = true
+		}
+		if err != nil {
+			return fmt.Errorf("restore %s %s: %w", record.Type, record.Name, a.dialect.Error(err))
+		}
+	}
+	if !ended {
+		return ErrDumpTruncated
+	}
+
+	return sqlAccess.Commit()
+}
+
+func restoreIdentity(columns []*SqlColumn) bool {
+	for _, column := range columns {
+		if column.AutoIncrement {
+			return true
+		}
+	}
+
+	return false
+}
+
+// restoreTable creates the table by the definition of the same kind of database, or by the generic columns,
+// the existing one is dropped first if options.DropExisting is set
+func (s *access) restoreTable(sqlAccess SqlAccess, record *dumpRecord, native, existing bool, options *RestoreOptions) error {
+	tableName := s.tableName(&SqlTable{Name: copyTableName(&SqlTable{Name: record.Name})})
+	if existing {
+		if !options.DropExisting {
+			return fmt.Errorf("table %s already exists", record.Name)
+		}
+		_, err := sqlAccess.Exec(fmt.Sprint("DROP TABLE ", tableName))
+		if err != nil {
+			return err
+		}
+	}
+
+	if native && record.Definition != "" {
+		return s.execScript(sqlAccess, record.Definition)
+	}
+	if len(record.Types) != len(record.Columns) {
+		return newError("column types not matched")
+	}
+	query, err := s.createTable(tableName, record.Columns, record.Types, nil)
+	if err != nil {
+		return err
+	}
+	_, err = sqlAccess.Exec(query)
+
+	return err
+}
+
+// restoreView creates the view by the definition, each batch (separated by the lines of GO) of which is one statement,
+// as the ';' may be in the body of view
+func (s *access) restoreView(sqlAccess SqlAccess, record *dumpRecord, existing bool, options *RestoreOptions) error {
+	if existing {
+		if !options.DropExisting {
+			return fmt.Errorf("view %s already exists", record.Name)
+		}
+		_, err := sqlAccess.Exec(fmt.Sprint("DROP VIEW ", s.tableName(&SqlTable{Name: record.Name})))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, batch := range splitBatches(record.Definition) {
+		_, err := sqlAccess.Exec(strings.TrimSuffix(batch, ";"))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *access) restoreRows(sqlAccess SqlAccess, tableName string, table *dumpRecord, rows [][]interface{}) error {
+	indexes := make([]int, len(table.Columns))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	for _, row := range rows {
+		if len(row) != len(table.Columns) {
+			return newError("columns count of row not matched")
+		}
+		for i, value := range row {
+			v, err := restoreValue(table.Types[i], value)
+			if err != nil {
+				return err
+			}
+			row[i] = v
+		}
+	}
+
+	maxRows, maxArgs := 1, 0
+	if inserter, ok := s.dialect.(BatchInserter); ok {
+		maxRows, maxArgs = inserter.BatchLimit()
+	}
+	if maxArgs > 0 && maxRows*len(indexes) > maxArgs {
+		maxRows = maxArgs / len(indexes)
+	}
+	if maxRows < 1 {
+		maxRows = 1
+	}
+	for start := 0; start < len(rows); start += maxRows {
+		end := start + maxRows
+		if end > len(rows) {
+			end = len(rows)
+		}
+		err := s.importExec(sqlAccess, tableName, table.Columns, indexes, rows[start:end])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// execScript executes the statements of script separated by the lines of GO (sql server) or ';' at the end of line
+func (s *access) execScript(sqlAccess SqlAccess, script string) error {
+	for _, statement := range splitScript(script) {
+		_, err := sqlAccess.Exec(statement)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// splitBatches splits script by the lines of GO only
+func splitBatches(script string) []string {
+	batches := make([]string, 0)
+	sb := &strings.Builder{}
+	for _, line := range strings.Split(script, "\n") {
+		if strings.EqualFold(strings.TrimSpace(line), "GO") {
+			if batch := strings.TrimSpace(sb.String()); batch != "" {
+				batches = append(batches, batch)
+			}
+			sb.Reset()
+			continue
+		}
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	if batch := strings.TrimSpace(sb.String()); batch != "" {
+		batches = append(batches, batch)
+	}
+
+	return batches
+}
+
+func splitScript(script string) []string {
+	statements := make([]string, 0)
+	sb := &strings.Builder{}
+	add := func() {
+		statement := strings.TrimSpace(sb.String())
+		if statement != "" {
+			statements = append(statements, statement)
+		}
+		sb.Reset()
+	}
+
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.EqualFold(trimmed, "GO") {
+			add()
+			continue
+		}
+		if strings.HasSuffix(trimmed, ";") {
+			sb.WriteString(strings.TrimSuffix(strings.TrimRight(line, " \t\r"), ";"))
+			add()
+			continue
+		}
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	add()
+
+	return statements
+}
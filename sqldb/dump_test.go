@@ -0,0 +1,67 @@
+package sqldb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDump_SplitScript(t *testing.T) {
+	script := "DROP TABLE IF EXISTS `t`;\nCREATE TABLE `t` (\n  `id` int\n);\nGO\nCREATE VIEW v AS SELECT 1\ngo\n"
+	statements := splitScript(script)
+	expected := []string{
+		"DROP TABLE IF EXISTS `t`",
+		"CREATE TABLE `t` (\n  `id` int\n)",
+		"CREATE VIEW v AS SELECT 1",
+	}
+	if !reflect.DeepEqual(statements, expected) {
+		t.Errorf("unexpected statements %q", statements)
+	}
+}
+
+func TestDump_SplitBatches(t *testing.T) {
+	script := "IF OBJECT_ID('v') IS NOT NULL\nDROP VIEW v\nGO\nCREATE VIEW v AS SELECT 'a;\nb;' AS c;\n"
+	batches := splitBatches(script)
+	expected := []string{
+		"IF OBJECT_ID('v') IS NOT NULL\nDROP VIEW v",
+		"CREATE VIEW v AS SELECT 'a;\nb;' AS c;",
+	}
+	if !reflect.DeepEqual(batches, expected) {
+		t.Errorf("unexpected batches %q", batches)
+	}
+}
+
+func TestDump_Literal(t *testing.T) {
+	at := time.Date(2024, 5, 6, 7, 8, 9, 500000000, time.UTC)
+	for value, expected := range map[interface{}]string{
+		nil:          "NULL",
+		true:         "1",
+		int64(-3):    "-3",
+		1.25:         "1.25",
+		"it's":       "'it''s'",
+		at:           "'2024-05-06 07:08:09.5'",
+		uint64(1000): "1000",
+	} {
+		actual := literal(nil, value)
+		if actual != expected {
+			t.Errorf("%v: expect %s, actual %s", value, expected, actual)
+		}
+	}
+	if actual := literal(nil, []byte{0xff, 1}); actual != "X'ff01'" {
+		t.Errorf("unexpected bytes literal %s", actual)
+	}
+}
+
+func TestDump_Included(t *testing.T) {
+	options := &DumpOptions{Include: []string{"user*", "role"}, Exclude: []string{"*_LOG"}}
+	for name, expected := range map[string]bool{
+		"User":     true,
+		"user_log": false,
+		"role":     true,
+		"roles":    false,
+	} {
+		if actual := dumpIncluded(name, options); actual != expected {
+			t.Errorf("%s: expect %v, actual %v", name, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package sqldb
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+const (
+	encryptAesGcm = "aes-gcm"
+)
+
+var (
+	ErrKeyProviderNotSet = errors.New("key provider not set")
+	ErrKeyNotFound       = errors.New("key not found")
+)
+
+// KeyProvider provides the keys of the fields tagged by encrypt:"aes-gcm",
+// the values are encrypted by the current key, and the id of key is saved with the ciphertext as "<id>:<base64>",
+// so that the values encrypted by the previous keys are still readable after rotating.
+type KeyProvider interface {
+	// CurrentKey returns the key (16, 24 or 32 bytes) for encrypting and the id of it, the id should not contain ':'
+	CurrentKey() (id string, key []byte, err error)
+	// Key returns the key of id for decrypting, ErrKeyNotFound if not exist
+	Key(id string) ([]byte, error)
+}
+
+var (
+	keyProvider      KeyProvider = nil
+	keyProviderMutex             = &sync.RWMutex{}
+)
+
+func SetKeyProvider(provider KeyProvider) {
+	keyProviderMutex.Lock()
+	defer keyProviderMutex.Unlock()
+
+	keyProvider = provider
+}
+
+func getKeyProvider() (KeyProvider, error) {
+	keyProviderMutex.RLock()
+	defer keyProviderMutex.RUnlock()
+
+	if keyProvider == nil {
+		return nil, ErrKeyProviderNotSet
+	}
+
+	return keyProvider, nil
+}
+
+// StaticKeyProvider is the KeyProvider of fixed keys, e.g. loaded from configuration
+type StaticKeyProvider struct {
+	Current string            `json:"current" note:"当前加密密钥ID"`
+	Keys    map[string][]byte `json:"keys" note:"密钥, 键为ID"`
+}
+
+func (s *StaticKeyProvider) CurrentKey() (string, []byte, error) {
+	key, err := s.Key(s.Current)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return s.Current, key, nil
+}
+
+func (s *StaticKeyProvider) Key(id string) ([]byte, error) {
+	key, ok := s.Keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, id)
+	}
+
+	return key, nil
+}
+
+// encryptConverter encrypts the value (converted by inner converter if any) as text,
+// the column name is authenticated with the value, so the ciphertext can not be moved to other columns.
+// The empty value is saved as it is, and the encrypted column can not be used in filter.
+type encryptConverter struct {
+	inner  Converter
+	column string
+}
+
+func newEncryptConverter(t reflect.Type, algorithm, column string, inner Converter) (Converter, error) {
+	if strings.ToLower(algorithm) != encryptAesGcm {
+		return nil, fmt.Errorf("encrypt algorithm '%s' not supported", algorithm)
+	}
+	if inner == nil && t.Kind() != reflect.String && !(t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8) {
+		return nil, fmt.Errorf("encrypt: %s is not string or []byte, use conv to convert it first", t)
+	}
+
+	return &encryptConverter{inner: inner, column: column}, nil
+}
+
+func (s *encryptConverter) Value(value interface{}) (interface{}, error) {
+	var err error
+	if s.inner != nil {
+		value, err = s.inner.Value(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if isNilValue(value) {
+		return nil, nil
+	}
+
+	var plaintext []byte
+	switch v := value.(type) {
+	case string:
+		plaintext = []byte(v)
+	case []byte:
+		plaintext = v
+	default:
+		return nil, fmt.Errorf("encrypt: %T is not string or []byte", value)
+	}
+	if len(plaintext) < 1 {
+		return "", nil
+	}
+
+	text, err := sealText(plaintext, s.column)
+	if err != nil {
+		return nil, err
+	}
+
+	return text, nil
+}
+
+func (s *encryptConverter) Scan(src interface{}, address interface{}) error {
+	text, ok := scanText(src)
+	var plaintext interface{} = nil
+	if ok && text == "" {
+		plaintext = ""
+	} else if ok {
+		data, err := s.decrypt(text)
+		if err != nil {
+			return err
+		}
+		plaintext = data
+	}
+
+	if s.inner != nil {
+		return s.inner.Scan(plaintext, address)
+	}
+
+	v := reflect.ValueOf(address).Elem()
+	switch p := plaintext.(type) {
+	case nil:
+		v.Set(reflect.Zero(v.Type()))
+	case string:
+		v.Set(reflect.Zero(v.Type()))
+	case []byte:
+		if v.Kind() == reflect.String {
+			v.SetString(string(p))
+		} else {
+			v.SetBytes(p)
+		}
+	}
+
+	return nil
+}
+
+func (s *encryptConverter) decrypt(text string) ([]byte, error) {
+	plaintext, err := openText(text, s.column)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt %s: %w", s.column, err)
+	}
+
+	return plaintext, nil
+}
+
+// sealText encrypts plaintext by the current key as "<id>:<base64>", aad is authenticated with it
+func sealText(plaintext []byte, aad string) (string, error) {
+	provider, err := getKeyProvider()
+	if err != nil {
+		return "", err
+	}
+	id, key, err := provider.CurrentKey()
+	if err != nil {
+		return "", err
+	}
+	aead, err := newAesGcm(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return "", err
+	}
+	ciphertext := aead.Seal(nonce, nonce, plaintext, []byte(aad))
+
+	return fmt.Sprintf("%s:%s", id, base64.StdEncoding.EncodeToString(ciphertext)), nil
+}
+
+// openText decrypts the text of sealText by the key of id in it
+func openText(text, aad string) ([]byte, error) {
+	index := strings.Index(text, ":")
+	if index < 0 {
+		return nil, newError("key id not found")
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(text[index+1:])
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := getKeyProvider()
+	if err != nil {
+		return nil, err
+	}
+	key, err := provider.Key(text[:index])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAesGcm(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, newError("invalid ciphertext")
+	}
+	nonce := ciphertext[:aead.NonceSize()]
+
+	return aead.Open(nil, nonce, ciphertext[aead.NonceSize():], []byte(aad))
+}
+
+func newAesGcm(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,94 @@
+package sqldb
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type tabEncrypted struct {
+	TabEntityBase
+
+	ID     uint64   `sql:"id" primary:"true"`
+	Phone  string   `sql:"phone" encrypt:"aes-gcm"`
+	Tags   []string `sql:"tags" conv:"csv" encrypt:"aes-gcm"`
+	Remark string   `sql:"remark" encrypt:"aes-gcm"`
+}
+
+func TestEncrypt(t *testing.T) {
+	provider := &StaticKeyProvider{
+		Current: "k1",
+		Keys: map[string][]byte{
+			"k1": []byte("0123456789abcdef"),
+			"k2": []byte("0123456789abcdef0123456789abcdef"),
+		},
+	}
+	SetKeyProvider(provider)
+	defer SetKeyProvider(nil)
+
+	entity := &entity{dialect: testDialect{}}
+	dbEntity := &tabEncrypted{Phone: "13800000000", Tags: []string{"vip", "new"}}
+	err := entity.Parse(dbEntity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entity.Values()[1] != "13800000000" {
+		t.Errorf("values should not be encrypted on parsing, actual=%v", entity.Values()[1])
+	}
+	err = entity.convert()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := entity.Values()
+	phone, _ := values[1].(string)
+	if !strings.HasPrefix(phone, "k1:") || strings.Contains(phone, "13800000000") {
+		t.Errorf("encrypted phone: unexpected %v", values[1])
+	}
+	if values[3] != "" {
+		t.Errorf("empty remark should not be encrypted, actual=%v", values[3])
+	}
+
+	// rotate the key, the values encrypted by k1 are still readable
+	provider.Current = "k2"
+	err = entity.Parse(&tabEncrypted{Phone: "13900000000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = entity.convert()
+	if err != nil {
+		t.Fatal(err)
+	}
+	phone2, _ := entity.Values()[1].(string)
+	if !strings.HasPrefix(phone2, "k2:") {
+		t.Errorf("encrypted phone by k2: unexpected %v", phone2)
+	}
+
+	scanned := &tabEncrypted{}
+	err = entity.Parse(scanned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources := []interface{}{nil, []byte(phone), values[2], nil}
+	args := entity.ScanArgs()
+	for i := 1; i < len(args); i++ {
+		err = args[i].(interface{ Scan(src interface{}) error }).Scan(sources[i])
+		if err != nil {
+			t.Fatal("scan fail:", err)
+		}
+	}
+	if scanned.Phone != "13800000000" || strings.Join(scanned.Tags, ",") != "vip,new" || scanned.Remark != "" {
+		t.Errorf("decrypted: unexpected %+v", scanned)
+	}
+
+	// the ciphertext of other column is rejected
+	err = args[1].(interface{ Scan(src interface{}) error }).Scan(values[2])
+	if err == nil {
+		t.Error("decrypt the ciphertext of other column should be error")
+	}
+
+	delete(provider.Keys, "k1")
+	err = args[1].(interface{ Scan(src interface{}) error }).Scan(phone)
+	if !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("decrypt by removed key: expect %v, actual=%v", ErrKeyNotFound, err)
+	}
+}
@@ -0,0 +1,211 @@
+package sqldb
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	sqlFieldTagName              = "sql"
+	sqlFieldTagIgnore            = "-"
+	sqlFieldFilterTagName        = "filter"
+	sqlFieldOrderTagName         = "order"
+	sqlFieldOrderValueAsc        = "ASC"
+	sqlFieldOrderValueDesc       = "DESC"
+	sqlFieldOrderValueCustom     = "CUSTOM"
+	sqlFieldAutoIncrementTagName = "auto"
+	sqlFieldPrimaryKeyTagName    = "primary"
+	sqlFieldIndexTagName         = "index"
+	sqlFieldSoftDeleteTagName    = "softdelete"
+	sqlFieldVersionTagName       = "version"
+	sqlFieldCreatedTagName       = "created"
+	sqlFieldUpdatedTagName       = "updated"
+	sqlFieldCreatedByTagName     = "createdBy"
+	sqlFieldUpdatedByTagName     = "updatedBy"
+	sqlFieldConverterTagName     = "conv"
+	sqlFieldEncryptTagName       = "encrypt"
+	sqlFieldRelationTagName      = "rel"
+
+	sqlFunSchemaTagName = "SchemaName"
+	sqlFunTableTagName  = "TableName"
+)
+
+type entity struct {
+	dialect  Dialect
+	name     string
+	fields   fieldCollection
+	selected fieldCollection // the fields scanned, nil means all
+	meta     *entityMeta
+}
+
+func NewEntity(dialect Dialect) SqlEntity {
+	return &entity{dialect: dialect}
+}
+
+// parse the name and fields of database table
+// entity: address of the struct
+func (s *entity) Parse(entity interface{}) error {
+	return s.parse(entity, false)
+}
+
+func (s *entity) ParseFilter(entity interface{}) error {
+	return s.parse(entity, true)
+}
+
+func (s *entity) parse(entity interface{}, filter bool) error {
+	s.name = ""
+	s.fields = make([]*field, 0)
+	s.selected = nil
+	s.meta = nil
+
+	// check kind of entity
+	if entity == nil {
+		return newError("invalid entity: nil")
+	}
+	if reflect.TypeOf(entity).Kind() != reflect.Ptr {
+		return newError("invalid entity: not address")
+	}
+	v := reflect.ValueOf(entity).Elem()
+	if v.Kind() != reflect.Struct {
+		return newError("invalid entity (", v.Type().Name(), "): not struct")
+	}
+
+	meta := getEntityMeta(s.dialect, v.Type(), filter)
+	if meta.err != nil {
+		return meta.err
+	}
+
+	if !filter {
+		name, err := parseTableName(s.dialect, v)
+		if err != nil {
+			return err
+		}
+		s.name = name
+	}
+
+	s.meta = meta
+	s.fields = make([]*field, len(meta.fields))
+	for i, fm := range meta.fields {
+		valueField := v.FieldByIndex(fm.path)
+		f := &field{
+			name:          fm.name,
+			value:         valueField.Interface(),
+			address:       valueField.Addr().Interface(),
+			autoIncrement: fm.autoIncrement,
+			primaryKey:    fm.primaryKey,
+			filter:        fm.filter,
+			order:         fm.order,
+			index:         fm.index,
+		}
+		if fm.converter != nil {
+			f.scan = &converterScanner{converter: fm.converter, address: f.address}
+		}
+		s.fields[i] = f
+	}
+
+	return nil
+}
+
+// convert replaces the values by the converters (conv and encrypt tags) for writing (insert and update),
+// the values of filters are converted by getFilterFields.
+func (s *entity) convert() error {
+	if s.meta == nil {
+		return nil
+	}
+	for i, fm := range s.meta.fields {
+		if fm.converter == nil {
+			continue
+		}
+		f := s.fields[i]
+		value, err := fm.converter.Value(f.value)
+		if err != nil {
+			return newError("invalid value of ", fm.name, ": ", err)
+		}
+		f.value = value
+	}
+
+	return nil
+}
+
+func newError(v ...interface{}) error {
+	return errors.New(fmt.Sprint(v...))
+}
+
+func (s *entity) fieldByName(name string) *field {
+	count := len(s.fields)
+	for i := 0; i < count; i++ {
+		f := s.fields[i]
+		if f.name == name {
+			return f
+		}
+	}
+
+	return &field{}
+}
+
+func (s *entity) Name() string {
+	return s.name
+}
+
+func (s *entity) FieldCount() int {
+	return len(s.fields)
+}
+
+func (s *entity) Field(i int) SqlField {
+	return s.fields[i]
+}
+
+func (s *entity) ScanFields() string {
+	fields := s.fields
+	if s.selected != nil {
+		fields = s.selected
+	} else if s.meta != nil {
+		return s.meta.scanFields
+	}
+
+	sb := &strings.Builder{}
+
+	count := len(fields)
+	if count > 0 {
+		sb.WriteString(fields[0].name)
+
+		for i := 1; i < count; i++ {
+			sb.WriteString(", ")
+			sb.WriteString(fields[i].name)
+		}
+	}
+
+	return sb.String()
+}
+
+func (s *entity) ScanArgs() []interface{} {
+	fields := s.fields
+	if s.selected != nil {
+		fields = s.selected
+	}
+	args := make([]interface{}, 0)
+
+	count := len(fields)
+	for i := 0; i < count; i++ {
+		if fields[i].scan != nil {
+			args = append(args, fields[i].scan)
+		} else {
+			args = append(args, fields[i].address)
+		}
+	}
+
+	return args
+}
+
+func (s *entity) Values() []interface{} {
+	values := make([]interface{}, 0)
+
+	count := len(s.fields)
+	for i := 0; i < count; i++ {
+		values = append(values, s.fields[i].value)
+	}
+
+	return values
+}
@@ -1,12 +1,15 @@
-package mysql
+package sqldb
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 )
 
 func TestParse(t *testing.T) {
-	entity := &entity{}
+	entity := &entity{dialect: testDialect{}}
 	err := entity.Parse(nil)
 	if err == nil {
 		t.Error("empty struct should be error")
@@ -67,6 +70,58 @@ func TestParse(t *testing.T) {
 
 }
 
+func TestParse_Cache(t *testing.T) {
+	entity := &entity{dialect: testDialect{}}
+
+	entity1 := &TabEntity2{UserID: 1, UserName: "Name 1"}
+	err := entity.Parse(entity1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := entity.meta
+
+	entity2 := &TabEntity2{UserID: 2, UserName: "Name 2"}
+	err = entity.Parse(entity2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entity.meta != meta {
+		t.Error("meta of the same type should be cached")
+	}
+	checkField(t, entity.fieldByName("`userId`"), "`userId`", "in", entity2.UserID, &entity2.UserID)
+	checkField(t, entity.fieldByName("`userName`"), "`userName`", "like", entity2.UserName, &entity2.UserName)
+
+	err = entity.ParseFilter(entity2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entity.meta == meta {
+		t.Error("meta of filter should not be shared with entity")
+	}
+
+	for _, tenant := range []string{"a", "b"} {
+		err = entity.Parse(&tabSharded{Tenant: tenant})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := "`tabSharded_" + tenant + "`"; entity.Name() != expected {
+			t.Errorf("table name of tenant %s: expect %s, actual %s", tenant, expected, entity.Name())
+		}
+	}
+	err = entity.Parse(&tabSharded{})
+	if err == nil {
+		t.Error("empty table name of value should be error")
+	}
+
+	entity22 := &tabEntity22{}
+	for i := 0; i < 2; i++ {
+		err = entity.Parse(entity22)
+		if err == nil {
+			t.Error("empty table name should be error")
+		}
+	}
+}
+
 func checkField(t *testing.T, entityField *field, name, filter string, value, address interface{}) {
 	if entityField.name != name {
 		t.Error("field name error: expect=", name, ", actual=", entityField.name)
@@ -143,3 +198,50 @@ type tabEntity22 struct {
 func (s tabEntity22) TableName() string {
 	return ""
 }
+
+type tabSharded struct {
+	ID     uint64 `sql:"id" primary:"true"`
+	Tenant string `sql:"-"`
+}
+
+func (s tabSharded) TableName() string {
+	if s.Tenant == "" {
+		return ""
+	}
+
+	return "tabSharded_" + s.Tenant
+}
+
+type testDialect struct {
+}
+
+func (s testDialect) Quote(name string) string {
+	return fmt.Sprintf("`%s`", name)
+}
+
+func (s testDialect) Placeholder(index int) string {
+	return "?"
+}
+
+func (s testDialect) Version(sqlAccess SqlAccess) int {
+	return 0
+}
+
+func (s testDialect) Page(sqlAccess SqlAccess, sqlBuilder SqlBuilder, paging *SqlPaging) {
+	sqlBuilder.Select(paging.Fields, false).From(paging.Table)
+	paging.Where(sqlBuilder)
+	sqlBuilder.Append(paging.Order)
+	sqlBuilder.Append("LIMIT ?, ?", paging.Offset, paging.Size)
+}
+
+func (s testDialect) Insert(sqlAccess SqlAccess, sqlBuilder SqlBuilder, autoField SqlField) (uint64, error) {
+	return 0, nil
+}
+
+func (s testDialect) IsNoRows(err error) bool {
+	return errors.Is(err, sql.ErrNoRows)
+}
+
+func (s testDialect) Error(err error) error {
+	return err
+}
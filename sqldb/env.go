@@ -0,0 +1,163 @@
+package sqldb
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EnvName returns the name of environment variable of the field, e.g. DB_HOST of prefix DB and name host
+func EnvName(prefix, name string) string {
+	name = strings.ToUpper(name)
+	if prefix == "" || strings.HasSuffix(prefix, "_") {
+		return prefix + name
+	}
+
+	return prefix + "_" + name
+}
+
+// LoadEnv sets the fields of the struct pointed by v from the environment variables named by EnvName
+// of prefix and the json names, the fields without variables are not changed.
+// The variables of slices are separated by comma, e.g. a,b or host1:3306,host2 for SqlEndpoint,
+// and maps of strings are in query format, e.g. a=1&b=2. The others are in json.
+func LoadEnv(prefix string, v interface{}) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return newError("invalid value: not pointer of struct")
+	}
+	value = value.Elem()
+	valueType := value.Type()
+
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		envName := EnvName(prefix, name)
+		text, ok := os.LookupEnv(envName)
+		if !ok {
+			continue
+		}
+		err := setEnv(value.Field(i), strings.TrimSpace(text))
+		if err != nil {
+			return fmt.Errorf("environment variable %s: %v", envName, err)
+		}
+	}
+
+	return nil
+}
+
+func setEnv(value reflect.Value, text string) error {
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(text)
+		return nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return err
+		}
+		value.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(text, 10, 64)
+		if err != nil {
+			return err
+		}
+		value.SetUint(n)
+		return nil
+	case reflect.Slice:
+		if strings.HasPrefix(text, "[") {
+			break
+		}
+		items := make([]string, 0)
+		for _, item := range strings.Split(text, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		switch value.Type().Elem() {
+		case reflect.TypeOf(""):
+			value.Set(reflect.ValueOf(items))
+			return nil
+		case reflect.TypeOf(&SqlEndpoint{}):
+			endpoints := make([]*SqlEndpoint, len(items))
+			for i, item := range items {
+				endpoint, err := parseEndpoint(item)
+				if err != nil {
+					return err
+				}
+				endpoints[i] = endpoint
+			}
+			value.Set(reflect.ValueOf(endpoints))
+			return nil
+		}
+	case reflect.Map:
+		if strings.HasPrefix(text, "{") || value.Type() != reflect.TypeOf(map[string]string{}) {
+			break
+		}
+		query, err := url.ParseQuery(text)
+		if err != nil {
+			return err
+		}
+		params := make(map[string]string, len(query))
+		for k := range query {
+			params[k] = query.Get(k)
+		}
+		value.Set(reflect.ValueOf(params))
+		return nil
+	}
+
+	return json.Unmarshal([]byte(text), value.Addr().Interface())
+}
+
+// parseEndpoint parses host:port, or host only, the IPv6 address is bracketed with port, e.g. [::1]:3306
+func parseEndpoint(text string) (*SqlEndpoint, error) {
+	endpoint := &SqlEndpoint{Host: text}
+	colons := strings.Count(text, ":")
+	if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+		endpoint.Host = text[1 : len(text)-1]
+		return endpoint, nil
+	}
+	if colons == 0 {
+		return endpoint, nil
+	}
+	if colons > 1 && !strings.HasPrefix(text, "[") {
+		// the bare IPv6 address without port
+		if net.ParseIP(text) == nil {
+			return nil, fmt.Errorf("invalid endpoint '%s', bracket the IPv6 address with port, e.g. [::1]:3306", text)
+		}
+		return endpoint, nil
+	}
+
+	host, port, err := net.SplitHostPort(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint '%s': %v", text, err)
+	}
+	endpoint.Host = host
+	endpoint.Port, err = strconv.Atoi(port)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port of '%s'", text)
+	}
+
+	return endpoint, nil
+}
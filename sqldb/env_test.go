@@ -0,0 +1,80 @@
+package sqldb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLoadEnv(t *testing.T) {
+	value := &struct {
+		Host     string            `json:"host"`
+		Port     int               `json:"port"`
+		Enabled  bool              `json:"enabled"`
+		Owners   []string          `json:"owners"`
+		Params   map[string]string `json:"params"`
+		Hosts    []*SqlEndpoint    `json:"hosts"`
+		Routing  *SqlRouting       `json:"routing"`
+		Password string            `json:"-"`
+	}{Host: "kept", Password: "kept"}
+
+	t.Setenv("DB_PORT", "3306")
+	t.Setenv("DB_ENABLED", "true")
+	t.Setenv("DB_OWNERS", "a, b")
+	t.Setenv("DB_PARAMS", "tls=true&loc=UTC")
+	t.Setenv("DB_HOSTS", "h1:3307,[::1]:3308,h3")
+	t.Setenv("DB_ROUTING", `{"policy": "least-latency", "maxLag": 5}`)
+	t.Setenv("DB_PASSWORD", "ignored")
+	err := LoadEnv("DB", value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if value.Host != "kept" || value.Port != 3306 || !value.Enabled || value.Password != "kept" {
+		t.Errorf("unexpected value %+v", value)
+	}
+	if !reflect.DeepEqual(value.Owners, []string{"a", "b"}) {
+		t.Errorf("unexpected owners %v", value.Owners)
+	}
+	if !reflect.DeepEqual(value.Params, map[string]string{"tls": "true", "loc": "UTC"}) {
+		t.Errorf("unexpected params %v", value.Params)
+	}
+	if len(value.Hosts) != 3 || *value.Hosts[1] != (SqlEndpoint{Host: "::1", Port: 3308}) || *value.Hosts[2] != (SqlEndpoint{Host: "h3"}) {
+		t.Errorf("unexpected hosts %v %v %v", value.Hosts[0], value.Hosts[1], value.Hosts[2])
+	}
+	if value.Routing == nil || value.Routing.Policy != RoutingLeastLatency || value.Routing.MaxLag != 5 {
+		t.Errorf("unexpected routing %v", value.Routing)
+	}
+
+	t.Setenv("DB_PORT", "x")
+	if LoadEnv("DB", value) == nil {
+		t.Error("expect error of invalid port")
+	}
+}
+
+func TestParseEndpoint(t *testing.T) {
+	tests := map[string]SqlEndpoint{
+		"h1":            {Host: "h1"},
+		"h1:3307":       {Host: "h1", Port: 3307},
+		"10.0.0.1:3307": {Host: "10.0.0.1", Port: 3307},
+		"[::1]:3308":    {Host: "::1", Port: 3308},
+		"[fe80::1]":     {Host: "fe80::1"},
+		"::1":           {Host: "::1"},
+		"fe80::1:3308":  {Host: "fe80::1:3308"},
+	}
+	for text, expect := range tests {
+		endpoint, err := parseEndpoint(text)
+		if err != nil {
+			t.Errorf("%s: %v", text, err)
+			continue
+		}
+		if *endpoint != expect {
+			t.Errorf("%s: expect %+v, actual %+v", text, expect, *endpoint)
+		}
+	}
+
+	for _, text := range []string{"h1:x", "h1:", "[::1]3308", "[::1:3308", "db:1:2"} {
+		if _, err := parseEndpoint(text); err == nil {
+			t.Errorf("%s: expect error", text)
+		}
+	}
+}
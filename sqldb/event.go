@@ -1,4 +1,4 @@
-package mysql
+package sqldb
 
 type event struct {
 	canceled bool
@@ -0,0 +1,506 @@
+package sqldb
+
+import (
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	ExportCsv     = "csv"
+	ExportNdjson  = "ndjson"
+	ExportXlsx    = "xlsx"
+	ExportParquet = "parquet"
+)
+
+type ExportOptions struct {
+	Format     string `json:"format" note:"格式: csv, ndjson, xlsx, parquet"`
+	TimeFormat string `json:"timeFormat" note:"时间格式, 默认2006-01-02 15:04:05.000, parquet的时间列为毫秒时间戳(UTC)"`
+	Location   string `json:"location" note:"时区, 如Asia/Shanghai, 默认保持原值"`
+	Separator  string `json:"separator" note:"CSV分隔符, 默认逗号"`
+	NoHeader   bool   `json:"noHeader" note:"CSV不输出标题行"`
+	SheetName  string `json:"sheetName" note:"XLSX工作表名称, 默认Sheet1"`
+}
+
+const (
+	exportString = iota
+	exportInt
+	exportFloat
+	exportBool
+	exportTime
+)
+
+type exportColumn struct {
+	name string
+	kind int
+}
+
+// exportWriter writes the rows of columns in one format,
+// the values are normalized by kind: nil, string, int64, float64, bool or time.Time.
+type exportWriter interface {
+	Begin(columns []*exportColumn) error
+	Write(values []interface{}) error
+	End() error
+}
+
+func newExportWriter(w io.Writer, options *ExportOptions) (exportWriter, error) {
+	if options == nil {
+		options = &ExportOptions{}
+	}
+	timeFormat := options.TimeFormat
+	if timeFormat == "" {
+		timeFormat = DefaultTimeFormat
+	}
+	var location *time.Location = nil
+	if options.Location != "" {
+		var err error
+		location, err = time.LoadLocation(options.Location)
+		if err != nil {
+			return nil, err
+		}
+	}
+	text := &exportText{timeFormat: timeFormat, location: location}
+
+	switch options.Format {
+	case ExportCsv, "":
+		writer := csv.NewWriter(w)
+		if options.Separator != "" {
+			separator, _ := utf8.DecodeRuneInString(options.Separator)
+			writer.Comma = separator
+		}
+		return &csvExportWriter{writer: writer, text: text, header: !options.NoHeader}, nil
+	case ExportNdjson:
+		return &ndjsonExportWriter{w: w, text: text}, nil
+	case ExportXlsx:
+		return newXlsxExportWriter(w, text, options.SheetName), nil
+	case ExportParquet:
+		return newParquetExportWriter(w, text), nil
+	default:
+		return nil, fmt.Errorf("export format '%s' not supported", options.Format)
+	}
+}
+
+// ExportResult writes the rows of result (the first result set only) in the format of options,
+// the types of columns are kept in xlsx and parquet.
+func ExportResult(w io.Writer, result *SqlSelectResult, options *ExportOptions) error {
+	writer, err := newExportWriter(w, options)
+	if err != nil {
+		return err
+	}
+
+	columns := make([]*exportColumn, len(result.Columns))
+	for i, column := range result.Columns {
+		columns[i] = &exportColumn{name: column.Name, kind: column.exportKind()}
+	}
+	err = writer.Begin(columns)
+	if err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(columns))
+	for _, row := range result.Rows {
+		for i, column := range result.Columns {
+			values[i], err = exportValue(columns[i], row.kv[column.Id])
+			if err != nil {
+				return err
+			}
+		}
+		err = writer.Write(values)
+		if err != nil {
+			return err
+		}
+	}
+
+	return writer.End()
+}
+
+// ExportRows writes the rows of SelectRows one by one without loading all of them,
+// the columns are the selected (see Only and Omit) fields of entity. The rows are not closed.
+func ExportRows(w io.Writer, sqlRows SqlRows, options *ExportOptions) error {
+	r, ok := sqlRows.(*rows)
+	if !ok {
+		return newError("invalid rows: not created by SelectRows")
+	}
+	writer, err := newExportWriter(w, options)
+	if err != nil {
+		return err
+	}
+
+	fields := make([]*field, 0)
+	columns := make([]*exportColumn, 0)
+	for i, fm := range r.sqlEntity.meta.fields {
+		f := r.sqlEntity.fields[i]
+		if r.sqlEntity.selected != nil && !r.sqlEntity.isSelected(f) {
+			continue
+		}
+		fields = append(fields, f)
+		columns = append(columns, &exportColumn{name: fm.column, kind: exportKindOf(fm.typ)})
+	}
+	err = writer.Begin(columns)
+	if err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(columns))
+	for r.Next() {
+		for i, f := range fields {
+			values[i], err = exportValue(columns[i], reflect.ValueOf(f.address).Elem().Interface())
+			if err != nil {
+				return err
+			}
+		}
+		err = writer.Write(values)
+		if err != nil {
+			return err
+		}
+	}
+	if err = r.Err(); err != nil {
+		return err
+	}
+
+	return writer.End()
+}
+
+// ExportQuery writes the rows of query (the first result set only) one by one without loading all of them
+func ExportQuery(w io.Writer, sqlAccess SqlAccess, options *ExportOptions, query string, args ...interface{}) error {
+	sqlRows, err := sqlAccess.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer sqlRows.Close()
+
+	columnTypes, err := sqlRows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+	result := &SqlSelectResult{}
+	result.Init(columnTypes)
+
+	writer, err := newExportWriter(w, options)
+	if err != nil {
+		return err
+	}
+	columns := make([]*exportColumn, len(result.Columns))
+	for i, column := range result.Columns {
+		columns[i] = &exportColumn{name: column.Name, kind: column.exportKind()}
+	}
+	err = writer.Begin(columns)
+	if err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(columns))
+	for sqlRows.Next() {
+		err = sqlRows.Scan(result.Scans()...)
+		if err != nil {
+			return err
+		}
+		for i, column := range result.Columns {
+			_, value := column.GetValue()
+			values[i], err = exportValue(columns[i], value)
+			if err != nil {
+				return err
+			}
+		}
+		err = writer.Write(values)
+		if err != nil {
+			return err
+		}
+	}
+	if err = sqlRows.Err(); err != nil {
+		return err
+	}
+
+	return writer.End()
+}
+
+func (s *entity) isSelected(f *field) bool {
+	for _, selected := range s.selected {
+		if selected == f {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *SqlSelectColumn) exportKind() int {
+	if s.scanType == nil {
+		return exportString
+	}
+	if s.scanType.Kind() != reflect.Bool && isDecimalType(s.Type) {
+		return exportFloat
+	}
+
+	return exportKindOf(s.scanType)
+}
+
+func isDecimalType(name string) bool {
+	switch name {
+	case "DECIMAL", "decimal", "NUMERIC", "numeric":
+		return true
+	}
+
+	return false
+}
+
+func exportKindOf(t reflect.Type) int {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return exportTime
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return exportBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return exportInt
+	case reflect.Float32, reflect.Float64:
+		return exportFloat
+	case reflect.Struct:
+		// sql.NullInt64, sql.NullTime etc.
+		valid, ok := t.FieldByName("Valid")
+		if ok && t.NumField() == 2 && reflect.PointerTo(t).Implements(reflect.TypeOf((*driver.Valuer)(nil)).Elem()) {
+			return exportKindOf(t.Field(1 - valid.Index[0]).Type)
+		}
+	}
+
+	return exportString
+}
+
+// exportValue normalizes value for the kind of column
+func exportValue(column *exportColumn, value interface{}) (interface{}, error) {
+	v := reflect.ValueOf(value)
+	for v.IsValid() && v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, nil
+	}
+	value = v.Interface()
+	if valuer, ok := value.(driver.Valuer); ok {
+		dv, err := valuer.Value()
+		if err != nil {
+			return nil, err
+		}
+		if dv == nil {
+			return nil, nil
+		}
+		value = dv
+		v = reflect.ValueOf(dv)
+	}
+	if data, ok := value.([]byte); ok {
+		value = string(data)
+		v = reflect.ValueOf(value)
+	}
+
+	switch column.kind {
+	case exportTime:
+		t, ok := value.(time.Time)
+		if ok {
+			return t, nil
+		}
+	case exportBool:
+		switch v.Kind() {
+		case reflect.Bool:
+			return v.Bool(), nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return v.Int() != 0, nil
+		case reflect.String:
+			b, err := strconv.ParseBool(v.String())
+			if err == nil {
+				return b, nil
+			}
+		}
+	case exportInt:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return v.Int(), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if v.Uint() <= math.MaxInt64 {
+				return int64(v.Uint()), nil
+			}
+		case reflect.Float32, reflect.Float64:
+			if v.Float() == math.Trunc(v.Float()) {
+				return int64(v.Float()), nil
+			}
+		case reflect.String:
+			n, err := strconv.ParseInt(v.String(), 10, 64)
+			if err == nil {
+				return n, nil
+			}
+		}
+	case exportFloat:
+		switch v.Kind() {
+		case reflect.Float32, reflect.Float64:
+			return v.Float(), nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return float64(v.Int()), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return float64(v.Uint()), nil
+		case reflect.String:
+			f, err := strconv.ParseFloat(v.String(), 64)
+			if err == nil {
+				return f, nil
+			}
+		}
+	default:
+		switch v.Kind() {
+		case reflect.String:
+			return v.String(), nil
+		case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+			if t, ok := value.(time.Time); ok {
+				return t, nil
+			}
+			data, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			return string(data), nil
+		default:
+			return fmt.Sprint(value), nil
+		}
+	}
+
+	return nil, fmt.Errorf("export column %s: %T value '%v' not supported", column.name, value, value)
+}
+
+// exportText formats the normalized values as text
+type exportText struct {
+	timeFormat string
+	location   *time.Location
+}
+
+func (s *exportText) format(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		if s.location != nil {
+			v = v.In(s.location)
+		}
+		return v.Format(s.timeFormat)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// uniqueNames renames the duplicate column names (e.g. of join) by suffix "_2", "_3"...
+func uniqueNames(columns []*exportColumn) []string {
+	names := make([]string, len(columns))
+	used := make(map[string]bool)
+	for i, column := range columns {
+		name := column.name
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s_%d", column.name, n)
+		}
+		used[name] = true
+		names[i] = name
+	}
+
+	return names
+}
+
+type csvExportWriter struct {
+	writer *csv.Writer
+	text   *exportText
+	header bool
+	record []string
+}
+
+func (s *csvExportWriter) Begin(columns []*exportColumn) error {
+	s.record = make([]string, len(columns))
+	if !s.header {
+		return nil
+	}
+
+	return s.writer.Write(uniqueNames(columns))
+}
+
+func (s *csvExportWriter) Write(values []interface{}) error {
+	for i, value := range values {
+		s.record[i] = s.text.format(value)
+	}
+
+	return s.writer.Write(s.record)
+}
+
+func (s *csvExportWriter) End() error {
+	s.writer.Flush()
+
+	return s.writer.Error()
+}
+
+type ndjsonExportWriter struct {
+	w     io.Writer
+	text  *exportText
+	names [][]byte
+}
+
+func (s *ndjsonExportWriter) Begin(columns []*exportColumn) error {
+	s.names = make([][]byte, len(columns))
+	for i, name := range uniqueNames(columns) {
+		data, err := json.Marshal(name)
+		if err != nil {
+			return err
+		}
+		s.names[i] = data
+	}
+
+	return nil
+}
+
+func (s *ndjsonExportWriter) Write(values []interface{}) error {
+	line := make([]byte, 0, 256)
+	line = append(line, '{')
+	for i, value := range values {
+		if i > 0 {
+			line = append(line, ',')
+		}
+		line = append(line, s.names[i]...)
+		line = append(line, ':')
+
+		switch v := value.(type) {
+		case time.Time:
+			value = s.text.format(v)
+		case float64:
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				value = s.text.format(v)
+			}
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		line = append(line, data...)
+	}
+	line = append(line, '}', '\n')
+
+	_, err := s.w.Write(line)
+	return err
+}
+
+func (s *ndjsonExportWriter) End() error {
+	return nil
+}
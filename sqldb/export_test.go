@@ -0,0 +1,599 @@
+package sqldb
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testExportResult() *SqlSelectResult {
+	columns := []*SqlSelectColumn{
+		{Id: "col00001", Name: "id", scanType: reflect.TypeOf(int64(0))},
+		{Id: "col00002", Name: "name", scanType: reflect.TypeOf("")},
+		{Id: "col00003", Name: "score", Type: "DECIMAL", scanType: reflect.TypeOf([]byte{})},
+		{Id: "col00004", Name: "ok", scanType: reflect.TypeOf(false)},
+		{Id: "col00005", Name: "at", scanType: reflect.TypeOf(time.Time{})},
+		{Id: "col00006", Name: "id", scanType: reflect.TypeOf(int64(0))},
+	}
+	at := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	values := [][]interface{}{
+		{int64(1), "apple, red", 90.5, true, at, int64(1)},
+		{int64(2), "say \"hi\"", nil, false, nil, nil},
+	}
+
+	result := &SqlSelectResult{Columns: columns}
+	for _, row := range values {
+		r := &SqlSelectRow{}
+		for i, v := range row {
+			r.SetValue(columns[i].Id, v)
+		}
+		result.Rows = append(result.Rows, r)
+	}
+
+	return result
+}
+
+func TestExport_Csv(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := ExportResult(buf, testExportResult(), &ExportOptions{Format: ExportCsv, TimeFormat: "2006-01-02"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := "id,name,score,ok,at,id_2\n" +
+		"1,\"apple, red\",90.5,true,2024-05-06,1\n" +
+		"2,\"say \"\"hi\"\"\",,false,,\n"
+	if buf.String() != expect {
+		t.Errorf("expect:\n%s\nactual:\n%s", expect, buf.String())
+	}
+}
+
+func TestExport_Ndjson(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := ExportResult(buf, testExportResult(), &ExportOptions{Format: ExportNdjson})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `{"id":1,"name":"apple, red","score":90.5,"ok":true,"at":"2024-05-06 07:08:09.000","id_2":1}` + "\n" +
+		`{"id":2,"name":"say \"hi\"","score":null,"ok":false,"at":null,"id_2":null}` + "\n"
+	if buf.String() != expect {
+		t.Errorf("expect:\n%s\nactual:\n%s", expect, buf.String())
+	}
+}
+
+func TestExport_Xlsx(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := ExportResult(buf, testExportResult(), &ExportOptions{Format: ExportXlsx, SheetName: "a/b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sheetName, rows, err := readXlsx(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sheetName != "a_b" {
+		t.Errorf("sheet name not sanitized: %s", sheetName)
+	}
+	expect := [][]string{
+		{"id", "name", "score", "ok", "at", "id_2"},
+		{"1", "apple, red", "90.5", "TRUE", "2024-05-06 07:08:09.000", "1"},
+		{"2", "say \"hi\"", "", "FALSE", "", ""},
+	}
+	if !reflect.DeepEqual(rows, expect) {
+		t.Errorf("expect: %q, actual: %q", expect, rows)
+	}
+}
+
+// readXlsx opens data as a workbook the way spreadsheet applications do: the main part from the package relationships,
+// the first sheet from the workbook relationships, then the cells by their references and types.
+func readXlsx(data []byte) (string, [][]string, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", nil, err
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range reader.File {
+		files[f.Name] = f
+	}
+	decode := func(name string, v interface{}) error {
+		f, ok := files[name]
+		if !ok {
+			return fmt.Errorf("part %s not found", name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		err = xml.NewDecoder(rc).Decode(v)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		return nil
+	}
+	type relationships struct {
+		Items []struct {
+			Id     string `xml:"Id,attr"`
+			Type   string `xml:"Type,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	target := func(rels *relationships, id, typ string) string {
+		for _, item := range rels.Items {
+			if (id == "" || item.Id == id) && strings.HasSuffix(item.Type, typ) {
+				return item.Target
+			}
+		}
+		return ""
+	}
+
+	types := &struct {
+		Overrides []struct {
+			PartName    string `xml:"PartName,attr"`
+			ContentType string `xml:"ContentType,attr"`
+		} `xml:"Override"`
+	}{}
+	err = decode("[Content_Types].xml", types)
+	if err != nil {
+		return "", nil, err
+	}
+	contentTypes := make(map[string]string)
+	for _, override := range types.Overrides {
+		contentTypes[strings.TrimPrefix(override.PartName, "/")] = override.ContentType
+	}
+
+	rels := &relationships{}
+	err = decode("_rels/.rels", rels)
+	if err != nil {
+		return "", nil, err
+	}
+	workbookPart := target(rels, "", "/officeDocument")
+	if !strings.HasSuffix(contentTypes[workbookPart], ".sheet.main+xml") {
+		return "", nil, fmt.Errorf("workbook %s: invalid content type '%s'", workbookPart, contentTypes[workbookPart])
+	}
+	workbook := &struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			Id   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}{}
+	err = decode(workbookPart, workbook)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(workbook.Sheets) < 1 {
+		return "", nil, fmt.Errorf("no sheets in workbook")
+	}
+
+	dir, base := path.Split(workbookPart)
+	workbookRels := &relationships{}
+	err = decode(dir+"_rels/"+base+".rels", workbookRels)
+	if err != nil {
+		return "", nil, err
+	}
+	stylesPart := path.Join(dir, target(workbookRels, "", "/styles"))
+	styles := &struct {
+		CellXfs []struct{} `xml:"cellXfs>xf"`
+	}{}
+	err = decode(stylesPart, styles)
+	if err != nil {
+		return "", nil, err
+	}
+	sheetPart := path.Join(dir, target(workbookRels, workbook.Sheets[0].Id, "/worksheet"))
+	if !strings.HasSuffix(contentTypes[sheetPart], ".worksheet+xml") {
+		return "", nil, fmt.Errorf("sheet %s: invalid content type '%s'", sheetPart, contentTypes[sheetPart])
+	}
+	sheet := &struct {
+		Rows []struct {
+			R     int `xml:"r,attr"`
+			Cells []struct {
+				R      string `xml:"r,attr"`
+				S      int    `xml:"s,attr"`
+				T      string `xml:"t,attr"`
+				V      string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}{}
+	err = decode(sheetPart, sheet)
+	if err != nil {
+		return "", nil, err
+	}
+
+	rows := make([][]string, 0)
+	for i, row := range sheet.Rows {
+		if row.R != i+1 {
+			return "", nil, fmt.Errorf("row %d: invalid reference %d", i+1, row.R)
+		}
+		values := make([]string, 0)
+		for _, cell := range row.Cells {
+			letters := strings.TrimRight(cell.R, "0123456789")
+			if cell.R[len(letters):] != strconv.Itoa(row.R) {
+				return "", nil, fmt.Errorf("cell %s: not in row %d", cell.R, row.R)
+			}
+			index := 0
+			for _, letter := range letters {
+				index = index*26 + int(letter-'A') + 1
+			}
+			if index <= len(values) {
+				return "", nil, fmt.Errorf("cell %s: out of order", cell.R)
+			}
+			if cell.S >= len(styles.CellXfs) {
+				return "", nil, fmt.Errorf("cell %s: style %d not found", cell.R, cell.S)
+			}
+			for len(values) < index {
+				values = append(values, "")
+			}
+
+			switch cell.T {
+			case "inlineStr":
+				values[index-1] = cell.Inline
+			case "b":
+				values[index-1] = map[string]string{"0": "FALSE", "1": "TRUE"}[cell.V]
+			case "", "n":
+				_, err = strconv.ParseFloat(cell.V, 64)
+				if err != nil {
+					return "", nil, fmt.Errorf("cell %s: invalid number '%s'", cell.R, cell.V)
+				}
+				values[index-1] = cell.V
+			default:
+				return "", nil, fmt.Errorf("cell %s: type '%s' not supported", cell.R, cell.T)
+			}
+		}
+		rows = append(rows, values)
+	}
+	for i := range rows {
+		for len(rows[i]) < len(rows[0]) {
+			rows[i] = append(rows[i], "")
+		}
+	}
+
+	return workbook.Sheets[0].Name, rows, nil
+}
+
+func TestExport_Parquet(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := ExportResult(buf, testExportResult(), &ExportOptions{Format: ExportParquet})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := readParquet(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.createdBy != parquetCreatedBy || file.rowGroups != 1 {
+		t.Errorf("unexpected file %s with %d row groups", file.createdBy, file.rowGroups)
+	}
+	expectColumns := []parquetTestColumn{
+		{name: "id", physicalType: parquetInt64, convertedType: -1},
+		{name: "name", physicalType: parquetByteArray, convertedType: parquetUtf8},
+		{name: "score", physicalType: parquetDouble, convertedType: -1},
+		{name: "ok", physicalType: parquetBoolean, convertedType: -1},
+		{name: "at", physicalType: parquetInt64, convertedType: parquetTimestampMillis},
+		{name: "id_2", physicalType: parquetInt64, convertedType: -1},
+	}
+	if !reflect.DeepEqual(file.columns, expectColumns) {
+		t.Errorf("expect schema: %+v, actual: %+v", expectColumns, file.columns)
+	}
+	expectRows := [][]interface{}{
+		{int64(1), "apple, red", 90.5, true, time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC), int64(1)},
+		{int64(2), "say \"hi\"", nil, false, nil, nil},
+	}
+	if file.numRows != 2 || !reflect.DeepEqual(file.rows, expectRows) {
+		t.Errorf("expect %d rows: %v, actual %d rows: %v", len(expectRows), expectRows, file.numRows, file.rows)
+	}
+
+	// the rows over parquetRowGroupSize are written in the next row group
+	result := &SqlSelectResult{Columns: []*SqlSelectColumn{{Id: "col00001", Name: "n", scanType: reflect.TypeOf(int64(0))}}}
+	expectRows = make([][]interface{}, parquetRowGroupSize+2)
+	for i := range expectRows {
+		r := &SqlSelectRow{}
+		expectRows[i] = []interface{}{nil}
+		if i%3 != 0 {
+			r.SetValue("col00001", int64(i))
+			expectRows[i][0] = int64(i)
+		}
+		result.Rows = append(result.Rows, r)
+	}
+	buf.Reset()
+	err = ExportResult(buf, result, &ExportOptions{Format: ExportParquet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err = readParquet(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.rowGroups != 2 || file.numRows != int64(len(expectRows)) || !reflect.DeepEqual(file.rows, expectRows) {
+		t.Errorf("expect %d rows in 2 row groups, actual %d rows in %d row groups", len(expectRows), file.numRows, file.rowGroups)
+	}
+}
+
+type parquetTestColumn struct {
+	name          string
+	physicalType  int64
+	convertedType int64 // -1 if not set
+}
+
+type parquetTestFile struct {
+	columns   []parquetTestColumn
+	numRows   int64
+	rowGroups int
+	createdBy string
+	rows      [][]interface{}
+}
+
+// readParquet reads data the way parquet readers do: the footer (FileMetaData) by the length before the trailing magic,
+// then the data page of each column chunk at its offset, with the definition levels and the plain values.
+func readParquet(data []byte) (*parquetTestFile, error) {
+	if len(data) < 12 || string(data[:4]) != parquetMagic || string(data[len(data)-4:]) != parquetMagic {
+		return nil, fmt.Errorf("magic not found")
+	}
+	size := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if size > len(data)-12 {
+		return nil, fmt.Errorf("invalid footer length %d", size)
+	}
+	footer := &thriftTestReader{data: data[:len(data)-8], pos: len(data) - 8 - size}
+	meta := footer.readStruct()
+	if footer.err != nil {
+		return nil, footer.err
+	}
+
+	file := &parquetTestFile{}
+	file.numRows, _ = meta[3].(int64)
+	createdBy, _ := meta[6].([]byte)
+	file.createdBy = string(createdBy)
+	schema, _ := meta[2].([]interface{})
+	if len(schema) < 1 || schema[0].(map[int16]interface{})[5] != int64(len(schema)-1) {
+		return nil, fmt.Errorf("invalid schema root")
+	}
+	for _, item := range schema[1:] {
+		element := item.(map[int16]interface{})
+		name, _ := element[4].([]byte)
+		column := parquetTestColumn{name: string(name), convertedType: -1}
+		column.physicalType, _ = element[1].(int64)
+		if converted, ok := element[6].(int64); ok {
+			column.convertedType = converted
+		}
+		if element[3] != int64(parquetOptional) {
+			return nil, fmt.Errorf("column %s: not optional", column.name)
+		}
+		file.columns = append(file.columns, column)
+	}
+
+	rowGroups, _ := meta[4].([]interface{})
+	file.rowGroups = len(rowGroups)
+	for _, item := range rowGroups {
+		rowGroup := item.(map[int16]interface{})
+		count, _ := rowGroup[3].(int64)
+		rows := make([][]interface{}, count)
+		for i := range rows {
+			rows[i] = make([]interface{}, len(file.columns))
+		}
+		chunks, _ := rowGroup[1].([]interface{})
+		if len(chunks) != len(file.columns) {
+			return nil, fmt.Errorf("expect %d column chunks, actual %d", len(file.columns), len(chunks))
+		}
+		for c, chunk := range chunks {
+			columnMeta, _ := chunk.(map[int16]interface{})[3].(map[int16]interface{})
+			path, _ := columnMeta[3].([]interface{})
+			if len(path) != 1 || string(path[0].([]byte)) != file.columns[c].name {
+				return nil, fmt.Errorf("column %d: unexpected path %v", c, path)
+			}
+			if columnMeta[4] != int64(0) || columnMeta[5] != count {
+				return nil, fmt.Errorf("column %s: compressed or unexpected values", file.columns[c].name)
+			}
+			offset, _ := columnMeta[9].(int64)
+			values, err := readParquetPage(data, offset, file.columns[c], int(count))
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %v", file.columns[c].name, err)
+			}
+			for i, value := range values {
+				rows[i][c] = value
+			}
+		}
+		file.rows = append(file.rows, rows...)
+	}
+
+	return file, nil
+}
+
+func readParquetPage(data []byte, offset int64, column parquetTestColumn, count int) ([]interface{}, error) {
+	if offset < 4 || offset >= int64(len(data)) {
+		return nil, fmt.Errorf("invalid page offset %d", offset)
+	}
+	reader := &thriftTestReader{data: data, pos: int(offset)}
+	header := reader.readStruct()
+	if reader.err != nil {
+		return nil, reader.err
+	}
+	size, _ := header[3].(int64)
+	dataPage, _ := header[5].(map[int16]interface{})
+	if header[1] != int64(parquetDataPage) || dataPage[1] != int64(count) || dataPage[2] != int64(parquetPlain) || dataPage[3] != int64(parquetRle) {
+		return nil, fmt.Errorf("unexpected page header %v", header)
+	}
+	if reader.pos+int(size) > len(data) {
+		return nil, fmt.Errorf("page out of range")
+	}
+	page := data[reader.pos : reader.pos+int(size)]
+
+	// definition levels: the length, then the runs of RLE/bit-packed hybrid encoding in bit width 1
+	length := int(binary.LittleEndian.Uint32(page))
+	levels := make([]bool, 0, count)
+	runs := page[4 : 4+length]
+	for len(runs) > 0 && len(levels) < count {
+		h, n := binary.Uvarint(runs)
+		runs = runs[n:]
+		if h&1 == 1 {
+			for _, b := range runs[:h>>1] {
+				for bit := 0; bit < 8; bit++ {
+					levels = append(levels, b&(1<<bit) != 0)
+				}
+			}
+			runs = runs[h>>1:]
+		} else {
+			for i := uint64(0); i < h>>1; i++ {
+				levels = append(levels, runs[0] == 1)
+			}
+			runs = runs[1:]
+		}
+	}
+	if len(levels) < count {
+		return nil, fmt.Errorf("expect %d definition levels, actual %d", count, len(levels))
+	}
+
+	plain := page[4+length:]
+	values := make([]interface{}, count)
+	bits := 0
+	for i := range values {
+		if !levels[i] {
+			continue
+		}
+		switch column.physicalType {
+		case parquetBoolean:
+			values[i] = plain[bits/8]&(1<<(bits%8)) != 0
+			bits++
+		case parquetInt64:
+			v := int64(binary.LittleEndian.Uint64(plain))
+			if column.convertedType == parquetTimestampMillis {
+				values[i] = time.UnixMilli(v).UTC()
+			} else {
+				values[i] = v
+			}
+			plain = plain[8:]
+		case parquetDouble:
+			values[i] = math.Float64frombits(binary.LittleEndian.Uint64(plain))
+			plain = plain[8:]
+		case parquetByteArray:
+			n := binary.LittleEndian.Uint32(plain)
+			values[i] = string(plain[4 : 4+n])
+			plain = plain[4+n:]
+		}
+	}
+
+	return values, nil
+}
+
+// thriftTestReader reads the thrift compact protocol, the structs as the values by field id and the lists as slices
+type thriftTestReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (s *thriftTestReader) byte() byte {
+	if s.pos >= len(s.data) {
+		if s.err == nil {
+			s.err = fmt.Errorf("unexpected end at %d", s.pos)
+		}
+		return 0
+	}
+	s.pos++
+
+	return s.data[s.pos-1]
+}
+
+func (s *thriftTestReader) varint() uint64 {
+	v, shift := uint64(0), uint(0)
+	for s.err == nil {
+		b := s.byte()
+		v |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			break
+		}
+		shift += 7
+	}
+
+	return v
+}
+
+func (s *thriftTestReader) zigzag() int64 {
+	v := s.varint()
+
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (s *thriftTestReader) readStruct() map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	last := int16(0)
+	for s.err == nil {
+		header := s.byte()
+		if header == 0 {
+			break
+		}
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			id = int16(s.zigzag())
+		}
+		last = id
+		switch header & 0x0f {
+		case 1:
+			fields[id] = true
+		case 2:
+			fields[id] = false
+		default:
+			fields[id] = s.readValue(header & 0x0f)
+		}
+	}
+
+	return fields
+}
+
+func (s *thriftTestReader) readValue(typ byte) interface{} {
+	switch typ {
+	case 1, 2, 3:
+		return s.byte()
+	case 4, 5, 6:
+		return s.zigzag()
+	case 7:
+		v := uint64(0)
+		for i := 0; i < 8; i++ {
+			v |= uint64(s.byte()) << (8 * i)
+		}
+		return math.Float64frombits(v)
+	case thriftBinary:
+		n := int(s.varint())
+		if s.err != nil || s.pos+n > len(s.data) {
+			s.err = fmt.Errorf("binary out of range at %d", s.pos)
+			return nil
+		}
+		s.pos += n
+		return s.data[s.pos-n : s.pos]
+	case thriftList, 10:
+		header := s.byte()
+		size := int(header >> 4)
+		if size == 15 {
+			size = int(s.varint())
+		}
+		items := make([]interface{}, 0, size)
+		for i := 0; i < size && s.err == nil; i++ {
+			items = append(items, s.readValue(header&0x0f))
+		}
+		return items
+	case thriftStruct:
+		return s.readStruct()
+	default:
+		s.err = fmt.Errorf("unknown type %d at %d", typ, s.pos)
+		return nil
+	}
+}
+
+func TestXlsxColumnName(t *testing.T) {
+	for index, expect := range map[int]string{0: "A", 25: "Z", 26: "AA", 701: "ZZ", 702: "AAA"} {
+		if actual := xlsxColumnName(index); actual != expect {
+			t.Errorf("%d: expect=%s, actual=%s", index, expect, actual)
+		}
+	}
+}
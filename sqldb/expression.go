@@ -0,0 +1,45 @@
+package sqldb
+
+import (
+	"strings"
+)
+
+// Expression is the raw sql used as the value in SqlBuilder.Set, UpdateMap, e.g. sqldb.Expr("counter + ?", 1),
+// the '?' in query are replaced by the placeholders of dialect in order,
+// except those in the quoted sections, i.e. string literals ('...') and identifiers ("...", `...`, [...]).
+type Expression struct {
+	query string
+	args  []interface{}
+}
+
+func Expr(query string, args ...interface{}) *Expression {
+	return &Expression{query: query, args: args}
+}
+
+// expand returns the query with the placeholders named by argName,
+// which is called before the argument of it is appended.
+func (s *Expression) expand(argName func() string, appendArg func(arg interface{})) string {
+	sb := &strings.Builder{}
+	index := 0
+	var quote rune = 0
+	for _, c := range s.query {
+		if quote != 0 {
+			// the escaped quote ('') closes and reopens the section
+			if c == quote {
+				quote = 0
+			}
+		} else if c == '\'' || c == '"' || c == '`' {
+			quote = c
+		} else if c == '[' {
+			quote = ']'
+		} else if c == '?' && index < len(s.args) {
+			sb.WriteString(argName())
+			appendArg(s.args[index])
+			index++
+			continue
+		}
+		sb.WriteRune(c)
+	}
+
+	return sb.String()
+}
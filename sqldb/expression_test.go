@@ -0,0 +1,32 @@
+package sqldb
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestExpression_Expand(t *testing.T) {
+	tests := []struct {
+		query    string
+		args     []interface{}
+		expected string
+		used     []interface{}
+	}{
+		{"score + ?", []interface{}{5}, "score + @p1", []interface{}{5}},
+		{"COALESCE(name, '?') || ?", []interface{}{"x"}, "COALESCE(name, '?') || @p1", []interface{}{"x"}},
+		{"'it''s ?' || ? || \"a?\" || `b?` || [c?]", []interface{}{1, 2}, "'it''s ?' || @p1 || \"a?\" || `b?` || [c?]", []interface{}{1}},
+		{"? + ?", []interface{}{1}, "@p1 + ?", []interface{}{1}},
+	}
+	for _, test := range tests {
+		used := make([]interface{}, 0)
+		actual := Expr(test.query, test.args...).expand(func() string {
+			return fmt.Sprintf("@p%d", len(used)+1)
+		}, func(arg interface{}) {
+			used = append(used, arg)
+		})
+		if actual != test.expected || !reflect.DeepEqual(used, test.used) {
+			t.Errorf("%s: expect %s %v, actual %s %v", test.query, test.expected, test.used, actual, used)
+		}
+	}
+}
@@ -1,4 +1,4 @@
-package mssql
+package sqldb
 
 import (
 	"fmt"
@@ -9,6 +9,7 @@ type field struct {
 	name          string
 	value         interface{}
 	address       interface{}
+	scan          interface{} // scanner of converted field, address is scanned directly if nil
 	autoIncrement bool
 	primaryKey    bool
 	filter        string
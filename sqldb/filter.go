@@ -1,4 +1,4 @@
-package mssql
+package sqldb
 
 type filter struct {
 	fieldOr bool
@@ -6,6 +6,10 @@ type filter struct {
 	fields  interface{}
 }
 
+func NewFilter(entity interface{}, fieldOr, groupOr bool) SqlFilter {
+	return newFilter(entity, fieldOr, groupOr)
+}
+
 func newFilter(entity interface{}, fieldOr, groupOr bool) *filter {
 	return &filter{
 		fieldOr: fieldOr,
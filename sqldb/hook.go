@@ -0,0 +1,86 @@
+package sqldb
+
+// The hooks are optional methods of entity called by the access around the operations,
+// the operation is aborted if the Before hook returns error, and the error of After hook is returned to caller.
+// sqlAccess is the access running the operation, so that the hook runs in the same transaction (if any).
+// AfterScan is called while the rows are still open, the hook should not query by sqlAccess in transaction.
+// AfterInsert is called after the auto increment field is set by the generated id.
+// UpdateMap does not call BeforeUpdate and AfterUpdate, as the entity provides the table and columns only, not the values.
+
+type BeforeInsertHook interface {
+	BeforeInsert(sqlAccess SqlAccess) error
+}
+
+type AfterInsertHook interface {
+	AfterInsert(sqlAccess SqlAccess) error
+}
+
+type BeforeUpdateHook interface {
+	BeforeUpdate(sqlAccess SqlAccess) error
+}
+
+type AfterUpdateHook interface {
+	AfterUpdate(sqlAccess SqlAccess) error
+}
+
+type BeforeDeleteHook interface {
+	BeforeDelete(sqlAccess SqlAccess) error
+}
+
+type AfterScanHook interface {
+	AfterScan(sqlAccess SqlAccess) error
+}
+
+func beforeInsert(sqlAccess SqlAccess, dbEntity interface{}) error {
+	hook, ok := dbEntity.(BeforeInsertHook)
+	if !ok {
+		return nil
+	}
+
+	return hook.BeforeInsert(sqlAccess)
+}
+
+func afterInsert(sqlAccess SqlAccess, dbEntity interface{}) error {
+	hook, ok := dbEntity.(AfterInsertHook)
+	if !ok {
+		return nil
+	}
+
+	return hook.AfterInsert(sqlAccess)
+}
+
+func beforeUpdate(sqlAccess SqlAccess, dbEntity interface{}) error {
+	hook, ok := dbEntity.(BeforeUpdateHook)
+	if !ok {
+		return nil
+	}
+
+	return hook.BeforeUpdate(sqlAccess)
+}
+
+func afterUpdate(sqlAccess SqlAccess, dbEntity interface{}) error {
+	hook, ok := dbEntity.(AfterUpdateHook)
+	if !ok {
+		return nil
+	}
+
+	return hook.AfterUpdate(sqlAccess)
+}
+
+func beforeDelete(sqlAccess SqlAccess, dbEntity interface{}) error {
+	hook, ok := dbEntity.(BeforeDeleteHook)
+	if !ok {
+		return nil
+	}
+
+	return hook.BeforeDelete(sqlAccess)
+}
+
+func afterScan(sqlAccess SqlAccess, dbEntity interface{}) error {
+	hook, ok := dbEntity.(AfterScanHook)
+	if !ok {
+		return nil
+	}
+
+	return hook.AfterScan(sqlAccess)
+}
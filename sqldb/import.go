@@ -0,0 +1,656 @@
+package sqldb
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	ImportCsv    = "csv"
+	ImportNdjson = "ndjson"
+
+	importBatchSize = 500
+	importMaxErrors = 100
+)
+
+const (
+	importDecimal = exportTime + 1 + iota
+	importBinary
+)
+
+var (
+	// ErrImportFailed is returned when some rows are not imported, the details are in ImportResult.Errors
+	ErrImportFailed = errors.New("import failed")
+)
+
+type ImportOptions struct {
+	Format     string            `json:"format" note:"格式: csv, ndjson"`
+	Separator  string            `json:"separator" note:"CSV分隔符, 默认逗号"`
+	Headers    []string          `json:"headers" note:"CSV列名, 为空时第一行为标题行"`
+	Mapping    map[string]string `json:"mapping" note:"字段名到列名的映射, 映射为空字符串时忽略该字段, 未映射的字段按名称匹配(不区分大小写)"`
+	TimeFormat string            `json:"timeFormat" note:"时间格式, 默认依次尝试2006-01-02 15:04:05(可带毫秒), RFC3339和2006-01-02"`
+	Location   string            `json:"location" note:"解析时间的时区, 如Asia/Shanghai, 默认UTC"`
+	BatchSize  int               `json:"batchSize" note:"每条插入语句的最大行数, 默认500"`
+	MaxErrors  int               `json:"maxErrors" note:"错误行数达到该值时停止导入, 默认100"`
+	SkipErrors bool              `json:"skipErrors" note:"跳过错误行并导入其余行, 否则有错误行时不再插入"`
+}
+
+type ImportError struct {
+	Row     int    `json:"row" note:"数据行号, 从1开始"`
+	Column  string `json:"column" note:"字段名称, 为空表示整行"`
+	Message string `json:"message" note:"错误信息"`
+}
+
+func (s *ImportError) Error() string {
+	if s.Column == "" {
+		return fmt.Sprintf("row %d: %s", s.Row, s.Message)
+	}
+
+	return fmt.Sprintf("row %d, %s: %s", s.Row, s.Column, s.Message)
+}
+
+type ImportResult struct {
+	Rows     int            `json:"rows" note:"读取行数"`
+	Inserted int            `json:"inserted" note:"插入行数"`
+	Errors   []*ImportError `json:"errors" note:"错误行"`
+}
+
+// importReader reads the records of source, the values are string (csv) or
+// nil, string, json.Number, bool, map and slice (ndjson).
+// The error of one record is returned as *ImportError and the reading can go on.
+type importReader interface {
+	Next() (map[string]interface{}, error)
+}
+
+type csvImportReader struct {
+	reader *csv.Reader
+	header []string
+	row    int
+}
+
+func (s *csvImportReader) Next() (map[string]interface{}, error) {
+	if s.header == nil {
+		header, err := s.reader.Read()
+		if err != nil {
+			return nil, err
+		}
+		if len(header) > 0 {
+			header[0] = strings.TrimPrefix(header[0], "\ufeff")
+		}
+		s.header = header
+	}
+
+	values, err := s.reader.Read()
+	if parseErr, ok := err.(*csv.ParseError); ok {
+		// the malformed record (e.g. bare quote) is skipped, the message has the line in source
+		s.row++
+		return nil, &ImportError{Row: s.row, Message: parseErr.Error()}
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.row++
+	if len(values) != len(s.header) {
+		return nil, &ImportError{Row: s.row, Message: fmt.Sprintf("%d fields expected but %d", len(s.header), len(values))}
+	}
+	record := make(map[string]interface{}, len(values))
+	for i, value := range values {
+		record[s.header[i]] = value
+	}
+
+	return record, nil
+}
+
+type ndjsonImportReader struct {
+	reader *bufio.Reader
+	row    int
+}
+
+func (s *ndjsonImportReader) Next() (map[string]interface{}, error) {
+	for {
+		line, err := s.reader.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			return nil, err
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		s.row++
+
+		record := make(map[string]interface{})
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.UseNumber()
+		if err = decoder.Decode(&record); err != nil {
+			return nil, &ImportError{Row: s.row, Message: err.Error()}
+		}
+
+		return record, nil
+	}
+}
+
+// importer reads the records and collects the errors of rows
+type importer struct {
+	reader     importReader
+	options    ImportOptions
+	location   *time.Location
+	result     *ImportResult
+	row        int
+	targets    map[string]int
+	targetName func(index int) string
+}
+
+func newImporter(r io.Reader, options *ImportOptions) (*importer, error) {
+	s := &importer{result: &ImportResult{Errors: make([]*ImportError, 0)}, location: time.UTC, targets: make(map[string]int)}
+	if options != nil {
+		s.options = *options
+	}
+	if s.options.BatchSize <= 0 {
+		s.options.BatchSize = importBatchSize
+	}
+	if s.options.MaxErrors <= 0 {
+		s.options.MaxErrors = importMaxErrors
+	}
+	if s.options.Location != "" {
+		location, err := time.LoadLocation(s.options.Location)
+		if err != nil {
+			return nil, err
+		}
+		s.location = location
+	}
+
+	switch s.options.Format {
+	case ImportCsv, "":
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		if s.options.Separator != "" {
+			separator, _ := utf8.DecodeRuneInString(s.options.Separator)
+			reader.Comma = separator
+		}
+		s.reader = &csvImportReader{reader: reader, header: s.options.Headers}
+	case ImportNdjson:
+		s.reader = &ndjsonImportReader{reader: bufio.NewReader(r)}
+	default:
+		return nil, fmt.Errorf("import format '%s' not supported", s.options.Format)
+	}
+
+	return s, nil
+}
+
+// next returns the next record, nil at the end or if too many errors
+func (s *importer) next() (map[string]interface{}, error) {
+	for !s.stopped() {
+		record, err := s.reader.Next()
+		if err == io.EOF {
+			return nil, nil
+		}
+		if rowErr, ok := err.(*ImportError); ok {
+			s.row = rowErr.Row
+			s.result.Rows++
+			s.fail(rowErr.Row, "", rowErr)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		s.row++
+		s.result.Rows++
+
+		return record, nil
+	}
+
+	return nil, nil
+}
+
+func (s *importer) fail(row int, column string, err error) {
+	rowErr, ok := err.(*ImportError)
+	if !ok {
+		rowErr = &ImportError{Row: row, Column: column, Message: err.Error()}
+	}
+	s.result.Errors = append(s.result.Errors, rowErr)
+}
+
+// inserting reports whether the valid rows are still inserted
+func (s *importer) inserting() bool {
+	return s.options.SkipErrors || len(s.result.Errors) < 1
+}
+
+func (s *importer) stopped() bool {
+	return len(s.result.Errors) >= s.options.MaxErrors
+}
+
+func (s *importer) end() (*ImportResult, error) {
+	if len(s.result.Errors) > 0 && (!s.options.SkipErrors || s.stopped()) {
+		return s.result, fmt.Errorf("%w: %d rows", ErrImportFailed, len(s.result.Errors))
+	}
+
+	return s.result, nil
+}
+
+// target returns the index of target (column or field) of the field in source, -1 if ignored
+func (s *importer) target(field string, count int) (int, error) {
+	index, ok := s.targets[field]
+	if ok {
+		return index, nil
+	}
+
+	name, ok := s.options.Mapping[field]
+	if !ok {
+		name = field
+	}
+	index = -1
+	if name != "" {
+		for i := 0; i < count; i++ {
+			if strings.EqualFold(s.targetName(i), name) {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return 0, fmt.Errorf("column '%s' not found", name)
+		}
+	}
+	s.targets[field] = index
+
+	return index, nil
+}
+
+func (s *importer) parseTime(text string) (time.Time, error) {
+	if s.options.TimeFormat != "" {
+		return time.ParseInLocation(s.options.TimeFormat, text, s.location)
+	}
+
+	var err error
+	for _, layout := range []string{"2006-01-02 15:04:05", time.RFC3339Nano, "2006-01-02"} {
+		var t time.Time
+		t, err = time.ParseInLocation(layout, text, s.location)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, err
+}
+
+func importFields(record map[string]interface{}) []string {
+	fields := make([]string, 0, len(record))
+	for field := range record {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	return fields
+}
+
+// importText returns the text of value read, false if it is NULL
+func importText(value interface{}) (string, bool, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", false, nil
+	case string:
+		return v, true, nil
+	case json.Number:
+		return v.String(), true, nil
+	case bool:
+		return strconv.FormatBool(v), true, nil
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", false, err
+		}
+		return string(data), true, nil
+	}
+}
+
+// importKindOf returns the kind of column by the data type, e.g. bigint, decimal, datetime2, varbinary
+func importKindOf(dataType string) int {
+	t := strings.ToLower(strings.TrimSpace(dataType))
+	if index := strings.IndexAny(t, "( "); index > 0 {
+		t = t[:index]
+	}
+
+	switch t {
+	case "bit", "bool", "boolean":
+		return exportBool
+	case "int", "integer", "tinyint", "smallint", "mediumint", "bigint", "serial", "bigserial":
+		return exportInt
+	case "float", "double", "real", "binary_float", "binary_double":
+		return exportFloat
+	case "decimal", "dec", "numeric", "number", "money", "smallmoney":
+		return importDecimal
+	case "date", "datetime", "datetime2", "smalldatetime", "datetimeoffset", "timestamp":
+		return exportTime
+	case "binary", "varbinary", "blob", "tinyblob", "mediumblob", "longblob", "image", "raw", "bytea":
+		return importBinary
+	}
+
+	return exportString
+}
+
+// columnValue coerces value read into the value of column, nil for NULL.
+// The empty text is NULL except the string columns.
+func (s *importer) columnValue(column *SqlColumn, value interface{}) (interface{}, error) {
+	text, ok, err := importText(value)
+	if err != nil || !ok {
+		return nil, err
+	}
+	kind := importKindOf(column.DataType)
+	if text == "" && kind != exportString {
+		return nil, nil
+	}
+
+	switch kind {
+	case exportBool:
+		return strconv.ParseBool(text)
+	case exportInt:
+		n, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			b, e := strconv.ParseBool(text)
+			if e != nil {
+				return nil, err
+			}
+			if b {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		}
+		return n, nil
+	case exportFloat:
+		return strconv.ParseFloat(text, 64)
+	case importDecimal:
+		// keep the text for precision
+		_, err = strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, err
+		}
+		return text, nil
+	case exportTime:
+		return s.parseTime(text)
+	case importBinary:
+		return []byte(text), nil
+	default:
+		return text, nil
+	}
+}
+
+// importBatch is the rows of the same columns inserted by one statement
+type importBatch struct {
+	columns []int
+	rows    [][]interface{}
+	numbers []int // row numbers
+}
+
+func (s *importBatch) same(columns []int) bool {
+	if len(s.columns) != len(columns) {
+		return false
+	}
+	for i, column := range columns {
+		if s.columns[i] != column {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *access) importTable(sqlAccess SqlAccess, table *SqlTable, columns []*SqlColumn, r io.Reader, options *ImportOptions) (*ImportResult, error) {
+	if table == nil || len(columns) < 1 {
+		return nil, newError("import: table columns not defined")
+	}
+	im, err := newImporter(r, options)
+	if err != nil {
+		return nil, err
+	}
+	im.targetName = func(index int) string {
+		return columns[index].Name
+	}
+
+	tableName := s.tableName(table)
+	maxRows, maxArgs := 1, 0
+	if inserter, ok := s.dialect.(BatchInserter); ok {
+		maxRows, maxArgs = inserter.BatchLimit()
+	}
+	if maxRows > im.options.BatchSize {
+		maxRows = im.options.BatchSize
+	}
+
+	batch := &importBatch{}
+	for {
+		record, err := im.next()
+		if err != nil {
+			return im.result, err
+		}
+		if record == nil {
+			break
+		}
+
+		values := make([]interface{}, len(columns))
+		present := make([]bool, len(columns))
+		valid := true
+		for _, field := range importFields(record) {
+			value := record[field]
+			index, err := im.target(field, len(columns))
+			if err == nil && index >= 0 {
+				values[index], err = im.columnValue(columns[index], value)
+				present[index] = true
+			}
+			if err != nil {
+				im.fail(im.row, field, err)
+				valid = false
+			}
+		}
+
+		rowColumns := make([]int, 0, len(columns))
+		row := make([]interface{}, 0, len(columns))
+		for i, column := range columns {
+			if values[i] == nil && (column.AutoIncrement || (column.DataDefault != nil && !present[i])) {
+				// the value generated, or default of database for the column absent from source
+				continue
+			}
+			if values[i] == nil && !column.Nullable {
+				if valid {
+					if present[i] {
+						im.fail(im.row, column.Name, errors.New("NULL not allowed"))
+					} else {
+						im.fail(im.row, column.Name, errors.New("value required"))
+					}
+				}
+				valid = false
+				continue
+			}
+			if present[i] {
+				rowColumns = append(rowColumns, i)
+				row = append(row, values[i])
+			}
+		}
+		if !valid || !im.inserting() {
+			continue
+		}
+
+		if !batch.same(rowColumns) || len(batch.rows) >= maxRows || (maxArgs > 0 && (len(batch.rows)+1)*len(rowColumns) > maxArgs) {
+			s.importInsert(sqlAccess, im, tableName, columns, batch)
+			batch = &importBatch{columns: rowColumns}
+		}
+		batch.rows = append(batch.rows, row)
+		batch.numbers = append(batch.numbers, im.row)
+	}
+	if im.inserting() {
+		s.importInsert(sqlAccess, im, tableName, columns, batch)
+	}
+
+	return im.end()
+}
+
+// importInsert inserts the rows of batch in one statement,
+// they are inserted one by one to find out the failed rows if the statement fails.
+func (s *access) importInsert(sqlAccess SqlAccess, im *importer, tableName string, columns []*SqlColumn, batch *importBatch) {
+	if len(batch.rows) < 1 || !im.inserting() {
+		return
+	}
+
+	err := s.importExec(sqlAccess, tableName, columns, batch.columns, batch.rows)
+	if err == nil {
+		im.result.Inserted += len(batch.rows)
+		return
+	}
+	if len(batch.rows) == 1 {
+		im.fail(batch.numbers[0], "", s.dialect.Error(err))
+		return
+	}
+
+	for i, row := range batch.rows {
+		if !im.inserting() || im.stopped() {
+			return
+		}
+		err = s.importExec(sqlAccess, tableName, columns, batch.columns, [][]interface{}{row})
+		if err != nil {
+			im.fail(batch.numbers[i], "", s.dialect.Error(err))
+		} else {
+			im.result.Inserted++
+		}
+	}
+}
+
+func (s *access) importExec(sqlAccess SqlAccess, tableName string, columns []*SqlColumn, indexes []int, rows [][]interface{}) error {
+	names := make([]string, len(indexes))
+	for i, index := range indexes {
+		names[i] = s.dialect.Quote(columns[index].Name)
+	}
+
+	args := make([]interface{}, 0, len(rows)*len(indexes))
+	values := make([]string, len(rows))
+	for i, row := range rows {
+		placeholders := make([]string, len(row))
+		for j := range row {
+			placeholders[j] = s.dialect.Placeholder(len(args) + j + 1)
+		}
+		args = append(args, row...)
+		values[i] = fmt.Sprint("(", strings.Join(placeholders, ", "), ")")
+	}
+
+	query := fmt.Sprint("INSERT INTO ", tableName, " (", strings.Join(names, ", "), ") VALUES ", strings.Join(values, ", "))
+	_, err := sqlAccess.Exec(query, args...)
+
+	return err
+}
+
+// importEntity inserts the records as entities one by one, so that the hooks, audit and converters work as Insert
+func (s *access) importEntity(sqlAccess SqlAccess, dbEntity interface{}, r io.Reader, options *ImportOptions) (*ImportResult, error) {
+	t := reflect.TypeOf(dbEntity)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, newError("import: entity should be struct")
+	}
+	meta := getEntityMeta(s.dialect, t, false)
+	if meta.err != nil {
+		return nil, meta.err
+	}
+
+	im, err := newImporter(r, options)
+	if err != nil {
+		return nil, err
+	}
+	im.targetName = func(index int) string {
+		return meta.fields[index].column
+	}
+
+	for {
+		record, err := im.next()
+		if err != nil {
+			return im.result, err
+		}
+		if record == nil {
+			break
+		}
+
+		v := reflect.New(t)
+		valid := true
+		for _, field := range importFields(record) {
+			value := record[field]
+			index, err := im.target(field, len(meta.fields))
+			if err == nil && index >= 0 {
+				fm := meta.fields[index]
+				err = im.setField(v.Elem().FieldByIndex(fm.path), fm, value)
+			}
+			if err != nil {
+				im.fail(im.row, field, err)
+				valid = false
+			}
+		}
+		if !valid || !im.inserting() {
+			continue
+		}
+
+		_, err = sqlAccess.Insert(v.Interface())
+		if err != nil {
+			im.fail(im.row, "", err)
+			continue
+		}
+		im.result.Inserted++
+	}
+
+	return im.end()
+}
+
+// setField sets the value read into the field, the empty text is the zero value except the string fields
+func (s *importer) setField(v reflect.Value, fm *fieldMeta, value interface{}) error {
+	text, ok, err := importText(value)
+	if err != nil || !ok {
+		return err
+	}
+
+	if v.Kind() == reflect.Ptr {
+		if text == "" && v.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+		elem := reflect.New(v.Type().Elem())
+		err = s.setField(elem.Elem(), fm, value)
+		if err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+	if text == "" && v.Kind() != reflect.String {
+		return nil
+	}
+
+	if v.Type() == reflect.TypeOf(time.Time{}) {
+		t, err := s.parseTime(text)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if scanner, ok := v.Addr().Interface().(sql.Scanner); ok {
+		return scanner.Scan(text)
+	}
+
+	switch v.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return setText(v, text)
+	}
+	if fm.converter != nil {
+		return fm.converter.Scan(text, v.Addr().Interface())
+	}
+
+	return json.Unmarshal([]byte(text), v.Addr().Interface())
+}
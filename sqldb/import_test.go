@@ -0,0 +1,71 @@
+package sqldb
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestImport_ColumnValue(t *testing.T) {
+	im, err := newImporter(strings.NewReader(""), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	at := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	tests := []struct {
+		dataType string
+		value    interface{}
+		expect   interface{}
+	}{
+		{"varchar", "", ""},
+		{"bigint", "12", int64(12)},
+		{"int", "", nil},
+		{"tinyint(1)", "true", int64(1)},
+		{"double", "1.5", 1.5},
+		{"decimal", "12.30", "12.30"},
+		{"bit", "false", false},
+		{"datetime2", "2024-05-06 07:08:09", at},
+		{"TIMESTAMP(6)", "2024-05-06T07:08:09Z", at},
+		{"text", nil, nil},
+		{"nvarchar", map[string]interface{}{"a": 1}, `{"a":1}`},
+	}
+	for _, test := range tests {
+		actual, err := im.columnValue(&SqlColumn{Name: "c", DataType: test.dataType}, test.value)
+		if err != nil {
+			t.Errorf("%s %v: %v", test.dataType, test.value, err)
+			continue
+		}
+		if at, ok := actual.(time.Time); ok {
+			if !at.Equal(test.expect.(time.Time)) {
+				t.Errorf("%s %v: expect=%v, actual=%v", test.dataType, test.value, test.expect, at)
+			}
+		} else if actual != test.expect {
+			t.Errorf("%s %v: expect=%#v, actual=%#v", test.dataType, test.value, test.expect, actual)
+		}
+	}
+
+	_, err = im.columnValue(&SqlColumn{Name: "c", DataType: "numeric"}, "1,2")
+	if err == nil {
+		t.Error("numeric '1,2': error expected")
+	}
+}
+
+func TestImport_Reader(t *testing.T) {
+	im, err := newImporter(strings.NewReader("\ufeffa,b\n1,2\n3\n4,5\n"), &ImportOptions{MaxErrors: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record, err := im.next()
+	if err != nil || record["a"] != "1" || record["b"] != "2" {
+		t.Fatalf("row 1: unexpected record %v, %v", record, err)
+	}
+	record, err = im.next()
+	if err != nil || record != nil {
+		t.Fatalf("row 2: stopped expected, actual %v, %v", record, err)
+	}
+	if im.result.Rows != 2 || len(im.result.Errors) != 1 || im.result.Errors[0].Row != 2 {
+		t.Errorf("unexpected result %d rows, errors %v", im.result.Rows, im.result.Errors)
+	}
+}
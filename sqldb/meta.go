@@ -0,0 +1,283 @@
+package sqldb
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// entityMeta is the definition of an entity type (columns and tags),
+// it is parsed once per type and dialect, only the values are extracted on each call.
+// The table name is not cached, TableName and SchemaName are called on the value of each call,
+// so they may depend on field values, e.g. sharded tables.
+type entityMeta struct {
+	typeName   string
+	fields     []*fieldMeta
+	scanFields string
+	softDelete *softDeleteMeta
+	version    *fieldMeta
+	relations  map[string]*relationMeta
+	err        error
+}
+
+type fieldMeta struct {
+	path          []int // index sequence for reflect.Value.FieldByIndex
+	column        string
+	name          string
+	autoIncrement bool
+	primaryKey    bool
+	filter        string
+	order         string
+	index         int
+	softDelete    bool
+	version       bool
+	audit         int
+	converter     Converter
+	typ           reflect.Type
+}
+
+type fieldMetaCollection []*fieldMeta
+
+func (s fieldMetaCollection) Len() int {
+	return len(s)
+}
+
+func (s fieldMetaCollection) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+func (s fieldMetaCollection) Less(i, j int) bool {
+	return s[i].index < s[j].index
+}
+
+type entityMetaKey struct {
+	dialect reflect.Type
+	entity  reflect.Type
+	filter  bool
+}
+
+var entityMetas = &sync.Map{}
+
+func getEntityMeta(dialect Dialect, t reflect.Type, filter bool) *entityMeta {
+	key := entityMetaKey{
+		dialect: reflect.TypeOf(dialect),
+		entity:  t,
+		filter:  filter,
+	}
+	v, ok := entityMetas.Load(key)
+	if ok {
+		return v.(*entityMeta)
+	}
+
+	v, _ = entityMetas.LoadOrStore(key, newEntityMeta(dialect, t, filter))
+	return v.(*entityMeta)
+}
+
+func newEntityMeta(dialect Dialect, t reflect.Type, filter bool) *entityMeta {
+	meta := &entityMeta{typeName: t.Name()}
+
+	fields := make(fieldMetaCollection, 0)
+	if filter {
+		// all fields are kept for filter, even if the columns are the same
+		meta.err = parseFieldMetas(dialect, t, nil, func(fm *fieldMeta) {
+			fields = append(fields, fm)
+		})
+		if meta.err != nil {
+			return meta
+		}
+	} else {
+		// the last one wins if more than one field defines the same column
+		positions := make(map[string]int)
+		meta.err = parseFieldMetas(dialect, t, nil, func(fm *fieldMeta) {
+			position, ok := positions[fm.column]
+			if ok {
+				fields[position] = fm
+			} else {
+				positions[fm.column] = len(fields)
+				fields = append(fields, fm)
+			}
+		})
+		if meta.err != nil {
+			return meta
+		}
+		sort.Stable(fields)
+
+		meta.softDelete, meta.err = newSoftDeleteMeta(t, fields)
+		if meta.err != nil {
+			return meta
+		}
+		meta.version, meta.err = newVersionMeta(t, fields)
+		if meta.err != nil {
+			return meta
+		}
+		meta.err = checkAuditMeta(t, fields)
+		if meta.err != nil {
+			return meta
+		}
+		meta.relations = make(map[string]*relationMeta)
+		meta.err = parseRelationMetas(t, nil, meta.relations)
+		if meta.err != nil {
+			return meta
+		}
+	}
+
+	if len(fields) < 1 {
+		meta.err = newError("invalid entity (", t.Name(), "): field empty")
+		return meta
+	}
+	meta.fields = fields
+
+	names := make([]string, len(fields))
+	for i, fm := range fields {
+		names[i] = fm.name
+	}
+	meta.scanFields = strings.Join(names, ", ")
+
+	return meta
+}
+
+func parseTableName(dialect Dialect, v reflect.Value) (string, error) {
+	name, err := callNameMethod(v, sqlFunTableTagName)
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		return "", newError("invalid entity (", v.Type().Name(), "): table name is empty")
+	}
+	name = dialect.Quote(name)
+
+	schema, err := callNameMethod(v, sqlFunSchemaTagName)
+	if err == nil && len(schema) > 0 {
+		return fmt.Sprintf("%s.%s", dialect.Quote(schema), name), nil
+	}
+
+	return name, nil
+}
+
+func callNameMethod(v reflect.Value, methodName string) (string, error) {
+	msgNotDefine := fmt.Sprintf("'func (s %s) %s() string' not define in struct", v.Type().Name(), methodName)
+	method := v.MethodByName(methodName)
+	if !method.IsValid() {
+		return "", errors.New(msgNotDefine)
+	}
+
+	methodType := method.Type()
+	if methodType.NumIn() != 0 {
+		return "", errors.New(msgNotDefine)
+	}
+	if methodType.NumOut() != 1 {
+		return "", errors.New(msgNotDefine)
+	}
+	if methodType.Out(0).Kind() != reflect.String {
+		return "", errors.New(msgNotDefine)
+	}
+
+	result := method.Call([]reflect.Value{})
+	if len(result) != 1 {
+		return "", newError("get ", methodName, " of '", v.Type().Name(), "' fail")
+	}
+
+	return result[0].String(), nil
+}
+
+func parseFieldMetas(dialect Dialect, t reflect.Type, parent []int, add func(fm *fieldMeta)) error {
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	n := t.NumField()
+	for i := 0; i < n; i++ {
+		typeField := t.Field(i)
+		// ignore private field
+		if typeField.PkgPath != "" {
+			continue
+		}
+
+		path := make([]int, len(parent)+1)
+		copy(path, parent)
+		path[len(parent)] = i
+
+		// parent struct fields
+		if typeField.Anonymous {
+			if typeField.Type.Kind() == reflect.Struct {
+				err := parseFieldMetas(dialect, typeField.Type, path, add)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		// filed define
+		fieldName := typeField.Tag.Get(sqlFieldTagName)
+		if fieldName == "" || fieldName == sqlFieldTagIgnore {
+			continue
+		}
+
+		info := &fieldMeta{
+			path:   path,
+			column: fieldName,
+			name:   dialect.Quote(fieldName),
+			filter: "=",
+			order:  "ASC",
+			typ:    typeField.Type,
+		}
+		if strings.ToLower(typeField.Tag.Get(sqlFieldAutoIncrementTagName)) == "true" {
+			info.autoIncrement = true
+		}
+		if strings.ToLower(typeField.Tag.Get(sqlFieldPrimaryKeyTagName)) == "true" {
+			info.primaryKey = true
+		}
+		if strings.ToLower(typeField.Tag.Get(sqlFieldSoftDeleteTagName)) == "true" {
+			info.softDelete = true
+		}
+		if strings.ToLower(typeField.Tag.Get(sqlFieldVersionTagName)) == "true" {
+			info.version = true
+		}
+		if strings.ToLower(typeField.Tag.Get(sqlFieldCreatedTagName)) == "true" {
+			info.audit = auditCreated
+		} else if strings.ToLower(typeField.Tag.Get(sqlFieldUpdatedTagName)) == "true" {
+			info.audit = auditUpdated
+		} else if strings.ToLower(typeField.Tag.Get(sqlFieldCreatedByTagName)) == "true" {
+			info.audit = auditCreatedBy
+		} else if strings.ToLower(typeField.Tag.Get(sqlFieldUpdatedByTagName)) == "true" {
+			info.audit = auditUpdatedBy
+		}
+		filter := typeField.Tag.Get(sqlFieldFilterTagName)
+		if len(filter) > 0 {
+			info.filter = filter
+		}
+		order := typeField.Tag.Get(sqlFieldOrderTagName)
+		if len(order) > 0 {
+			info.order = order
+		}
+		index := typeField.Tag.Get(sqlFieldIndexTagName)
+		if len(index) > 0 {
+			indexVal, err := strconv.Atoi(index)
+			if err == nil {
+				info.index = indexVal
+			}
+		}
+		conv := typeField.Tag.Get(sqlFieldConverterTagName)
+		if len(conv) > 0 {
+			converter, err := newConverter(typeField.Type, conv)
+			if err != nil {
+				return newError("invalid field (", t.Name(), ".", typeField.Name, "): ", err)
+			}
+			info.converter = converter
+		}
+		encrypt := typeField.Tag.Get(sqlFieldEncryptTagName)
+		if len(encrypt) > 0 {
+			converter, err := newEncryptConverter(typeField.Type, encrypt, fieldName, info.converter)
+			if err != nil {
+				return newError("invalid field (", t.Name(), ".", typeField.Name, "): ", err)
+			}
+			info.converter = converter
+		}
+		add(info)
+	}
+
+	return nil
+}
@@ -0,0 +1,732 @@
+package sqldb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	copyBatchSize = 1000
+)
+
+var (
+	// ErrCopyVerify is returned when the rows count or checksum of the copied tables are different
+	ErrCopyVerify = errors.New("copy verification failed")
+)
+
+type CopyOptions struct {
+	Tables      []string          `json:"tables" note:"复制的表名, 为空时复制全部表"`
+	CreateTable bool              `json:"createTable" note:"在目标库创建不存在的表(列, 类型, 可空, 主键和自增长)"`
+	DropTable   bool              `json:"dropTable" note:"创建前删除目标库已存在的表, 从断点继续时不删除"`
+	SkipData    bool              `json:"skipData" note:"只复制表结构"`
+	TypeMapping map[string]string `json:"typeMapping" note:"源数据类型到目标类型的映射(优先于默认映射), 如money: DECIMAL(19,4)"`
+	BatchSize   int               `json:"batchSize" note:"每批读取和写入(一个事务)的行数, 默认1000"`
+	Checkpoint  string            `json:"checkpoint" note:"断点文件路径, 每批提交后保存, 再次复制时从断点继续"`
+	Verify      bool              `json:"verify" note:"复制后校验行数和校验和"`
+
+	Progress func(table string, rows uint64) `json:"-"`
+}
+
+type CopyTableResult struct {
+	Table          string `json:"table" note:"表名"`
+	Created        bool   `json:"created" note:"是否创建了目标表"`
+	Resumed        bool   `json:"resumed" note:"是否从断点继续"`
+	Rows           uint64 `json:"rows" note:"本次复制行数"`
+	SourceRows     uint64 `json:"sourceRows" note:"源表行数(校验时)"`
+	TargetRows     uint64 `json:"targetRows" note:"目标表行数(校验时)"`
+	SourceChecksum string `json:"sourceChecksum" note:"源表校验和(校验时)"`
+	TargetChecksum string `json:"targetChecksum" note:"目标表校验和(校验时)"`
+	Verified       bool   `json:"verified" note:"行数和校验和是否一致"`
+}
+
+// CopyTables copies the tables (structure and data) from source to target, which can be different databases,
+// e.g. sql server to mysql. The types are mapped by the generic types (see ColumnTypeOf) unless in TypeMapping,
+// the defaults, indexes (except primary key) and views are not copied.
+// The rows are read in batches ordered by the primary key and each batch is inserted in one transaction,
+// the copying is resumed from the last batch committed if Checkpoint is given.
+func CopyTables(source, target SqlDatabase, options *CopyOptions) ([]*CopyTableResult, error) {
+	if options == nil {
+		options = &CopyOptions{}
+	}
+	checkpoint, err := loadCopyCheckpoint(options.Checkpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceAccess, err := source.NewAccess(false)
+	if err != nil {
+		return nil, err
+	}
+	defer sourceAccess.Close()
+	targetAccess, err := target.NewAccess(false)
+	if err != nil {
+		return nil, err
+	}
+	defer targetAccess.Close()
+
+	s := &copier{
+		options:      options,
+		batchSize:    options.BatchSize,
+		checkpoint:   checkpoint,
+		source:       source,
+		target:       target,
+		sourceAccess: sourceAccess,
+		targetAccess: targetAccess,
+		existing:     make(map[string]bool),
+	}
+	if s.batchSize <= 0 {
+		s.batchSize = copyBatchSize
+	}
+	s.src, err = accessOf(sourceAccess)
+	if err != nil {
+		return nil, err
+	}
+	s.dst, err = accessOf(targetAccess)
+	if err != nil {
+		return nil, err
+	}
+
+	tables, err := s.tables()
+	if err != nil {
+		return nil, err
+	}
+	targetTables, err := target.Tables()
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range targetTables {
+		s.existing[strings.ToLower(table.Name)] = true
+	}
+
+	results := make([]*CopyTableResult, 0, len(tables))
+	failed := make([]string, 0)
+	for _, table := range tables {
+		result, err := s.copyTable(table)
+		if result != nil {
+			results = append(results, result)
+		}
+		if err != nil {
+			return results, fmt.Errorf("copy table %s: %w", table.Name, err)
+		}
+		if options.Verify && !result.Verified {
+			failed = append(failed, result.Table)
+		}
+	}
+	if len(failed) > 0 {
+		return results, fmt.Errorf("%w: %s", ErrCopyVerify, strings.Join(failed, ", "))
+	}
+
+	return results, nil
+}
+
+type copier struct {
+	options    *CopyOptions
+	batchSize  int
+	checkpoint *copyCheckpoint
+
+	source       SqlDatabase
+	target       SqlDatabase
+	sourceAccess SqlAccess
+	targetAccess SqlAccess
+	src          *access
+	dst          *access
+	existing     map[string]bool // the tables of target in lower case
+}
+
+// accessOf returns the generic access of sqlAccess created by NewAccess
+func accessOf(sqlAccess SqlAccess) (*access, error) {
+	switch v := sqlAccess.(type) {
+	case *normal:
+		return &v.access, nil
+	case *transaction:
+		return &v.access, nil
+	}
+
+	return nil, newError("invalid access: not created by NewAccess")
+}
+
+func (s *copier) tables() ([]*SqlTable, error) {
+	tables, err := s.source.Tables()
+	if err != nil {
+		return nil, err
+	}
+	if len(s.options.Tables) < 1 {
+		return tables, nil
+	}
+
+	selected := make([]*SqlTable, 0, len(s.options.Tables))
+	for _, name := range s.options.Tables {
+		var found *SqlTable = nil
+		for _, table := range tables {
+			if strings.EqualFold(table.Name, name) || strings.EqualFold(copyTableName(table), name) {
+				found = table
+				break
+			}
+		}
+		if found == nil {
+			return nil, fmt.Errorf("copy table %s: not found", name)
+		}
+		selected = append(selected, found)
+	}
+
+	return selected, nil
+}
+
+// copyTableName returns the name of table in target, without the owner of oracle
+func copyTableName(table *SqlTable) string {
+	name := table.Name
+	if index := strings.LastIndex(name, "."); index >= 0 {
+		name = name[index+1:]
+	}
+
+	return name
+}
+
+func (s *copier) copyTable(table *SqlTable) (*CopyTableResult, error) {
+	columns, err := s.source.Columns(table)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) < 1 {
+		return nil, newError("no columns")
+	}
+	types := make([]*SqlColumnType, len(columns))
+	for i, column := range columns {
+		types[i] = ColumnTypeOf(column)
+	}
+
+	name := copyTableName(table)
+	result := &CopyTableResult{Table: name}
+	targetName := s.dst.tableName(&SqlTable{Name: name})
+	state := s.checkpoint.table(name)
+	result.Resumed = state.Done || state.Rows > 0
+
+	if s.options.CreateTable && !result.Resumed {
+		exists := s.existing[strings.ToLower(name)]
+		if exists && s.options.DropTable {
+			_, err = s.targetAccess.Exec(fmt.Sprint("DROP TABLE ", targetName))
+			if err != nil {
+				return result, s.dst.dialect.Error(err)
+			}
+			exists = false
+		}
+		if !exists {
+			query, err := s.dst.createTable(targetName, columns, types, s.options.TypeMapping)
+			if err != nil {
+				return result, err
+			}
+			_, err = s.targetAccess.Exec(query)
+			if err != nil {
+				return result, s.dst.dialect.Error(err)
+			}
+			s.existing[strings.ToLower(name)] = true
+			result.Created = true
+		}
+	}
+
+	if !s.options.SkipData && !state.Done {
+		err = s.copyRows(s.src.tableName(table), targetName, columns, types, state, result)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if s.options.Verify {
+		result.SourceRows, result.SourceChecksum, err = s.src.tableChecksum(s.sourceAccess, s.src.tableName(table), columns, types)
+		if err != nil {
+			return result, err
+		}
+		result.TargetRows, result.TargetChecksum, err = s.dst.tableChecksum(s.targetAccess, targetName, columns, types)
+		if err != nil {
+			return result, err
+		}
+		result.Verified = result.SourceRows == result.TargetRows && result.SourceChecksum == result.TargetChecksum
+	}
+
+	return result, nil
+}
+
+// copyRows reads the rows after the key (single primary key) or offset (otherwise) of checkpoint in batches
+func (s *copier) copyRows(sourceName, targetName string, columns []*SqlColumn, types []*SqlColumnType, state *copyTableCheckpoint, result *CopyTableResult) error {
+	keyIndex := -1
+	fields := make([]string, len(columns))
+	orders := make([]string, 0)
+	for i, column := range columns {
+		fields[i] = s.src.dialect.Quote(column.Name)
+		if column.PrimaryKey {
+			keyIndex = i
+			orders = append(orders, fields[i])
+		}
+	}
+	if len(orders) != 1 {
+		keyIndex = -1
+	}
+	if len(orders) < 1 {
+		// paging without primary key is stable only if ordered by all columns
+		for i, t := range types {
+			if t.Kind != ColumnText && t.Kind != ColumnBlob {
+				orders = append(orders, fields[i])
+			}
+		}
+	}
+
+	for {
+		paging := &SqlPaging{
+			Fields: strings.Join(fields, ", "),
+			Table:  sourceName,
+			Order:  fmt.Sprint("ORDER BY ", strings.Join(orders, ", ")),
+			Offset: state.Offset,
+			Size:   uint64(s.batchSize),
+		}
+		if keyIndex >= 0 {
+			paging.Offset = 0
+			if state.Key != nil {
+				key := copyKey(state.Key)
+				paging.where = func(sqlBuilder SqlBuilder) {
+					sqlBuilder.Where(fmt.Sprintf("%s > %s", fields[keyIndex], s.src.dialect.Placeholder(1)), key)
+				}
+			}
+		}
+		sqlBuilder := s.src.newBuilder()
+		s.src.dialect.Page(s.sourceAccess, sqlBuilder, paging)
+		data, err := s.sourceAccess.QueryResult(sqlBuilder.Query(), sqlBuilder.Args()...)
+		if err != nil {
+			return err
+		}
+		if len(data.Rows) < 1 {
+			break
+		}
+
+		rows := make([][]interface{}, len(data.Rows))
+		for i, row := range data.Rows {
+			values := make([]interface{}, len(columns))
+			for j, column := range data.Columns {
+				values[j] = copyValue(types[j], row.kv[column.Id])
+			}
+			rows[i] = values
+		}
+		err = s.insertRows(targetName, columns, rows)
+		if err != nil {
+			return err
+		}
+
+		count := uint64(len(rows))
+		if keyIndex >= 0 {
+			state.Key = data.Rows[len(data.Rows)-1].kv[data.Columns[keyIndex].Id]
+		} else {
+			state.Offset += count
+		}
+		state.Rows += count
+		result.Rows += count
+		err = s.checkpoint.save()
+		if err != nil {
+			return err
+		}
+		if s.options.Progress != nil {
+			s.options.Progress(result.Table, state.Rows)
+		}
+
+		if count < uint64(s.batchSize) {
+			break
+		}
+	}
+
+	state.Done = true
+
+	return s.checkpoint.save()
+}
+
+// insertRows inserts the rows in one transaction
+func (s *copier) insertRows(tableName string, columns []*SqlColumn, rows [][]interface{}) error {
+	sqlAccess, err := s.target.NewAccess(true)
+	if err != nil {
+		return err
+	}
+	defer sqlAccess.Close()
+
+	identity := false
+	for _, column := range columns {
+		if column.AutoIncrement {
+			identity = true
+		}
+	}
+	inserter, ok := s.dst.dialect.(IdentityInserter)
+	identity = identity && ok
+	if identity {
+		err = inserter.IdentityInsert(sqlAccess, tableName, true)
+		if err != nil {
+			return s.dst.dialect.Error(err)
+		}
+	}
+
+	indexes := make([]int, len(columns))
+	for i := range columns {
+		indexes[i] = i
+	}
+	maxRows, maxArgs := 1, 0
+	if batchInserter, ok := s.dst.dialect.(BatchInserter); ok {
+		maxRows, maxArgs = batchInserter.BatchLimit()
+	}
+	if maxArgs > 0 && maxRows*len(columns) > maxArgs {
+		maxRows = maxArgs / len(columns)
+	}
+	if maxRows < 1 {
+		maxRows = 1
+	}
+	for start := 0; start < len(rows); start += maxRows {
+		end := start + maxRows
+		if end > len(rows) {
+			end = len(rows)
+		}
+		err = s.dst.importExec(sqlAccess, tableName, columns, indexes, rows[start:end])
+		if err != nil {
+			return s.dst.dialect.Error(err)
+		}
+	}
+
+	if identity {
+		err = inserter.IdentityInsert(sqlAccess, tableName, false)
+		if err != nil {
+			return s.dst.dialect.Error(err)
+		}
+	}
+
+	return sqlAccess.Commit()
+}
+
+// createTable returns the statement creating table of columns by the native types of dialect
+func (s *access) createTable(tableName string, columns []*SqlColumn, types []*SqlColumnType, mapping map[string]string) (string, error) {
+	creator, ok := s.dialect.(TableCreator)
+	if !ok {
+		return "", newError("create table: not supported by the dialect")
+	}
+
+	primaryKeys := make([]string, 0)
+	for _, column := range columns {
+		if column.PrimaryKey {
+			primaryKeys = append(primaryKeys, s.dialect.Quote(column.Name))
+		}
+	}
+	inline := false
+	definitions := make([]string, 0, len(columns)+1)
+	for i, column := range columns {
+		columnType := ""
+		for k, v := range mapping {
+			if strings.EqualFold(k, column.DataType) {
+				columnType = v
+				break
+			}
+		}
+		if columnType == "" {
+			columnType = creator.ColumnType(types[i])
+		}
+
+		definition := fmt.Sprint(s.dialect.Quote(column.Name), " ", columnType)
+		if column.AutoIncrement {
+			autoIncrement := creator.AutoIncrement()
+			if strings.Contains(strings.ToUpper(autoIncrement), "PRIMARY KEY") {
+				// declared in the column, only if it is the primary key
+				if !column.PrimaryKey || len(primaryKeys) != 1 {
+					autoIncrement = ""
+				} else {
+					inline = true
+				}
+			}
+			if autoIncrement != "" {
+				definition = fmt.Sprint(definition, " ", autoIncrement)
+			}
+		}
+		if !column.Nullable {
+			definition = fmt.Sprint(definition, " NOT NULL")
+		}
+		definitions = append(definitions, definition)
+	}
+	if len(primaryKeys) > 0 && !inline {
+		definitions = append(definitions, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(primaryKeys, ", ")))
+	}
+
+	return fmt.Sprintf("CREATE TABLE %s (\n\t%s\n)", tableName, strings.Join(definitions, ",\n\t")), nil
+}
+
+// tableChecksum returns the rows count and checksum of table, the checksum is the sum of hashes of rows
+// (normalized by the generic types), so it is independent of the order of rows and the native types.
+func (s *access) tableChecksum(sqlAccess SqlAccess, tableName string, columns []*SqlColumn, types []*SqlColumnType) (uint64, string, error) {
+	fields := make([]string, len(columns))
+	for i, column := range columns {
+		fields[i] = s.dialect.Quote(column.Name)
+	}
+	rows, err := sqlAccess.Query(fmt.Sprint("SELECT ", strings.Join(fields, ", "), " FROM ", tableName))
+	if err != nil {
+		return 0, "", s.dialect.Error(err)
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, "", s.dialect.Error(err)
+	}
+	result := &SqlSelectResult{}
+	result.Init(columnTypes)
+	s.fillResultValuer(result)
+
+	count, sum := uint64(0), uint64(0)
+	h := fnv.New64a()
+	for rows.Next() {
+		err = rows.Scan(result.Scans()...)
+		if err != nil {
+			return 0, "", s.dialect.Error(err)
+		}
+		h.Reset()
+		for i, column := range result.Columns {
+			_, value := column.GetValue()
+			h.Write([]byte(checksumText(types[i], value)))
+			h.Write([]byte{0x1f})
+		}
+		sum += h.Sum64()
+		count++
+	}
+	if err = rows.Err(); err != nil {
+		return 0, "", s.dialect.Error(err)
+	}
+
+	return count, fmt.Sprintf("%016x", sum), nil
+}
+
+// copyValue converts the value read for the generic type, which may be different in target
+func copyValue(t *SqlColumnType, value interface{}) interface{} {
+	switch t.Kind {
+	case ColumnBinary, ColumnBlob:
+		if v, ok := value.(string); ok {
+			return []byte(v)
+		}
+	case ColumnBool:
+		switch v := value.(type) {
+		case int64:
+			return v != 0
+		case uint64:
+			return v != 0
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err == nil {
+				return b
+			}
+		}
+	}
+
+	return value
+}
+
+func copyNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	case []byte:
+		f, err := strconv.ParseFloat(string(bytes.TrimSpace(v)), 64)
+		return f, err == nil
+	}
+
+	return 0, false
+}
+
+// decimalRat returns the exact value of decimal, which is parsed from the text rather than float64 to keep the precision,
+// the float ones are taken by their shortest text, e.g. 0.1 rather than 0.1000000000000000055511151231257827
+func decimalRat(value interface{}) (*big.Rat, bool) {
+	text := ""
+	switch v := value.(type) {
+	case int64:
+		return new(big.Rat).SetInt64(v), true
+	case uint64:
+		return new(big.Rat).SetUint64(v), true
+	case float64:
+		text = strconv.FormatFloat(v, 'g', -1, 64)
+	case float32:
+		text = strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		return nil, false
+	}
+
+	return new(big.Rat).SetString(strings.TrimSpace(text))
+}
+
+// decimalText returns the text of decimal without the trailing zeros, e.g. 12.3 for 12.30 and 12 for 12.00
+func decimalText(r *big.Rat) string {
+	scale := 0
+	for n := new(big.Rat).Set(r); !n.IsInt(); scale++ {
+		n.Mul(n, big.NewRat(10, 1))
+	}
+
+	return r.FloatString(scale)
+}
+
+// checksumText returns the normalized text of value, which is the same in the databases of different types
+func checksumText(t *SqlColumnType, value interface{}) string {
+	if value == nil {
+		return "\x00"
+	}
+
+	switch t.Kind {
+	case ColumnBool, ColumnSmallInt, ColumnInt, ColumnBigInt:
+		switch v := value.(type) {
+		case int64:
+			return strconv.FormatInt(v, 10)
+		case uint64:
+			return strconv.FormatUint(v, 10)
+		}
+		if f, ok := copyNumber(value); ok {
+			return strconv.FormatFloat(f, 'f', 0, 64)
+		}
+	case ColumnFloat:
+		if f, ok := copyNumber(value); ok {
+			return strconv.FormatFloat(f, 'g', 6, 32)
+		}
+	case ColumnDouble:
+		if f, ok := copyNumber(value); ok {
+			return strconv.FormatFloat(f, 'g', 15, 64)
+		}
+	case ColumnDecimal:
+		if r, ok := decimalRat(value); ok {
+			return decimalText(r)
+		}
+	case ColumnDate, ColumnTime, ColumnDateTime:
+		layout := map[string]string{ColumnDate: "2006-01-02", ColumnTime: "15:04:05", ColumnDateTime: DefaultTimeFormat}[t.Kind]
+		v, ok := value.(time.Time)
+		if !ok {
+			text := fmt.Sprint(value)
+			if t.Kind == ColumnTime {
+				return text
+			}
+			for _, l := range []string{"2006-01-02 15:04:05", time.RFC3339Nano, "2006-01-02"} {
+				parsed, err := time.Parse(l, text)
+				if err == nil {
+					return parsed.Format(layout)
+				}
+			}
+			return text
+		}
+		return v.Format(layout)
+	case ColumnGuid:
+		return strings.ToLower(fmt.Sprint(value))
+	case ColumnString, ColumnText:
+		if v, ok := value.(string); ok {
+			return strings.TrimRight(v, " ")
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// copyKey returns the key of checkpoint as argument, the numbers are json.Number after loading
+func copyKey(key interface{}) interface{} {
+	n, ok := key.(json.Number)
+	if !ok {
+		return key
+	}
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	if f, err := n.Float64(); err == nil {
+		return f
+	}
+
+	return n.String()
+}
+
+type copyTableCheckpoint struct {
+	Key    interface{} `json:"key,omitempty"`
+	Offset uint64      `json:"offset,omitempty"`
+	Rows   uint64      `json:"rows"`
+	Done   bool        `json:"done"`
+}
+
+type copyCheckpoint struct {
+	Tables map[string]*copyTableCheckpoint `json:"tables"`
+
+	path string
+}
+
+func loadCopyCheckpoint(path string) (*copyCheckpoint, error) {
+	checkpoint := &copyCheckpoint{Tables: make(map[string]*copyTableCheckpoint), path: path}
+	if path == "" {
+		return checkpoint, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return checkpoint, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err = decoder.Decode(checkpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checkpoint %s: %v", path, err)
+	}
+	if checkpoint.Tables == nil {
+		checkpoint.Tables = make(map[string]*copyTableCheckpoint)
+	}
+
+	return checkpoint, nil
+}
+
+func (s *copyCheckpoint) table(name string) *copyTableCheckpoint {
+	state, ok := s.Tables[name]
+	if !ok {
+		state = &copyTableCheckpoint{}
+		s.Tables[name] = state
+	}
+
+	return state
+}
+
+// save writes the checkpoint into a temporary file and renames it, so that the file is never half written
+func (s *copyCheckpoint) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s, "", "    ")
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(s.path+".tmp", data, 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(s.path+".tmp", s.path)
+}
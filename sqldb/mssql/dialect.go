@@ -0,0 +1,255 @@
+package mssql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/csby/database/sqldb"
+	"strconv"
+	"strings"
+	"time"
+
+	driver "github.com/denisenkom/go-mssqldb"
+)
+
+const (
+	errDuplicateKey   = 2627
+	errDuplicateIndex = 2601
+
+	// OFFSET ... FETCH is supported since SQL Server 2012
+	versionOffsetFetch = 2012
+)
+
+type dialect struct {
+}
+
+func (s dialect) Quote(name string) string {
+	return fmt.Sprintf("[%s]", name)
+}
+
+func (s dialect) Placeholder(index int) string {
+	return fmt.Sprintf("@p%d", index)
+}
+
+func (s dialect) Version(sqlAccess sqldb.SqlAccess) int {
+	version := ""
+	err := sqlAccess.QueryRow("SELECT @@VERSION").Scan(&version)
+	if err != nil {
+		return 0
+	}
+
+	vs := strings.Split(version, " ")
+	if len(vs) > 3 {
+		v, err := strconv.Atoi(vs[3])
+		if err == nil {
+			return v
+		}
+	}
+
+	return 0
+}
+
+func (s dialect) Page(sqlAccess sqldb.SqlAccess, sqlBuilder sqldb.SqlBuilder, paging *sqldb.SqlPaging) {
+	version := sqlAccess.Version()
+	if version < versionOffsetFetch {
+		sqlBuilder.Append("SELECT ")
+		sqlBuilder.Append(paging.Fields)
+		sqlBuilder.Append("FROM ( SELECT ")
+		sqlBuilder.Append(paging.Fields)
+		sqlBuilder.Append(fmt.Sprintf(", ROW_NUMBER() OVER(%s) AS [RowNumber] ", paging.Order)).From(paging.Table)
+		paging.Where(sqlBuilder)
+		sqlBuilder.Append(") as t ")
+		sqlBuilder.Append(fmt.Sprintf("where [RowNumber] BETWEEN %d and %d ", paging.Offset+1, paging.Offset+paging.Size))
+		sqlBuilder.Append("order by [RowNumber]")
+	} else {
+		sqlBuilder.Select(paging.Fields, false).From(paging.Table)
+		paging.Where(sqlBuilder)
+		sqlBuilder.Append(paging.Order)
+		sqlBuilder.Append(fmt.Sprintf("OFFSET %d ROWS FETCH NEXT %d ROWS ONLY", paging.Offset, paging.Size))
+	}
+}
+
+func (s dialect) Insert(sqlAccess sqldb.SqlAccess, sqlBuilder sqldb.SqlBuilder, autoField sqldb.SqlField) (uint64, error) {
+	if autoField != nil {
+		query := fmt.Sprintf("%s; SELECT SCOPE_IDENTITY()", sqlBuilder.Query())
+		lastInsertId := uint64(0)
+		err := sqlAccess.QueryRow(query, sqlBuilder.Args()...).Scan(&lastInsertId)
+		if err != nil {
+			return 0, err
+		}
+
+		return lastInsertId, nil
+	}
+
+	_, err := sqlAccess.Exec(sqlBuilder.Query(), sqlBuilder.Args()...)
+	if err != nil {
+		return 0, err
+	}
+
+	return 0, nil
+}
+
+func (s dialect) TableRows(sqlAccess sqldb.SqlAccess, tableName string) (uint64, error) {
+	query := fmt.Sprintf("select [rows] from [sysindexes] where [id] = object_id('%s') and [indid] < 2 and [indid] > -1", tableName)
+	count := uint64(0)
+	row := sqlAccess.QueryRow(query)
+	err := row.Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// BatchLimit returns the limits of multiple rows insert, SQL Server allows 1000 rows of VALUES and 2100 parameters
+func (s dialect) BatchLimit() (rows, args int) {
+	return 1000, 2100 - 1
+}
+
+func (s dialect) IsNoRows(err error) bool {
+	return errors.Is(err, sql.ErrNoRows)
+}
+
+func (s dialect) Error(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var mssqlErr driver.Error
+	if errors.As(err, &mssqlErr) {
+		if mssqlErr.Number == errDuplicateKey || mssqlErr.Number == errDuplicateIndex {
+			return fmt.Errorf("%w: %w", sqldb.ErrDuplicateKey, err)
+		}
+	}
+
+	return err
+}
+
+// ResultValue converts UNIQUEIDENTIFIER (bytes in mixed endian) into the text form
+func (s dialect) ResultValue(column *sqldb.SqlSelectColumn, value interface{}) interface{} {
+	data, ok := value.([]byte)
+	if !ok || strings.ToUpper(column.Type) != "UNIQUEIDENTIFIER" {
+		return value
+	}
+
+	var id driver.UniqueIdentifier
+	if id.Scan(data) != nil {
+		return value
+	}
+
+	return id.String()
+}
+
+func (s dialect) ColumnType(t *sqldb.SqlColumnType) string {
+	switch t.Kind {
+	case sqldb.ColumnString:
+		if t.Length > 4000 {
+			return "nvarchar(max)"
+		}
+		return fmt.Sprintf("nvarchar(%d)", t.Length)
+	case sqldb.ColumnSmallInt:
+		return "smallint"
+	case sqldb.ColumnInt:
+		return "int"
+	case sqldb.ColumnBigInt:
+		return "bigint"
+	case sqldb.ColumnBool:
+		return "bit"
+	case sqldb.ColumnFloat:
+		return "real"
+	case sqldb.ColumnDouble:
+		return "float"
+	case sqldb.ColumnDecimal:
+		return fmt.Sprintf("decimal(%d, %d)", t.Precision, t.Scale)
+	case sqldb.ColumnDate:
+		return "date"
+	case sqldb.ColumnTime:
+		return "time"
+	case sqldb.ColumnDateTime:
+		return "datetime2"
+	case sqldb.ColumnBinary:
+		if t.Length > 8000 {
+			return "varbinary(max)"
+		}
+		return fmt.Sprintf("varbinary(%d)", t.Length)
+	case sqldb.ColumnBlob:
+		return "varbinary(max)"
+	case sqldb.ColumnGuid:
+		return "uniqueidentifier"
+	default:
+		return "nvarchar(max)"
+	}
+}
+
+func (s dialect) AutoIncrement() string {
+	return "IDENTITY(1,1)"
+}
+
+// IdentityInsert allows (on) or disallows (off) inserting the values of identity column
+func (s dialect) IdentityInsert(sqlAccess sqldb.SqlAccess, tableName string, on bool) error {
+	value := "OFF"
+	if on {
+		value = "ON"
+	}
+	_, err := sqlAccess.Exec(fmt.Sprintf("SET IDENTITY_INSERT %s %s", tableName, value))
+
+	return err
+}
+
+// SnapshotOptions reads in the snapshot isolation, which should be allowed by ALLOW_SNAPSHOT_ISOLATION of database
+func (s dialect) SnapshotOptions() *sql.TxOptions {
+	return &sql.TxOptions{Isolation: sql.LevelSnapshot}
+}
+
+// Literal writes the text as N'...', the time as ISO 8601 (for both datetime and datetime2) and the bytes as 0x...
+func (s dialect) Literal(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return fmt.Sprintf("N'%s'", strings.ReplaceAll(v, "'", "''")), true
+	case time.Time:
+		return fmt.Sprintf("'%s'", v.Format("2006-01-02T15:04:05.000")), true
+	case []byte:
+		return fmt.Sprintf("0x%x", v), true
+	}
+
+	return "", false
+}
+
+// ChunkHash aggregates BINARY_CHECKSUM of rows, which ignores the columns of text, ntext, image and xml
+func (s dialect) ChunkHash(fields []string) string {
+	return fmt.Sprintf("CHECKSUM_AGG(BINARY_CHECKSUM(%s))", strings.Join(fields, ", "))
+}
+
+// ReplicaLag estimates the lag by the redo queue (KB) and rate (KB/s) of the local secondary in availability group
+func (s dialect) ReplicaLag(sqlAccess sqldb.SqlAccess) (time.Duration, error) {
+	query := "SELECT ISNULL(redo_queue_size, 0), ISNULL(redo_rate, 0) " +
+		"FROM sys.dm_hadr_database_replica_states " +
+		"WHERE is_local = 1 AND is_primary_replica = 0 AND database_id = DB_ID()"
+	queueSize, rate := int64(0), int64(0)
+	err := sqlAccess.QueryRow(query).Scan(&queueSize, &rate)
+	if err != nil {
+		if s.IsNoRows(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if queueSize < 1 {
+		return 0, nil
+	}
+	if rate < 1 {
+		return 0, fmt.Errorf("redo stopped with %d KB in queue", queueSize)
+	}
+
+	return time.Duration(queueSize) * time.Second / time.Duration(rate), nil
+}
+
+// IsPrimary checks the replica role of database in availability group, the database not in any group is primary
+func (s dialect) IsPrimary(sqlAccess sqldb.SqlAccess) (bool, error) {
+	primary := 0
+	err := sqlAccess.QueryRow("SELECT ISNULL(sys.fn_hadr_is_primary_replica(DB_NAME()), 1)").Scan(&primary)
+	if err != nil {
+		return false, err
+	}
+
+	return primary == 1, nil
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"github.com/csby/database/sqldb"
+	"io"
 	"strconv"
 	"strings"
 
@@ -12,14 +13,15 @@ import (
 
 type mssql struct {
 	connection sqldb.SqlConnection
+	router     *sqldb.SqlRouter
 }
 
 func NewDatabase(conn sqldb.SqlConnection) sqldb.SqlDatabase {
-	return &mssql{connection: conn}
+	return &mssql{connection: conn, router: sqldb.NewRouter(dialect{}, conn)}
 }
 
 func (s *mssql) Open() (*sql.DB, error) {
-	db, err := sql.Open(s.connection.DriverName(), s.connection.SourceName())
+	db, err := s.open(s.sourceName())
 	if err != nil {
 		return nil, err
 	}
@@ -60,7 +62,7 @@ func (s *mssql) Instances(host, port string) ([]sqldb.SqlInstance, error) {
 }
 
 func (s *mssql) Test() (string, error) {
-	db, err := sql.Open(s.connection.DriverName(), s.connection.SourceName())
+	db, err := s.open(s.sourceName())
 	if err != nil {
 		return "", err
 	}
@@ -88,7 +90,11 @@ func (s *mssql) Test() (string, error) {
 }
 
 func (s *mssql) ClusterTest(readOnly bool) (string, error) {
-	db, err := sql.Open(s.connection.DriverName(), s.connection.ClusterSourceName(readOnly))
+	sourceName := s.connection.ClusterSourceName(readOnly)
+	if s.router != nil {
+		sourceName = s.router.SourceName(readOnly)
+	}
+	db, err := s.open(sourceName)
 	if err != nil {
 		return "", err
 	}
@@ -137,7 +143,7 @@ func (s *mssql) Schema() string {
 }
 
 func (s *mssql) Tables() ([]*sqldb.SqlTable, error) {
-	db, err := sql.Open(s.connection.DriverName(), s.connection.SourceName())
+	db, err := s.open(s.sourceName())
 	if err != nil {
 		return nil, err
 	}
@@ -185,7 +191,7 @@ func (s *mssql) Tables() ([]*sqldb.SqlTable, error) {
 }
 
 func (s *mssql) Views() ([]*sqldb.SqlTable, error) {
-	db, err := sql.Open(s.connection.DriverName(), s.connection.SourceName())
+	db, err := s.open(s.sourceName())
 	if err != nil {
 		return nil, err
 	}
@@ -224,7 +230,7 @@ func (s *mssql) Views() ([]*sqldb.SqlTable, error) {
 }
 
 func (s *mssql) Columns(table *sqldb.SqlTable) ([]*sqldb.SqlColumn, error) {
-	db, err := sql.Open(s.connection.DriverName(), s.connection.SourceName())
+	db, err := s.open(s.sourceName())
 	if err != nil {
 		return nil, err
 	}
@@ -447,7 +453,7 @@ func (s *mssql) TableDefinition(table *sqldb.SqlTable) (string, error) {
 }
 
 func (s *mssql) ViewDefinition(viewName string) (string, error) {
-	db, err := sql.Open(s.connection.DriverName(), s.connection.SourceName())
+	db, err := s.open(s.sourceName())
 	if err != nil {
 		return "", err
 	}
@@ -517,56 +523,100 @@ func (s *mssql) columnDateDefault(value string) string {
 }
 
 func (s *mssql) NewAccess(transactional bool) (sqldb.SqlAccess, error) {
-	db, err := sql.Open(s.connection.DriverName(), s.connection.SourceName())
+	db, err := s.open(s.sourceName())
 	if err != nil {
 		return nil, err
 	}
 
-	if transactional {
-		tx, err := db.Begin()
-		if err != nil {
-			db.Close()
-			return nil, err
-		}
+	return sqldb.NewAccess(dialect{}, db, transactional)
+}
 
-		return &transaction{db: db, tx: tx}, nil
+func (s *mssql) NewSnapshotAccess() (sqldb.SqlAccess, error) {
+	db, err := s.open(s.sourceName())
+	if err != nil {
+		return nil, err
 	}
 
-	return &normal{db: db}, nil
+	return sqldb.NewSnapshotAccess(dialect{}, db)
 }
 
 func (s *mssql) NewClusterAccess(transactional bool, readOnly bool) (sqldb.SqlAccess, error) {
-	db, err := sql.Open(s.connection.DriverName(), s.connection.ClusterSourceName(readOnly))
+	sourceName := s.connection.ClusterSourceName(readOnly)
+	if s.router != nil {
+		// the transactions are routed to the primary
+		sourceName = s.router.SourceName(readOnly && !transactional)
+	}
+	db, err := s.open(sourceName)
 	if err != nil {
 		return nil, err
 	}
 
-	if transactional {
-		tx, err := db.Begin()
-		if err != nil {
-			db.Close()
-			return nil, err
-		}
+	return sqldb.NewAccess(dialect{}, db, transactional)
+}
 
-		return &transaction{db: db, tx: tx}, nil
+// open opens the db of source name, the error of credential (if any) is returned rather than opening by the configured password
+func (s *mssql) open(sourceName string) (*sql.DB, error) {
+	err := sqldb.CheckCredential(s.connection)
+	if err != nil {
+		return nil, err
 	}
 
-	return &normal{db: db}, nil
+	return sql.Open(s.connection.DriverName(), sourceName)
+}
+
+// sourceName returns the source name of the primary, which is the current healthy host if failover
+func (s *mssql) sourceName() string {
+	if s.router != nil && s.router.Failover() {
+		return s.router.SourceName(false)
+	}
+
+	return s.connection.SourceName()
+}
+
+// Router returns the router of replicas, nil if no replicas
+func (s *mssql) Router() *sqldb.SqlRouter {
+	return s.router
+}
+
+// readAccess returns the access of a replica if routed and ReadReplica is in filters, or the primary
+func (s *mssql) readAccess(filters []sqldb.SqlFilter) (sqldb.SqlAccess, error) {
+	if s.router == nil || !sqldb.IsReadReplica(filters) {
+		return s.NewAccess(false)
+	}
+
+	return s.NewClusterAccess(false, true)
 }
 
 func (s *mssql) NewEntity() sqldb.SqlEntity {
-	return &entity{}
+	return sqldb.NewEntity(dialect{})
 }
 
 func (s *mssql) NewBuilder() sqldb.SqlBuilder {
-	instance := &builder{}
-	instance.Reset()
-
-	return instance
+	return sqldb.NewBuilder(dialect{})
 }
 
 func (s *mssql) NewFilter(entity interface{}, fieldOr, groupOr bool) sqldb.SqlFilter {
-	return newFilter(entity, fieldOr, groupOr)
+	return sqldb.NewFilter(entity, fieldOr, groupOr)
+}
+
+func (s *mssql) QueryResult(query string, args ...interface{}) (*sqldb.SqlSelectResult, error) {
+	sqlAccess, err := s.NewAccess(false)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.QueryResult(query, args...)
+}
+
+func (s *mssql) QueryResultLimit(limit uint64, query string, args ...interface{}) (*sqldb.SqlSelectResult, error) {
+	sqlAccess, err := s.NewAccess(false)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.QueryResultLimit(limit, query, args...)
 }
 
 func (s *mssql) IsNoRows(err error) bool {
@@ -574,11 +624,7 @@ func (s *mssql) IsNoRows(err error) bool {
 		return false
 	}
 
-	if err == sql.ErrNoRows {
-		return true
-	}
-
-	return false
+	return dialect{}.IsNoRows(err)
 }
 
 func (s *mssql) Insert(entity interface{}) (uint64, error) {
@@ -611,6 +657,16 @@ func (s *mssql) Delete(entity interface{}, filters ...sqldb.SqlFilter) (uint64,
 	return sqlAccess.Delete(entity, filters...)
 }
 
+func (s *mssql) HardDelete(entity interface{}, filters ...sqldb.SqlFilter) (uint64, error) {
+	sqlAccess, err := s.NewAccess(false)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.HardDelete(entity, filters...)
+}
+
 func (s *mssql) Update(entity interface{}, filters ...sqldb.SqlFilter) (uint64, error) {
 	sqlAccess, err := s.NewAccess(false)
 	if err != nil {
@@ -651,8 +707,28 @@ func (s *mssql) UpdateSelectiveByPrimaryKey(entity interface{}) (uint64, error)
 	return sqlAccess.UpdateSelectiveByPrimaryKey(entity)
 }
 
-func (s *mssql) SelectOne(entity interface{}, filters ...sqldb.SqlFilter) error {
+func (s *mssql) UpdateFields(entity interface{}, columns []string, filters ...sqldb.SqlFilter) (uint64, error) {
+	sqlAccess, err := s.NewAccess(false)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.UpdateFields(entity, columns, filters...)
+}
+
+func (s *mssql) UpdateMap(entity interface{}, values map[string]interface{}, filters ...sqldb.SqlFilter) (uint64, error) {
 	sqlAccess, err := s.NewAccess(false)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.UpdateMap(entity, values, filters...)
+}
+
+func (s *mssql) SelectOne(entity interface{}, filters ...sqldb.SqlFilter) error {
+	sqlAccess, err := s.readAccess(filters)
 	if err != nil {
 		return err
 	}
@@ -662,7 +738,7 @@ func (s *mssql) SelectOne(entity interface{}, filters ...sqldb.SqlFilter) error
 }
 
 func (s *mssql) SelectDistinct(entity interface{}, row func(index uint64, evt sqldb.SqlEvent), order interface{}, filters ...sqldb.SqlFilter) error {
-	sqlAccess, err := s.NewAccess(false)
+	sqlAccess, err := s.readAccess(filters)
 	if err != nil {
 		return err
 	}
@@ -672,7 +748,7 @@ func (s *mssql) SelectDistinct(entity interface{}, row func(index uint64, evt sq
 }
 
 func (s *mssql) SelectList(entity interface{}, row func(index uint64, evt sqldb.SqlEvent), order interface{}, filters ...sqldb.SqlFilter) error {
-	sqlAccess, err := s.NewAccess(false)
+	sqlAccess, err := s.readAccess(filters)
 	if err != nil {
 		return err
 	}
@@ -682,7 +758,7 @@ func (s *mssql) SelectList(entity interface{}, row func(index uint64, evt sqldb.
 }
 
 func (s *mssql) SelectPage(entity interface{}, page func(total, page, size, index uint64), row func(index uint64, evt sqldb.SqlEvent), size, index uint64, order interface{}, filters ...sqldb.SqlFilter) error {
-	sqlAccess, err := s.NewAccess(false)
+	sqlAccess, err := s.readAccess(filters)
 	if err != nil {
 		return err
 	}
@@ -691,8 +767,66 @@ func (s *mssql) SelectPage(entity interface{}, page func(total, page, size, inde
 	return sqlAccess.SelectPage(entity, page, row, size, index, order, filters...)
 }
 
+// SelectRows opens an access for the rows, which is closed with the rows
+func (s *mssql) SelectRows(entity interface{}, order interface{}, filters ...sqldb.SqlFilter) (sqldb.SqlRows, error) {
+	sqlAccess, err := s.readAccess(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := sqlAccess.SelectRows(entity, order, filters...)
+	if err != nil {
+		sqlAccess.Close()
+		return nil, err
+	}
+
+	return sqldb.CloseWithRows(rows, sqlAccess), nil
+}
+
+func (s *mssql) ImportTable(table *sqldb.SqlTable, r io.Reader, options *sqldb.ImportOptions) (*sqldb.ImportResult, error) {
+	columns, err := s.Columns(table)
+	if err != nil {
+		return nil, err
+	}
+	sqlAccess, err := s.NewAccess(true)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlAccess.Close()
+
+	result, err := sqlAccess.ImportTable(table, columns, r, options)
+	if err == nil {
+		err = sqlAccess.Commit()
+	}
+	if err != nil && result != nil {
+		// the inserted rows are rolled back
+		result.Inserted = 0
+	}
+
+	return result, err
+}
+
+func (s *mssql) ImportEntity(entity interface{}, r io.Reader, options *sqldb.ImportOptions) (*sqldb.ImportResult, error) {
+	sqlAccess, err := s.NewAccess(true)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlAccess.Close()
+
+	result, err := sqlAccess.ImportEntity(entity, r, options)
+	if err == nil {
+		err = sqlAccess.Commit()
+	}
+	if err != nil && result != nil {
+		// the inserted rows are rolled back
+		result.Inserted = 0
+	}
+
+	return result, err
+}
+
 func (s *mssql) SelectCount(entity interface{}, filters ...sqldb.SqlFilter) (uint64, error) {
-	sqlAccess, err := s.NewAccess(false)
+	sqlAccess, err := s.readAccess(filters)
 	if err != nil {
 		return 0, err
 	}
@@ -3,8 +3,10 @@ package mssql
 import (
 	"fmt"
 	"github.com/csby/database/sqldb"
+	"github.com/csby/database/sqldb/sqltest"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
 	"testing"
@@ -14,7 +16,7 @@ func TestTest(t *testing.T) {
 	db := &mssql{
 		connection: testConnection(),
 	}
-	t.Log("connection:", db.connection.SourceName())
+	t.Log("connection:", sqldb.RedactSourceName(db.connection.SourceName()))
 
 	dbVer, err := db.Test()
 	if err != nil {
@@ -169,6 +171,34 @@ func TestMssql_SelectPage(t *testing.T) {
 	}
 }
 
+func TestMssql_Conformance(t *testing.T) {
+	suite := &sqltest.Suite{
+		Database: NewDatabase(testConnection()),
+		Setup: []string{
+			"IF OBJECT_ID('sqltest_item', 'U') IS NOT NULL DROP TABLE [sqltest_item]",
+			"CREATE TABLE [sqltest_item] (" +
+				"[id] BIGINT IDENTITY(1,1) NOT NULL PRIMARY KEY, " +
+				"[code] NVARCHAR(32) NOT NULL, " +
+				"[name] NVARCHAR(64) NOT NULL DEFAULT '', " +
+				"[score] BIGINT NOT NULL DEFAULT 0, " +
+				"[deleted_at] DATETIME NULL, " +
+				"[version] BIGINT NOT NULL DEFAULT 0, " +
+				"[created_at] DATETIME NULL, " +
+				"[updated_at] DATETIME NULL, " +
+				"[created_by] NVARCHAR(32) NOT NULL DEFAULT '', " +
+				"[updated_by] NVARCHAR(32) NOT NULL DEFAULT '', " +
+				"[attrs] NVARCHAR(MAX) NULL)",
+			"IF OBJECT_ID('sqltest_tag', 'U') IS NOT NULL DROP TABLE [sqltest_tag]",
+			"CREATE TABLE [sqltest_tag] (" +
+				"[id] BIGINT IDENTITY(1,1) NOT NULL PRIMARY KEY, " +
+				"[item_id] BIGINT NOT NULL, " +
+				"[label] NVARCHAR(32) NOT NULL DEFAULT '')",
+		},
+	}
+
+	suite.Run(t)
+}
+
 func testConnection() *Connection {
 	goPath := os.Getenv("GOPATH")
 	paths := strings.Split(goPath, string(os.PathListSeparator))
@@ -254,3 +284,54 @@ func (s tabOrder) SchemaName() string {
 func (s tabOrder) TableName() string {
 	return "T_ORDER"
 }
+
+func TestConnection_ParseDSN(t *testing.T) {
+	connection := &Connection{
+		Host:     "db.local",
+		Port:     1433,
+		Instance: "SQLExpress",
+		Schema:   "test",
+		Intent:   2,
+		User:     "sa",
+		Password: "p@ss/word",
+		Timeout:  30,
+		Params:   map[string]string{"encrypt": "true", "TrustServerCertificate": "true"},
+	}
+
+	parsed, err := ParseDSN(connection.SourceName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, connection) {
+		t.Errorf("expect %+v, actual %+v", connection, parsed)
+	}
+}
+
+func TestConnection_Tls(t *testing.T) {
+	connection := &Connection{
+		Host:   "db.local",
+		Port:   1433,
+		Schema: "test",
+		User:   "sa",
+		Tls:    &sqldb.SqlTls{Mode: sqldb.TlsVerifyFull, CA: "/etc/ssl/ca.pem", ServerName: "db.internal"},
+	}
+	parsed, err := ParseDSN(connection.SourceName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"encrypt":                "true",
+		"TrustServerCertificate": "false",
+		"certificate":            "/etc/ssl/ca.pem",
+		"hostNameInCertificate":  "db.internal",
+	}
+	if !reflect.DeepEqual(parsed.Params, expected) {
+		t.Errorf("expect %v, actual %v", expected, parsed.Params)
+	}
+
+	connection.Tls = &sqldb.SqlTls{Mode: sqldb.TlsRequire}
+	parsed, _ = ParseDSN(connection.ClusterSourceName(true))
+	if parsed.Params["encrypt"] != "true" || parsed.Params["TrustServerCertificate"] != "true" {
+		t.Errorf("unexpected params %v", parsed.Params)
+	}
+}
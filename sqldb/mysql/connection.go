@@ -1,21 +1,40 @@
 package mysql
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"github.com/csby/database/sqldb"
+	driver "github.com/go-sql-driver/mysql"
 	"io/ioutil"
+	"net"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
+// tlsConfigs is the names of tls configs registered to the driver
+var tlsConfigs sync.Map
+
 type Connection struct {
-	Host     string `json:"host" note:"服务器名称或IP, 默认127.0.0.1"`
-	Port     int    `json:"port" note:"服务器端口, 默认3306"`
-	Schema   string `json:"schema" note:"数据库名称, 默认mysql"`
-	Charset  string `json:"charset" note:"字符集, 默认utf8"`
-	Timeout  int    `json:"timeout" note:"连接超时时间，单位秒，默认10"`
-	User     string `json:"user" note:"登录名"`
-	Password string `json:"password" note:"登陆密码"`
+	Host       string               `json:"host" note:"服务器名称或IP, 默认127.0.0.1"`
+	Port       int                  `json:"port" note:"服务器端口, 默认3306"`
+	Schema     string               `json:"schema" note:"数据库名称, 默认mysql"`
+	Charset    string               `json:"charset" note:"字符集, 默认utf8"`
+	Timeout    int                  `json:"timeout" note:"连接超时时间，单位秒，默认10"`
+	User       string               `json:"user" note:"登录名"`
+	Password   string               `json:"password" note:"登陆密码"`
+	Credential *sqldb.SqlCredential `json:"credential" note:"凭据提供方式(文件, 环境变量或外部命令), 打开连接时获取登录名和密码以支持轮换, 为空时使用登录名和登陆密码"`
+	Tls        *sqldb.SqlTls        `json:"tls" note:"TLS加密设置, 为空时使用驱动默认值(不加密)"`
+	Params     map[string]string    `json:"params" note:"其它驱动参数(按驱动格式原样写入), 如tls, collation, 同名时替换默认参数(charset, timeout, parseTime, loc)"`
+	Hosts      []*sqldb.SqlEndpoint `json:"hosts" note:"故障转移的备用服务器, 按优先顺序, 主服务器不可用或只读时使用, 除服务器和端口外连接参数相同"`
+	Replicas   []*sqldb.SqlEndpoint `json:"replicas" note:"只读副本, 除服务器和端口外与主库连接参数相同"`
+	Routing    *sqldb.SqlRouting    `json:"routing" note:"读写分离路由, 为空时使用默认值"`
 }
 
 func (s *Connection) DriverName() string {
@@ -23,14 +42,170 @@ func (s *Connection) DriverName() string {
 }
 
 func (s *Connection) SourceName() string {
-	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&timeout=%ds&parseTime=true&loc=Local",
-		s.User,
-		s.Password,
+	// user:password@tcp(host:port)/schema?charset=utf8&timeout=10s&parseTime=true&loc=Local
+	defaults := [][2]string{
+		{"charset", s.Charset},
+		{"timeout", fmt.Sprintf("%ds", s.Timeout)},
+		{"parseTime", "true"},
+		{"loc", "Local"},
+	}
+	if s.Tls != nil {
+		tlsParam, _ := s.tlsParam()
+		defaults = append(defaults, [2]string{"tls", tlsParam})
+	}
+	params := make([]string, 0, len(defaults)+len(s.Params))
+	for _, param := range defaults {
+		if value, ok := s.Params[param[0]]; ok {
+			param[1] = value
+		}
+		params = append(params, fmt.Sprintf("%s=%s", param[0], param[1]))
+	}
+	for _, key := range paramKeys(s.Params) {
+		switch key {
+		case "charset", "timeout", "parseTime", "loc":
+			continue
+		case "tls":
+			if s.Tls != nil {
+				continue
+			}
+		}
+		params = append(params, fmt.Sprintf("%s=%s", key, s.Params[key]))
+	}
+
+	user, password := s.credential()
+	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?%s",
+		user,
+		password,
 		s.Host,
 		s.Port,
 		s.Schema,
-		s.Charset,
-		s.Timeout)
+		strings.Join(params, "&"))
+}
+
+// tlsParam returns the value of parameter tls, the custom config (e.g. of CA) is registered to the driver
+// once by the name of settings, so the files of certificates are read on the first open of the settings.
+func (s *Connection) tlsParam() (string, error) {
+	if !s.Tls.Enabled() {
+		return "false", nil
+	}
+	if s.Tls.CA == "" && s.Tls.Cert == "" && s.Tls.Key == "" && s.Tls.ServerName == "" {
+		switch s.Tls.Mode {
+		case sqldb.TlsRequire:
+			return "skip-verify", nil
+		case "", sqldb.TlsVerifyFull:
+			return "true", nil
+		}
+	}
+
+	settings, _ := json.Marshal(s.Tls)
+	name := fmt.Sprintf("sqldb-%x", sha256.Sum256(settings))[:22]
+	if _, ok := tlsConfigs.Load(name); ok {
+		return name, nil
+	}
+	config, err := s.Tls.Config()
+	if err != nil {
+		// not registered, so it is read again on the next open, e.g. after the files fixed
+		return name, err
+	}
+	driver.RegisterTLSConfig(name, config)
+	tlsConfigs.Store(name, true)
+
+	return name, nil
+}
+
+// TlsError returns the error of the config of Tls, e.g. the certificate not found, nil if not set
+func (s *Connection) TlsError() error {
+	_, err := s.tlsParam()
+	return err
+}
+
+func paramKeys(params map[string]string) []string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// ParseDSN parses the connection string of driver, e.g. user:password@tcp(host:port)/schema?charset=utf8,
+// it is the inverse of SourceName. The string is parsed by the driver, and the parameters are kept as written.
+func ParseDSN(dsn string) (*Connection, error) {
+	cfg, err := driver.ParseDSN(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dsn: %v", err)
+	}
+	if cfg.Net != "tcp" {
+		return nil, fmt.Errorf("invalid dsn: protocol '%s' not supported", cfg.Net)
+	}
+	host, port, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dsn: %v", err)
+	}
+	s := &Connection{
+		Host:     host,
+		Schema:   cfg.DBName,
+		User:     cfg.User,
+		Password: cfg.Passwd,
+	}
+	s.Port, err = strconv.Atoi(port)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dsn: port '%s'", port)
+	}
+
+	// the parameters follow '?' after the last '/', as the driver parses
+	query := dsn[strings.LastIndex(dsn, "/")+1:]
+	if index := strings.Index(query, "?"); index >= 0 {
+		query = query[index+1:]
+	} else {
+		query = ""
+	}
+	for _, param := range strings.Split(query, "&") {
+		if param == "" {
+			continue
+		}
+		key, value := param, ""
+		if index := strings.Index(param, "="); index >= 0 {
+			key, value = param[:index], param[index+1:]
+		}
+		switch {
+		case key == "charset":
+			s.Charset = value
+		case key == "timeout" && cfg.Timeout%time.Second == 0:
+			s.Timeout = int(cfg.Timeout / time.Second)
+		case key == "parseTime" && cfg.ParseTime, key == "loc" && cfg.Loc == time.Local:
+		default:
+			if s.Params == nil {
+				s.Params = make(map[string]string)
+			}
+			s.Params[key] = value
+		}
+	}
+
+	return s, nil
+}
+
+// LoadFromEnv loads the connection from the environment variables with prefix (see sqldb.LoadEnv), e.g. DB_HOST,
+// the connection string in DSN (e.g. DB_DSN) is parsed first if present, then the others override.
+func (s *Connection) LoadFromEnv(prefix string) error {
+	if dsn, ok := os.LookupEnv(sqldb.EnvName(prefix, "dsn")); ok {
+		c, err := ParseDSN(dsn)
+		if err != nil {
+			return err
+		}
+		c.Hosts, c.Replicas, c.Routing = s.Hosts, s.Replicas, s.Routing
+		c.Credential, c.Tls = s.Credential, s.Tls
+		*s = *c
+	}
+
+	err := sqldb.LoadEnv(prefix, s)
+	if err != nil {
+		return err
+	}
+	s.Password, err = sqldb.DecryptSecret(s.Password)
+
+	return err
 }
 
 func (s *Connection) ClusterSourceName(readOnly bool) string {
@@ -41,8 +216,66 @@ func (s *Connection) SchemaName() string {
 	return s.Schema
 }
 
+// ReplicaSourceNames returns the source names of replicas, which connect as the primary except host and port
+func (s *Connection) ReplicaSourceNames() []string {
+	return s.endpointSourceNames(s.Replicas)
+}
+
+// FailoverSourceNames returns the source names of the host and failover hosts in order
+func (s *Connection) FailoverSourceNames() []string {
+	return append([]string{s.SourceName()}, s.endpointSourceNames(s.Hosts)...)
+}
+
+func (s *Connection) endpointSourceNames(endpoints []*sqldb.SqlEndpoint) []string {
+	sourceNames := make([]string, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		if endpoint == nil || endpoint.Host == "" {
+			continue
+		}
+		c := *s
+		c.Host = endpoint.Host
+		if endpoint.Port > 0 {
+			c.Port = endpoint.Port
+		}
+		sourceNames = append(sourceNames, c.SourceName())
+	}
+
+	return sourceNames
+}
+
+func (s *Connection) RoutingOptions() *sqldb.SqlRouting {
+	return s.Routing
+}
+
+// credential returns the user and password from Credential, or the configured ones if not set or failed
+func (s *Connection) credential() (string, string) {
+	user, password, _ := sqldb.Credential(s.Credential, s.User, s.Password)
+	return user, password
+}
+
+// CredentialError returns the error of Credential, nil if not set
+func (s *Connection) CredentialError() error {
+	_, _, err := sqldb.Credential(s.Credential, s.User, s.Password)
+	return err
+}
+
+// String returns the source name without password for logging
+func (s *Connection) String() string {
+	c := *s
+	c.Credential = nil
+
+	return sqldb.RedactSourceName(c.SourceName())
+}
+
+// SaveToFile saves the connection only for the owner, the password is encrypted if KeyProvider set (see sqldb.EncryptSecret)
 func (s *Connection) SaveToFile(filePath string) error {
-	bytes, err := json.MarshalIndent(s, "", "    ")
+	password, err := sqldb.EncryptSecret(s.Password)
+	if err != nil {
+		return err
+	}
+	c := *s
+	c.Password = password
+	bytes, err := json.MarshalIndent(&c, "", "    ")
 	if err != nil {
 		return err
 	}
@@ -50,14 +283,18 @@ func (s *Connection) SaveToFile(filePath string) error {
 	fileFolder := filepath.Dir(filePath)
 	_, err = os.Stat(fileFolder)
 	if os.IsNotExist(err) {
-		os.MkdirAll(fileFolder, 0777)
+		os.MkdirAll(fileFolder, 0700)
 	}
 
-	file, err := os.Create(filePath)
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
+	err = file.Chmod(0600)
+	if err != nil {
+		return err
+	}
 
 	_, err = fmt.Fprint(file, string(bytes[:]))
 
@@ -70,7 +307,13 @@ func (s *Connection) LoadFromFile(filePath string) error {
 		return err
 	}
 
-	return json.Unmarshal(bytes, s)
+	err = json.Unmarshal(bytes, s)
+	if err != nil {
+		return err
+	}
+	s.Password, err = sqldb.DecryptSecret(s.Password)
+
+	return err
 }
 
 func (s *Connection) CopyTo(target *Connection) int {
@@ -108,5 +351,30 @@ func (s *Connection) CopyTo(target *Connection) int {
 		count++
 	}
 
+	if !reflect.DeepEqual(target.Params, s.Params) {
+		target.Params = s.Params
+		count++
+	}
+	if !reflect.DeepEqual(target.Hosts, s.Hosts) {
+		target.Hosts = s.Hosts
+		count++
+	}
+	if !reflect.DeepEqual(target.Replicas, s.Replicas) {
+		target.Replicas = s.Replicas
+		count++
+	}
+	if !reflect.DeepEqual(target.Routing, s.Routing) {
+		target.Routing = s.Routing
+		count++
+	}
+	if !reflect.DeepEqual(target.Tls, s.Tls) {
+		target.Tls = s.Tls
+		count++
+	}
+	if !reflect.DeepEqual(target.Credential, s.Credential) {
+		target.Credential = s.Credential
+		count++
+	}
+
 	return count
 }
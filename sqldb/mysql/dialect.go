@@ -0,0 +1,218 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/csby/database/sqldb"
+	"strconv"
+	"strings"
+	"time"
+
+	driver "github.com/go-sql-driver/mysql"
+)
+
+const (
+	errDuplicateEntry = 1062
+)
+
+type dialect struct {
+}
+
+func (s dialect) Quote(name string) string {
+	return fmt.Sprintf("`%s`", name)
+}
+
+func (s dialect) Placeholder(index int) string {
+	return "?"
+}
+
+func (s dialect) Version(sqlAccess sqldb.SqlAccess) int {
+	return 0
+}
+
+func (s dialect) Page(sqlAccess sqldb.SqlAccess, sqlBuilder sqldb.SqlBuilder, paging *sqldb.SqlPaging) {
+	sqlBuilder.Select(paging.Fields, false).From(paging.Table)
+	paging.Where(sqlBuilder)
+	sqlBuilder.Append(paging.Order)
+	sqlBuilder.Append("LIMIT ?, ?", paging.Offset, paging.Size)
+}
+
+func (s dialect) Insert(sqlAccess sqldb.SqlAccess, sqlBuilder sqldb.SqlBuilder, autoField sqldb.SqlField) (uint64, error) {
+	result, err := sqlAccess.Exec(sqlBuilder.Query(), sqlBuilder.Args()...)
+	if err != nil {
+		return 0, err
+	}
+
+	if autoField != nil {
+		id, err := result.LastInsertId()
+		if err != nil {
+			return 0, err
+		}
+		return uint64(id), nil
+	}
+
+	return 0, nil
+}
+
+// BatchLimit returns the limits of multiple rows insert, the placeholders are limited to 65535 by the protocol
+func (s dialect) BatchLimit() (rows, args int) {
+	return 1000, 65535
+}
+
+func (s dialect) IsNoRows(err error) bool {
+	return errors.Is(err, sql.ErrNoRows)
+}
+
+func (s dialect) Error(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var mysqlErr *driver.MySQLError
+	if errors.As(err, &mysqlErr) {
+		if mysqlErr.Number == errDuplicateEntry {
+			return fmt.Errorf("%w: %w", sqldb.ErrDuplicateKey, err)
+		}
+	}
+
+	return err
+}
+
+// ResultValue converts BIT (bytes in big endian) into number
+func (s dialect) ResultValue(column *sqldb.SqlSelectColumn, value interface{}) interface{} {
+	data, ok := value.([]byte)
+	if !ok || strings.ToUpper(column.Type) != "BIT" {
+		return value
+	}
+
+	n := uint64(0)
+	for _, b := range data {
+		n = n<<8 | uint64(b)
+	}
+
+	return n
+}
+
+func (s dialect) ColumnType(t *sqldb.SqlColumnType) string {
+	switch t.Kind {
+	case sqldb.ColumnString:
+		if t.Length > 16383 {
+			return "longtext"
+		}
+		return fmt.Sprintf("varchar(%d)", t.Length)
+	case sqldb.ColumnSmallInt:
+		return "smallint"
+	case sqldb.ColumnInt:
+		return "int"
+	case sqldb.ColumnBigInt:
+		return "bigint"
+	case sqldb.ColumnBool:
+		return "tinyint(1)"
+	case sqldb.ColumnFloat:
+		return "float"
+	case sqldb.ColumnDouble:
+		return "double"
+	case sqldb.ColumnDecimal:
+		return fmt.Sprintf("decimal(%d,%d)", t.Precision, t.Scale)
+	case sqldb.ColumnDate:
+		return "date"
+	case sqldb.ColumnTime:
+		return "time"
+	case sqldb.ColumnDateTime:
+		return "datetime(6)"
+	case sqldb.ColumnBinary:
+		return fmt.Sprintf("varbinary(%d)", t.Length)
+	case sqldb.ColumnBlob:
+		return "longblob"
+	case sqldb.ColumnGuid:
+		return "char(36)"
+	default:
+		return "longtext"
+	}
+}
+
+func (s dialect) AutoIncrement() string {
+	return "AUTO_INCREMENT"
+}
+
+// SnapshotOptions reads in the repeatable read transaction, the snapshot is established by the first read
+func (s dialect) SnapshotOptions() *sql.TxOptions {
+	return &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+}
+
+// Literal escapes the backslashes of text, which is the escape character of mysql
+func (s dialect) Literal(value interface{}) (string, bool) {
+	v, ok := value.(string)
+	if !ok {
+		return "", false
+	}
+
+	return fmt.Sprintf("'%s'", strings.NewReplacer(`\`, `\\`, `'`, `''`).Replace(v)), true
+}
+
+// ChunkHash xors the crc32 of rows, the nulls are distinguished from the empty texts by ISNULL
+func (s dialect) ChunkHash(fields []string) string {
+	items := make([]string, 0, 2*len(fields))
+	for _, field := range fields {
+		items = append(items, field, fmt.Sprintf("ISNULL(%s)", field))
+	}
+
+	return fmt.Sprintf("BIT_XOR(CRC32(CONCAT_WS('|', %s)))", strings.Join(items, ", "))
+}
+
+// ReplicaLag returns Seconds_Behind_Source (or Seconds_Behind_Master) of replica status,
+// it fails if the replication is stopped.
+func (s dialect) ReplicaLag(sqlAccess sqldb.SqlAccess) (time.Duration, error) {
+	rows, err := sqlAccess.Query("SHOW REPLICA STATUS")
+	if err != nil {
+		// before 8.0.22
+		rows, err = sqlAccess.Query("SHOW SLAVE STATUS")
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return 0, rows.Err()
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	values := make([]sql.NullString, len(columns))
+	scans := make([]interface{}, len(columns))
+	for i := range values {
+		scans[i] = &values[i]
+	}
+	err = rows.Scan(scans...)
+	if err != nil {
+		return 0, err
+	}
+	for i, column := range columns {
+		if column != "Seconds_Behind_Master" && column != "Seconds_Behind_Source" {
+			continue
+		}
+		if !values[i].Valid {
+			return 0, fmt.Errorf("replication stopped")
+		}
+		seconds, err := strconv.ParseInt(values[i].String, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	return 0, nil
+}
+
+func (s dialect) IsPrimary(sqlAccess sqldb.SqlAccess) (bool, error) {
+	readOnly := 0
+	err := sqlAccess.QueryRow("SELECT @@global.read_only").Scan(&readOnly)
+	if err != nil {
+		return false, err
+	}
+
+	return readOnly == 0, nil
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"github.com/csby/database/sqldb"
+	"io"
 	"strings"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -11,14 +12,15 @@ import (
 
 type mysql struct {
 	connection sqldb.SqlConnection
+	router     *sqldb.SqlRouter
 }
 
 func NewDatabase(conn sqldb.SqlConnection) sqldb.SqlDatabase {
-	return &mysql{connection: conn}
+	return &mysql{connection: conn, router: sqldb.NewRouter(dialect{}, conn)}
 }
 
 func (s *mysql) Open() (*sql.DB, error) {
-	db, err := sql.Open(s.connection.DriverName(), s.connection.SourceName())
+	db, err := s.open(s.sourceName())
 	if err != nil {
 		return nil, err
 	}
@@ -31,7 +33,11 @@ func (s *mysql) Instances(host, port string) ([]sqldb.SqlInstance, error) {
 }
 
 func (s *mysql) Test() (string, error) {
-	db, err := sql.Open(s.connection.DriverName(), s.connection.SourceName())
+	return s.test(s.sourceName())
+}
+
+func (s *mysql) test(sourceName string) (string, error) {
+	db, err := s.open(sourceName)
 	if err != nil {
 		return "", err
 	}
@@ -49,7 +55,11 @@ func (s *mysql) Test() (string, error) {
 }
 
 func (s *mysql) ClusterTest(readOnly bool) (string, error) {
-	return s.Test()
+	if s.router == nil {
+		return s.Test()
+	}
+
+	return s.test(s.router.SourceName(readOnly))
 }
 
 func (s *mysql) Schema() string {
@@ -57,7 +67,7 @@ func (s *mysql) Schema() string {
 }
 
 func (s *mysql) Tables() ([]*sqldb.SqlTable, error) {
-	db, err := sql.Open(s.connection.DriverName(), s.connection.SourceName())
+	db, err := s.open(s.sourceName())
 	if err != nil {
 		return nil, err
 	}
@@ -97,7 +107,7 @@ func (s *mysql) Tables() ([]*sqldb.SqlTable, error) {
 }
 
 func (s *mysql) Views() ([]*sqldb.SqlTable, error) {
-	db, err := sql.Open(s.connection.DriverName(), s.connection.SourceName())
+	db, err := s.open(s.sourceName())
 	if err != nil {
 		return nil, err
 	}
@@ -137,7 +147,7 @@ func (s *mysql) Views() ([]*sqldb.SqlTable, error) {
 }
 
 func (s *mysql) Columns(table *sqldb.SqlTable) ([]*sqldb.SqlColumn, error) {
-	db, err := sql.Open(s.connection.DriverName(), s.connection.SourceName())
+	db, err := s.open(s.sourceName())
 	if err != nil {
 		return nil, err
 	}
@@ -283,7 +293,7 @@ func (s *mysql) TableDefinition(table *sqldb.SqlTable) (string, error) {
 }
 
 func (s *mysql) ViewDefinition(viewName string) (string, error) {
-	db, err := sql.Open(s.connection.DriverName(), s.connection.SourceName())
+	db, err := s.open(s.sourceName())
 	if err != nil {
 		return "", err
 	}
@@ -311,41 +321,111 @@ func (s *mysql) ViewDefinition(viewName string) (string, error) {
 }
 
 func (s *mysql) NewAccess(transactional bool) (sqldb.SqlAccess, error) {
-	db, err := sql.Open(s.connection.DriverName(), s.connection.SourceName())
+	db, err := s.open(s.sourceName())
+	if err != nil {
+		return nil, err
+	}
+
+	return sqldb.NewAccess(dialect{}, db, transactional)
+}
+
+func (s *mysql) NewSnapshotAccess() (sqldb.SqlAccess, error) {
+	db, err := s.open(s.sourceName())
+	if err != nil {
+		return nil, err
+	}
+
+	return sqldb.NewSnapshotAccess(dialect{}, db)
+}
+
+func (s *mysql) NewClusterAccess(transactional bool, readOnly bool) (sqldb.SqlAccess, error) {
+	if s.router == nil {
+		return s.NewAccess(transactional)
+	}
+	// the transactions are routed to the primary
+	db, err := s.open(s.router.SourceName(readOnly && !transactional))
 	if err != nil {
 		return nil, err
 	}
 
-	if transactional {
-		tx, err := db.Begin()
+	return sqldb.NewAccess(dialect{}, db, transactional)
+}
+
+// tlsConnection is implemented by Connection, of which the tls config is registered to the driver by SourceName
+type tlsConnection interface {
+	TlsError() error
+}
+
+// open opens the db of source name, the error of credential or tls config (if any) is returned
+// rather than opening by the configured password or the unregistered tls config
+func (s *mysql) open(sourceName string) (*sql.DB, error) {
+	err := sqldb.CheckCredential(s.connection)
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := s.connection.(tlsConnection); ok {
+		err = c.TlsError()
 		if err != nil {
-			db.Close()
-			return nil, err
+			return nil, fmt.Errorf("tls: %w", err)
 		}
+	}
 
-		return &transaction{db: db, tx: tx}, nil
+	return sql.Open(s.connection.DriverName(), sourceName)
+}
+
+// sourceName returns the source name of the primary, which is the current healthy host if failover
+func (s *mysql) sourceName() string {
+	if s.router != nil && s.router.Failover() {
+		return s.router.SourceName(false)
 	}
 
-	return &normal{db: db}, nil
+	return s.connection.SourceName()
 }
 
-func (s *mysql) NewClusterAccess(transactional bool, readOnly bool) (sqldb.SqlAccess, error) {
-	return s.NewAccess(transactional)
+// Router returns the router of replicas, nil if no replicas
+func (s *mysql) Router() *sqldb.SqlRouter {
+	return s.router
+}
+
+// readAccess returns the access of a replica if routed and ReadReplica is in filters, or the primary
+func (s *mysql) readAccess(filters []sqldb.SqlFilter) (sqldb.SqlAccess, error) {
+	if s.router == nil || !sqldb.IsReadReplica(filters) {
+		return s.NewAccess(false)
+	}
+
+	return s.NewClusterAccess(false, true)
 }
 
 func (s *mysql) NewEntity() sqldb.SqlEntity {
-	return &entity{}
+	return sqldb.NewEntity(dialect{})
 }
 
 func (s *mysql) NewBuilder() sqldb.SqlBuilder {
-	instance := &builder{}
-	instance.Reset()
-
-	return instance
+	return sqldb.NewBuilder(dialect{})
 }
 
 func (s *mysql) NewFilter(entity interface{}, fieldOr, groupOr bool) sqldb.SqlFilter {
-	return newFilter(entity, fieldOr, groupOr)
+	return sqldb.NewFilter(entity, fieldOr, groupOr)
+}
+
+func (s *mysql) QueryResult(query string, args ...interface{}) (*sqldb.SqlSelectResult, error) {
+	sqlAccess, err := s.NewAccess(false)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.QueryResult(query, args...)
+}
+
+func (s *mysql) QueryResultLimit(limit uint64, query string, args ...interface{}) (*sqldb.SqlSelectResult, error) {
+	sqlAccess, err := s.NewAccess(false)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.QueryResultLimit(limit, query, args...)
 }
 
 func (s *mysql) IsNoRows(err error) bool {
@@ -353,11 +433,7 @@ func (s *mysql) IsNoRows(err error) bool {
 		return false
 	}
 
-	if err == sql.ErrNoRows {
-		return true
-	}
-
-	return false
+	return dialect{}.IsNoRows(err)
 }
 
 func (s *mysql) Insert(entity interface{}) (uint64, error) {
@@ -390,6 +466,16 @@ func (s *mysql) Delete(entity interface{}, filters ...sqldb.SqlFilter) (uint64,
 	return sqlAccess.Delete(entity, filters...)
 }
 
+func (s *mysql) HardDelete(entity interface{}, filters ...sqldb.SqlFilter) (uint64, error) {
+	sqlAccess, err := s.NewAccess(false)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.HardDelete(entity, filters...)
+}
+
 func (s *mysql) Update(entity interface{}, filters ...sqldb.SqlFilter) (uint64, error) {
 	sqlAccess, err := s.NewAccess(false)
 	if err != nil {
@@ -430,8 +516,28 @@ func (s *mysql) UpdateSelectiveByPrimaryKey(entity interface{}) (uint64, error)
 	return sqlAccess.UpdateSelectiveByPrimaryKey(entity)
 }
 
-func (s *mysql) SelectOne(entity interface{}, filters ...sqldb.SqlFilter) error {
+func (s *mysql) UpdateFields(entity interface{}, columns []string, filters ...sqldb.SqlFilter) (uint64, error) {
 	sqlAccess, err := s.NewAccess(false)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.UpdateFields(entity, columns, filters...)
+}
+
+func (s *mysql) UpdateMap(entity interface{}, values map[string]interface{}, filters ...sqldb.SqlFilter) (uint64, error) {
+	sqlAccess, err := s.NewAccess(false)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.UpdateMap(entity, values, filters...)
+}
+
+func (s *mysql) SelectOne(entity interface{}, filters ...sqldb.SqlFilter) error {
+	sqlAccess, err := s.readAccess(filters)
 	if err != nil {
 		return err
 	}
@@ -441,7 +547,7 @@ func (s *mysql) SelectOne(entity interface{}, filters ...sqldb.SqlFilter) error
 }
 
 func (s *mysql) SelectDistinct(entity interface{}, row func(index uint64, evt sqldb.SqlEvent), order interface{}, filters ...sqldb.SqlFilter) error {
-	sqlAccess, err := s.NewAccess(false)
+	sqlAccess, err := s.readAccess(filters)
 	if err != nil {
 		return err
 	}
@@ -451,7 +557,7 @@ func (s *mysql) SelectDistinct(entity interface{}, row func(index uint64, evt sq
 }
 
 func (s *mysql) SelectList(entity interface{}, row func(index uint64, evt sqldb.SqlEvent), order interface{}, filters ...sqldb.SqlFilter) error {
-	sqlAccess, err := s.NewAccess(false)
+	sqlAccess, err := s.readAccess(filters)
 	if err != nil {
 		return err
 	}
@@ -461,7 +567,7 @@ func (s *mysql) SelectList(entity interface{}, row func(index uint64, evt sqldb.
 }
 
 func (s *mysql) SelectPage(entity interface{}, page func(total, page, size, index uint64), row func(index uint64, evt sqldb.SqlEvent), size, index uint64, order interface{}, filters ...sqldb.SqlFilter) error {
-	sqlAccess, err := s.NewAccess(false)
+	sqlAccess, err := s.readAccess(filters)
 	if err != nil {
 		return err
 	}
@@ -470,8 +576,66 @@ func (s *mysql) SelectPage(entity interface{}, page func(total, page, size, inde
 	return sqlAccess.SelectPage(entity, page, row, size, index, order, filters...)
 }
 
+// SelectRows opens an access for the rows, which is closed with the rows
+func (s *mysql) SelectRows(entity interface{}, order interface{}, filters ...sqldb.SqlFilter) (sqldb.SqlRows, error) {
+	sqlAccess, err := s.readAccess(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := sqlAccess.SelectRows(entity, order, filters...)
+	if err != nil {
+		sqlAccess.Close()
+		return nil, err
+	}
+
+	return sqldb.CloseWithRows(rows, sqlAccess), nil
+}
+
+func (s *mysql) ImportTable(table *sqldb.SqlTable, r io.Reader, options *sqldb.ImportOptions) (*sqldb.ImportResult, error) {
+	columns, err := s.Columns(table)
+	if err != nil {
+		return nil, err
+	}
+	sqlAccess, err := s.NewAccess(true)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlAccess.Close()
+
+	result, err := sqlAccess.ImportTable(table, columns, r, options)
+	if err == nil {
+		err = sqlAccess.Commit()
+	}
+	if err != nil && result != nil {
+		// the inserted rows are rolled back
+		result.Inserted = 0
+	}
+
+	return result, err
+}
+
+func (s *mysql) ImportEntity(entity interface{}, r io.Reader, options *sqldb.ImportOptions) (*sqldb.ImportResult, error) {
+	sqlAccess, err := s.NewAccess(true)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlAccess.Close()
+
+	result, err := sqlAccess.ImportEntity(entity, r, options)
+	if err == nil {
+		err = sqlAccess.Commit()
+	}
+	if err != nil && result != nil {
+		// the inserted rows are rolled back
+		result.Inserted = 0
+	}
+
+	return result, err
+}
+
 func (s *mysql) SelectCount(entity interface{}, filters ...sqldb.SqlFilter) (uint64, error) {
-	sqlAccess, err := s.NewAccess(false)
+	sqlAccess, err := s.readAccess(filters)
 	if err != nil {
 		return 0, err
 	}
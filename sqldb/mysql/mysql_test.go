@@ -3,8 +3,10 @@ package mysql
 import (
 	"fmt"
 	"github.com/csby/database/sqldb"
+	"github.com/csby/database/sqldb/sqltest"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
 	"testing"
@@ -101,6 +103,36 @@ func TestMysql_ViewDefinition(t *testing.T) {
 	t.Log("definition:", definition)
 }
 
+func TestMysql_Conformance(t *testing.T) {
+	suite := &sqltest.Suite{
+		Database: NewDatabase(testConnection()),
+		Setup: []string{
+			"DROP TABLE IF EXISTS `sqltest_item`",
+			"CREATE TABLE `sqltest_item` (" +
+				"`id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT, " +
+				"`code` VARCHAR(32) NOT NULL, " +
+				"`name` VARCHAR(64) NOT NULL DEFAULT '', " +
+				"`score` BIGINT NOT NULL DEFAULT 0, " +
+				"`deleted_at` DATETIME NULL, " +
+				"`version` BIGINT NOT NULL DEFAULT 0, " +
+				"`created_at` DATETIME NULL, " +
+				"`updated_at` DATETIME NULL, " +
+				"`created_by` VARCHAR(32) NOT NULL DEFAULT '', " +
+				"`updated_by` VARCHAR(32) NOT NULL DEFAULT '', " +
+				"`attrs` TEXT NULL, " +
+				"PRIMARY KEY (`id`))",
+			"DROP TABLE IF EXISTS `sqltest_tag`",
+			"CREATE TABLE `sqltest_tag` (" +
+				"`id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT, " +
+				"`item_id` BIGINT UNSIGNED NOT NULL, " +
+				"`label` VARCHAR(32) NOT NULL DEFAULT '', " +
+				"PRIMARY KEY (`id`))",
+		},
+	}
+
+	suite.Run(t)
+}
+
 func testConnection() *Connection {
 	goPath := os.Getenv("GOPATH")
 	paths := strings.Split(goPath, string(os.PathListSeparator))
@@ -141,3 +173,152 @@ func testConnection() *Connection {
 
 	return cfg
 }
+
+func TestConnection_ParseDSN(t *testing.T) {
+	connection := &Connection{
+		Host:     "db.local",
+		Port:     3307,
+		Schema:   "test",
+		Charset:  "utf8mb4",
+		Timeout:  10,
+		User:     "root",
+		Password: "p@ss:word",
+		Params:   map[string]string{"tls": "skip-verify", "loc": "UTC", "collation": "utf8mb4_bin"},
+	}
+	sourceName := connection.SourceName()
+	if !strings.HasSuffix(sourceName, "?charset=utf8mb4&timeout=10s&parseTime=true&loc=UTC&collation=utf8mb4_bin&tls=skip-verify") {
+		t.Errorf("unexpected source name %s", sourceName)
+	}
+
+	parsed, err := ParseDSN(sourceName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, connection) {
+		t.Errorf("expect %+v, actual %+v", connection, parsed)
+	}
+
+	_, err = ParseDSN("root@unix(/tmp/mysql.sock)/test")
+	if err == nil {
+		t.Error("expect error of unix socket")
+	}
+	_, err = ParseDSN("root@tcp(db.local)/test?timeout=abc")
+	if err == nil {
+		t.Error("expect error of invalid timeout")
+	}
+
+	parsed, err = ParseDSN("root:pass/word@tcp(db.local)/test?timeout=500ms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Password != "pass/word" || parsed.Port != 3306 || parsed.Timeout != 0 || parsed.Params["timeout"] != "500ms" {
+		t.Errorf("unexpected connection %+v", parsed)
+	}
+}
+
+func TestConnection_LoadFromEnv(t *testing.T) {
+	t.Setenv("MYSQL_DSN", "root:secret@tcp(db.local:3306)/test?charset=utf8")
+	t.Setenv("MYSQL_PASSWORD", "changed")
+	t.Setenv("MYSQL_REPLICAS", "r1:3307, r2")
+	t.Setenv("MYSQL_PARAMS", "tls=true&collation=utf8_bin")
+
+	connection := &Connection{}
+	err := connection.LoadFromEnv("MYSQL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if connection.Host != "db.local" || connection.User != "root" || connection.Password != "changed" || connection.Schema != "test" {
+		t.Errorf("unexpected connection %+v", connection)
+	}
+	if len(connection.Replicas) != 2 || connection.Replicas[0].Port != 3307 || connection.Replicas[1].Host != "r2" {
+		t.Errorf("unexpected replicas %v", connection.Replicas)
+	}
+	if connection.Params["tls"] != "true" || connection.Params["collation"] != "utf8_bin" {
+		t.Errorf("unexpected params %v", connection.Params)
+	}
+}
+
+func TestConnection_SaveToFile(t *testing.T) {
+	sqldb.SetKeyProvider(&sqldb.StaticKeyProvider{Current: "k1", Keys: map[string][]byte{"k1": []byte("0123456789abcdef")}})
+	defer sqldb.SetKeyProvider(nil)
+
+	connection := &Connection{Host: "127.0.0.1", Port: 3306, Schema: "mysql", User: "root", Password: "secret"}
+	filePath := filepath.Join(t.TempDir(), "cfg", "mysql.json")
+	err := connection.SaveToFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filePath)
+	if strings.Contains(string(data), "secret") {
+		t.Errorf("password saved in plaintext: %s", data)
+	}
+	if info, err := os.Stat(filePath); err == nil && runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("unexpected file mode %v", info.Mode())
+	}
+
+	loaded := &Connection{}
+	err = loaded.LoadFromFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Password != "secret" {
+		t.Errorf("unexpected password %s", loaded.Password)
+	}
+	if text := fmt.Sprint(loaded); strings.Contains(text, "secret") || !strings.HasPrefix(text, "root:***@tcp(") {
+		t.Errorf("unexpected string %s", text)
+	}
+
+	t.Setenv("MYSQL_CREDENTIAL_PASSWORD", "rotated")
+	loaded.Credential = &sqldb.SqlCredential{Type: sqldb.CredentialEnv, PasswordEnv: "MYSQL_CREDENTIAL_PASSWORD"}
+	if sourceName := loaded.SourceName(); !strings.HasPrefix(sourceName, "root:rotated@") {
+		t.Errorf("unexpected source name %s", sourceName)
+	}
+
+	loaded.Credential = &sqldb.SqlCredential{Type: sqldb.CredentialEnv, PasswordEnv: "MYSQL_CREDENTIAL_MISSING"}
+	_, err = NewDatabase(loaded).NewAccess(false)
+	if err == nil || !strings.Contains(err.Error(), "MYSQL_CREDENTIAL_MISSING") {
+		t.Errorf("expect the error of credential on open, actual %v", err)
+	}
+}
+
+func TestConnection_Tls(t *testing.T) {
+	connection := &Connection{Host: "127.0.0.1", Port: 3306, Schema: "mysql", User: "root", Password: "pwd", Charset: "utf8"}
+	if sourceName := connection.SourceName(); strings.Contains(sourceName, "tls=") {
+		t.Errorf("unexpected source name %s", sourceName)
+	}
+
+	tests := map[string]*sqldb.SqlTls{
+		"tls=false":       {Mode: sqldb.TlsDisable},
+		"tls=skip-verify": {Mode: sqldb.TlsRequire},
+		"tls=true":        {Mode: sqldb.TlsVerifyFull},
+		"tls=sqldb-":      {Mode: sqldb.TlsVerifyCA},
+	}
+	for param, settings := range tests {
+		connection.Tls = settings
+		if sourceName := connection.SourceName(); !strings.Contains(sourceName, param) {
+			t.Errorf("%+v: expect %s in %s", settings, param, sourceName)
+		}
+	}
+
+	connection.Params = map[string]string{"tls": "preferred"}
+	if sourceName := connection.SourceName(); !strings.Contains(sourceName, "tls=preferred") || strings.Count(sourceName, "tls=") != 1 {
+		t.Errorf("unexpected source name %s", sourceName)
+	}
+
+	connection.Params = nil
+	connection.Tls = &sqldb.SqlTls{Mode: sqldb.TlsVerifyCA}
+	name, err := connection.tlsParam()
+	if _, ok := tlsConfigs.Load(name); err != nil || !ok {
+		t.Errorf("expect %s registered, actual %v", name, err)
+	}
+
+	connection.Tls = &sqldb.SqlTls{Mode: sqldb.TlsVerifyFull, CA: filepath.Join(t.TempDir(), "missing.pem")}
+	name, _ = connection.tlsParam()
+	if _, ok := tlsConfigs.Load(name); ok {
+		t.Errorf("expect %s not registered", name)
+	}
+	_, err = NewDatabase(connection).NewAccess(false)
+	if err == nil || !strings.Contains(err.Error(), "read ca") {
+		t.Errorf("expect the error of ca on open, actual %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"io"
+)
+
+type normal struct {
+	access
+
+	db *sql.DB
+}
+
+func (s *normal) Close() error {
+	return s.db.Close()
+}
+
+func (s *normal) Commit() error {
+	return nil
+}
+
+// WithContext returns the access bound to ctx which shares the same connection (or transaction)
+func (s *normal) WithContext(ctx context.Context) SqlAccess {
+	instance := *s
+	instance.ctx = ctx
+
+	return &instance
+}
+
+func (s *normal) Version() int {
+	return s.dialect.Version(s)
+}
+
+func (s *normal) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return s.db.ExecContext(s.ctx, query, args...)
+}
+
+func (s *normal) Prepare(query string) (*sql.Stmt, error) {
+	return s.db.PrepareContext(s.ctx, query)
+}
+
+func (s *normal) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return s.db.QueryContext(s.ctx, query, args...)
+}
+
+func (s *normal) QueryRow(query string, args ...interface{}) *sql.Row {
+	return s.db.QueryRowContext(s.ctx, query, args...)
+}
+
+func (s *normal) QueryResult(query string, args ...interface{}) (*SqlSelectResult, error) {
+	return s.queryResult(s, 0, query, args...)
+}
+
+func (s *normal) QueryResultLimit(limit uint64, query string, args ...interface{}) (*SqlSelectResult, error) {
+	return s.queryResult(s, limit, query, args...)
+}
+
+func (s *normal) IsNoRows(err error) bool {
+	return s.isNoRows(err)
+}
+
+func (s *normal) Insert(entity interface{}, fields ...SqlField) (uint64, error) {
+	return s.insert(s, false, entity, fields...)
+}
+
+func (s *normal) InsertSelective(entity interface{}) (uint64, error) {
+	return s.insert(s, true, entity)
+}
+
+func (s *normal) Delete(entity interface{}, filters ...SqlFilter) (uint64, error) {
+	return s.delete(s, false, entity, filters...)
+}
+
+func (s *normal) HardDelete(entity interface{}, filters ...SqlFilter) (uint64, error) {
+	return s.delete(s, true, entity, filters...)
+}
+
+func (s *normal) Update(entity interface{}, filters ...SqlFilter) (uint64, error) {
+	return s.update(s, false, entity, filters...)
+}
+
+func (s *normal) UpdateSelective(entity interface{}, filters ...SqlFilter) (uint64, error) {
+	return s.update(s, true, entity, filters...)
+}
+
+func (s *normal) UpdateByPrimaryKey(entity interface{}) (uint64, error) {
+	return s.updateByPrimaryKey(s, false, entity)
+}
+
+func (s *normal) UpdateSelectiveByPrimaryKey(entity interface{}) (uint64, error) {
+	return s.updateByPrimaryKey(s, true, entity)
+}
+
+func (s *normal) UpdateFields(entity interface{}, columns []string, filters ...SqlFilter) (uint64, error) {
+	return s.updateFields(s, entity, columns, filters...)
+}
+
+func (s *normal) UpdateMap(entity interface{}, values map[string]interface{}, filters ...SqlFilter) (uint64, error) {
+	return s.updateMap(s, entity, values, filters...)
+}
+
+func (s *normal) SelectOne(entity interface{}, filters ...SqlFilter) error {
+	return s.selectOne(s, entity, filters...)
+}
+
+func (s *normal) SelectDistinct(entity interface{}, row func(index uint64, evt SqlEvent), order interface{}, filters ...SqlFilter) error {
+	return s.selectList(s, true, entity, row, order, filters...)
+}
+
+func (s *normal) SelectList(entity interface{}, row func(index uint64, evt SqlEvent), order interface{}, filters ...SqlFilter) error {
+	return s.selectList(s, false, entity, row, order, filters...)
+}
+
+func (s *normal) SelectPage(entity interface{}, page func(total, page, size, index uint64), row func(index uint64, evt SqlEvent), size, index uint64, order interface{}, filters ...SqlFilter) error {
+	return s.selectPage(s, entity, page, row, size, index, order, filters...)
+}
+
+func (s *normal) SelectRows(entity interface{}, order interface{}, filters ...SqlFilter) (SqlRows, error) {
+	return s.selectRows(s, entity, order, filters...)
+}
+
+func (s *normal) SelectCount(entity interface{}, filters ...SqlFilter) (uint64, error) {
+	return s.selectCount(s, entity, filters...)
+}
+
+func (s *normal) ImportTable(table *SqlTable, columns []*SqlColumn, r io.Reader, options *ImportOptions) (*ImportResult, error) {
+	return s.importTable(s, table, columns, r, options)
+}
+
+func (s *normal) ImportEntity(entity interface{}, r io.Reader, options *ImportOptions) (*ImportResult, error) {
+	return s.importEntity(s, entity, r, options)
+}
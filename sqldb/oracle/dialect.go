@@ -0,0 +1,189 @@
+package oracle
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/csby/database/sqldb"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	errUniqueConstraint = "ORA-00001"
+)
+
+type dialect struct {
+}
+
+// Quote keeps the name as it is, quoted identifiers are case sensitive in oracle
+func (s dialect) Quote(name string) string {
+	return name
+}
+
+func (s dialect) Placeholder(index int) string {
+	return fmt.Sprintf(":%d", index)
+}
+
+func (s dialect) Version(sqlAccess sqldb.SqlAccess) int {
+	return 0
+}
+
+// Page uses OFFSET ... FETCH which is supported since oracle 12c
+func (s dialect) Page(sqlAccess sqldb.SqlAccess, sqlBuilder sqldb.SqlBuilder, paging *sqldb.SqlPaging) {
+	sqlBuilder.Select(paging.Fields, false).From(paging.Table)
+	paging.Where(sqlBuilder)
+	sqlBuilder.Append(paging.Order)
+	sqlBuilder.Append(fmt.Sprintf("OFFSET %d ROWS FETCH NEXT %d ROWS ONLY", paging.Offset, paging.Size))
+}
+
+// Insert returns the generated value of autoField by "RETURNING ... INTO", the driver does not support LastInsertId
+func (s dialect) Insert(sqlAccess sqldb.SqlAccess, sqlBuilder sqldb.SqlBuilder, autoField sqldb.SqlField) (uint64, error) {
+	query := sqlBuilder.Query()
+	args := sqlBuilder.Args()
+	lastInsertId := int64(0)
+	if autoField != nil {
+		query = fmt.Sprintf("%s RETURNING %s INTO %s", query, autoField.Name(), sqlBuilder.ArgName())
+		args = append(args, sql.Out{Dest: &lastInsertId})
+	}
+
+	_, err := sqlAccess.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(lastInsertId), nil
+}
+
+func (s dialect) IsNoRows(err error) bool {
+	return errors.Is(err, sql.ErrNoRows)
+}
+
+func (s dialect) Error(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if strings.Contains(err.Error(), errUniqueConstraint) {
+		return fmt.Errorf("%w: %w", sqldb.ErrDuplicateKey, err)
+	}
+
+	return err
+}
+
+// ResultValue converts NUMBER (text by driver to keep the precision) into int64 or float64,
+// the text is kept if out of range.
+func (s dialect) ResultValue(column *sqldb.SqlSelectColumn, value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.String || strings.ToUpper(column.Type) != "NUMBER" {
+		return value
+	}
+
+	text := v.String()
+	n, err := strconv.ParseInt(text, 10, 64)
+	if err == nil {
+		return n
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err == nil {
+		return f
+	}
+
+	return text
+}
+
+func (s dialect) ColumnType(t *sqldb.SqlColumnType) string {
+	switch t.Kind {
+	case sqldb.ColumnString:
+		if t.Length > 2000 {
+			return "NCLOB"
+		}
+		return fmt.Sprintf("NVARCHAR2(%d)", t.Length)
+	case sqldb.ColumnSmallInt:
+		return "NUMBER(5)"
+	case sqldb.ColumnInt:
+		return "NUMBER(10)"
+	case sqldb.ColumnBigInt:
+		return "NUMBER(19)"
+	case sqldb.ColumnBool:
+		return "NUMBER(1)"
+	case sqldb.ColumnFloat:
+		return "BINARY_FLOAT"
+	case sqldb.ColumnDouble:
+		return "BINARY_DOUBLE"
+	case sqldb.ColumnDecimal:
+		return fmt.Sprintf("NUMBER(%d, %d)", t.Precision, t.Scale)
+	case sqldb.ColumnDate:
+		return "DATE"
+	case sqldb.ColumnTime:
+		return "VARCHAR2(16)"
+	case sqldb.ColumnDateTime:
+		return "TIMESTAMP"
+	case sqldb.ColumnBinary:
+		if t.Length > 2000 {
+			return "BLOB"
+		}
+		return fmt.Sprintf("RAW(%d)", t.Length)
+	case sqldb.ColumnBlob:
+		return "BLOB"
+	case sqldb.ColumnGuid:
+		return "VARCHAR2(36)"
+	default:
+		return "NCLOB"
+	}
+}
+
+func (s dialect) AutoIncrement() string {
+	return "GENERATED BY DEFAULT AS IDENTITY"
+}
+
+// Literal writes the time as TIMESTAMP '...' and the bytes as HEXTORAW('...')
+func (s dialect) Literal(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return fmt.Sprintf("TIMESTAMP '%s'", v.Format("2006-01-02 15:04:05.999999999")), true
+	case []byte:
+		return fmt.Sprintf("HEXTORAW('%x')", v), true
+	}
+
+	return "", false
+}
+
+func (s dialect) ChunkHash(fields []string) string {
+	return fmt.Sprintf("SUM(ORA_HASH(%s))", strings.Join(fields, " || '|' || "))
+}
+
+// ReplicaLag returns the apply lag of data guard standby, e.g. +00 00:00:05
+func (s dialect) ReplicaLag(sqlAccess sqldb.SqlAccess) (time.Duration, error) {
+	value := sql.NullString{}
+	err := sqlAccess.QueryRow("SELECT value FROM v$dataguard_stats WHERE name = 'apply lag'").Scan(&value)
+	if err != nil {
+		if s.IsNoRows(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if !value.Valid || value.String == "" {
+		return 0, fmt.Errorf("apply lag unknown")
+	}
+
+	days, hours, minutes, seconds := 0, 0, 0, 0
+	_, err = fmt.Sscanf(strings.TrimSpace(value.String), "+%d %d:%d:%d", &days, &hours, &minutes, &seconds)
+	if err != nil {
+		return 0, fmt.Errorf("invalid apply lag '%s'", value.String)
+	}
+
+	return time.Duration(((days*24+hours)*60+minutes)*60+seconds) * time.Second, nil
+}
+
+func (s dialect) IsPrimary(sqlAccess sqldb.SqlAccess) (bool, error) {
+	role, mode := "", ""
+	err := sqlAccess.QueryRow("SELECT database_role, open_mode FROM v$database").Scan(&role, &mode)
+	if err != nil {
+		return false, err
+	}
+
+	return role == "PRIMARY" && mode == "READ WRITE", nil
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"github.com/csby/database/sqldb"
+	"io"
 	"strings"
 
 	_ "gopkg.in/goracle.v2"
@@ -11,14 +12,15 @@ import (
 
 type Oracle struct {
 	connection sqldb.SqlConnection
+	router     *sqldb.SqlRouter
 }
 
 func NewDatabase(conn sqldb.SqlConnection) sqldb.SqlDatabase {
-	return &Oracle{connection: conn}
+	return &Oracle{connection: conn, router: sqldb.NewRouter(dialect{}, conn)}
 }
 
 func (s *Oracle) Open() (*sql.DB, error) {
-	db, err := sql.Open(s.connection.DriverName(), s.connection.SourceName())
+	db, err := s.open(s.sourceName())
 	if err != nil {
 		return nil, err
 	}
@@ -31,7 +33,11 @@ func (s *Oracle) Instances(host, port string) ([]sqldb.SqlInstance, error) {
 }
 
 func (s *Oracle) Test() (string, error) {
-	db, err := sql.Open(s.connection.DriverName(), s.connection.SourceName())
+	return s.test(s.sourceName())
+}
+
+func (s *Oracle) test(sourceName string) (string, error) {
+	db, err := s.open(sourceName)
 	if err != nil {
 		return "", err
 	}
@@ -53,7 +59,11 @@ func (s *Oracle) Test() (string, error) {
 }
 
 func (s *Oracle) ClusterTest(readOnly bool) (string, error) {
-	return s.Test()
+	if s.router == nil {
+		return s.Test()
+	}
+
+	return s.test(s.router.SourceName(readOnly))
 }
 
 func (s *Oracle) Schema() string {
@@ -61,7 +71,7 @@ func (s *Oracle) Schema() string {
 }
 
 func (s *Oracle) Tables() ([]*sqldb.SqlTable, error) {
-	db, err := sql.Open(s.connection.DriverName(), s.connection.SourceName())
+	db, err := s.open(s.sourceName())
 	if err != nil {
 		return nil, err
 	}
@@ -113,7 +123,7 @@ func (s *Oracle) Tables() ([]*sqldb.SqlTable, error) {
 }
 
 func (s *Oracle) Views() ([]*sqldb.SqlTable, error) {
-	db, err := sql.Open(s.connection.DriverName(), s.connection.SourceName())
+	db, err := s.open(s.sourceName())
 	if err != nil {
 		return nil, err
 	}
@@ -165,7 +175,7 @@ func (s *Oracle) Views() ([]*sqldb.SqlTable, error) {
 }
 
 func (s *Oracle) Columns(table *sqldb.SqlTable) ([]*sqldb.SqlColumn, error) {
-	db, err := sql.Open(s.connection.DriverName(), s.connection.SourceName())
+	db, err := s.open(s.sourceName())
 	if err != nil {
 		return nil, err
 	}
@@ -247,41 +257,99 @@ func (s *Oracle) ViewDefinition(viewName string) (string, error) {
 }
 
 func (s *Oracle) NewAccess(transactional bool) (sqldb.SqlAccess, error) {
-	db, err := sql.Open(s.connection.DriverName(), s.connection.SourceName())
+	db, err := s.open(s.sourceName())
 	if err != nil {
 		return nil, err
 	}
 
-	if transactional {
-		tx, err := db.Begin()
-		if err != nil {
-			db.Close()
-			return nil, err
-		}
+	return sqldb.NewAccess(dialect{}, db, transactional)
+}
 
-		return &transaction{db: db, tx: tx}, nil
+func (s *Oracle) NewSnapshotAccess() (sqldb.SqlAccess, error) {
+	db, err := s.open(s.sourceName())
+	if err != nil {
+		return nil, err
 	}
 
-	return &normal{db: db}, nil
+	return sqldb.NewSnapshotAccess(dialect{}, db)
 }
 
 func (s *Oracle) NewClusterAccess(transactional bool, readOnly bool) (sqldb.SqlAccess, error) {
-	return s.NewAccess(transactional)
+	if s.router == nil {
+		return s.NewAccess(transactional)
+	}
+	// the transactions are routed to the primary
+	db, err := s.open(s.router.SourceName(readOnly && !transactional))
+	if err != nil {
+		return nil, err
+	}
+
+	return sqldb.NewAccess(dialect{}, db, transactional)
+}
+
+// open opens the db of source name, the error of credential (if any) is returned rather than opening by the configured password
+func (s *Oracle) open(sourceName string) (*sql.DB, error) {
+	err := sqldb.CheckCredential(s.connection)
+	if err != nil {
+		return nil, err
+	}
+
+	return sql.Open(s.connection.DriverName(), sourceName)
+}
+
+// sourceName returns the source name of the primary, which is the current healthy host if failover
+func (s *Oracle) sourceName() string {
+	if s.router != nil && s.router.Failover() {
+		return s.router.SourceName(false)
+	}
+
+	return s.connection.SourceName()
+}
+
+// Router returns the router of replicas, nil if no replicas
+func (s *Oracle) Router() *sqldb.SqlRouter {
+	return s.router
+}
+
+// readAccess returns the access of a replica if routed and ReadReplica is in filters, or the primary
+func (s *Oracle) readAccess(filters []sqldb.SqlFilter) (sqldb.SqlAccess, error) {
+	if s.router == nil || !sqldb.IsReadReplica(filters) {
+		return s.NewAccess(false)
+	}
+
+	return s.NewClusterAccess(false, true)
 }
 
 func (s *Oracle) NewEntity() sqldb.SqlEntity {
-	return &entity{}
+	return sqldb.NewEntity(dialect{})
 }
 
 func (s *Oracle) NewBuilder() sqldb.SqlBuilder {
-	instance := &builder{}
-	instance.Reset()
-
-	return instance
+	return sqldb.NewBuilder(dialect{})
 }
 
 func (s *Oracle) NewFilter(entity interface{}, fieldOr, groupOr bool) sqldb.SqlFilter {
-	return newFilter(entity, fieldOr, groupOr)
+	return sqldb.NewFilter(entity, fieldOr, groupOr)
+}
+
+func (s *Oracle) QueryResult(query string, args ...interface{}) (*sqldb.SqlSelectResult, error) {
+	sqlAccess, err := s.NewAccess(false)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.QueryResult(query, args...)
+}
+
+func (s *Oracle) QueryResultLimit(limit uint64, query string, args ...interface{}) (*sqldb.SqlSelectResult, error) {
+	sqlAccess, err := s.NewAccess(false)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.QueryResultLimit(limit, query, args...)
 }
 
 func (s *Oracle) IsNoRows(err error) bool {
@@ -289,11 +357,7 @@ func (s *Oracle) IsNoRows(err error) bool {
 		return false
 	}
 
-	if err == sql.ErrNoRows {
-		return true
-	}
-
-	return false
+	return dialect{}.IsNoRows(err)
 }
 
 func (s *Oracle) Insert(entity interface{}) (uint64, error) {
@@ -326,6 +390,16 @@ func (s *Oracle) Delete(entity interface{}, filters ...sqldb.SqlFilter) (uint64,
 	return sqlAccess.Delete(entity, filters...)
 }
 
+func (s *Oracle) HardDelete(entity interface{}, filters ...sqldb.SqlFilter) (uint64, error) {
+	sqlAccess, err := s.NewAccess(false)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.HardDelete(entity, filters...)
+}
+
 func (s *Oracle) Update(entity interface{}, filters ...sqldb.SqlFilter) (uint64, error) {
 	sqlAccess, err := s.NewAccess(false)
 	if err != nil {
@@ -366,8 +440,28 @@ func (s *Oracle) UpdateSelectiveByPrimaryKey(entity interface{}) (uint64, error)
 	return sqlAccess.UpdateSelectiveByPrimaryKey(entity)
 }
 
-func (s *Oracle) SelectOne(entity interface{}, filters ...sqldb.SqlFilter) error {
+func (s *Oracle) UpdateFields(entity interface{}, columns []string, filters ...sqldb.SqlFilter) (uint64, error) {
 	sqlAccess, err := s.NewAccess(false)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.UpdateFields(entity, columns, filters...)
+}
+
+func (s *Oracle) UpdateMap(entity interface{}, values map[string]interface{}, filters ...sqldb.SqlFilter) (uint64, error) {
+	sqlAccess, err := s.NewAccess(false)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.UpdateMap(entity, values, filters...)
+}
+
+func (s *Oracle) SelectOne(entity interface{}, filters ...sqldb.SqlFilter) error {
+	sqlAccess, err := s.readAccess(filters)
 	if err != nil {
 		return err
 	}
@@ -377,7 +471,7 @@ func (s *Oracle) SelectOne(entity interface{}, filters ...sqldb.SqlFilter) error
 }
 
 func (s *Oracle) SelectDistinct(entity interface{}, row func(index uint64, evt sqldb.SqlEvent), order interface{}, filters ...sqldb.SqlFilter) error {
-	sqlAccess, err := s.NewAccess(false)
+	sqlAccess, err := s.readAccess(filters)
 	if err != nil {
 		return err
 	}
@@ -387,7 +481,7 @@ func (s *Oracle) SelectDistinct(entity interface{}, row func(index uint64, evt s
 }
 
 func (s *Oracle) SelectList(entity interface{}, row func(index uint64, evt sqldb.SqlEvent), order interface{}, filters ...sqldb.SqlFilter) error {
-	sqlAccess, err := s.NewAccess(false)
+	sqlAccess, err := s.readAccess(filters)
 	if err != nil {
 		return err
 	}
@@ -397,7 +491,7 @@ func (s *Oracle) SelectList(entity interface{}, row func(index uint64, evt sqldb
 }
 
 func (s *Oracle) SelectPage(entity interface{}, page func(total, page, size, index uint64), row func(index uint64, evt sqldb.SqlEvent), size, index uint64, order interface{}, filters ...sqldb.SqlFilter) error {
-	sqlAccess, err := s.NewAccess(false)
+	sqlAccess, err := s.readAccess(filters)
 	if err != nil {
 		return err
 	}
@@ -406,8 +500,66 @@ func (s *Oracle) SelectPage(entity interface{}, page func(total, page, size, ind
 	return sqlAccess.SelectPage(entity, page, row, size, index, order, filters...)
 }
 
+// SelectRows opens an access for the rows, which is closed with the rows
+func (s *Oracle) SelectRows(entity interface{}, order interface{}, filters ...sqldb.SqlFilter) (sqldb.SqlRows, error) {
+	sqlAccess, err := s.readAccess(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := sqlAccess.SelectRows(entity, order, filters...)
+	if err != nil {
+		sqlAccess.Close()
+		return nil, err
+	}
+
+	return sqldb.CloseWithRows(rows, sqlAccess), nil
+}
+
+func (s *Oracle) ImportTable(table *sqldb.SqlTable, r io.Reader, options *sqldb.ImportOptions) (*sqldb.ImportResult, error) {
+	columns, err := s.Columns(table)
+	if err != nil {
+		return nil, err
+	}
+	sqlAccess, err := s.NewAccess(true)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlAccess.Close()
+
+	result, err := sqlAccess.ImportTable(table, columns, r, options)
+	if err == nil {
+		err = sqlAccess.Commit()
+	}
+	if err != nil && result != nil {
+		// the inserted rows are rolled back
+		result.Inserted = 0
+	}
+
+	return result, err
+}
+
+func (s *Oracle) ImportEntity(entity interface{}, r io.Reader, options *sqldb.ImportOptions) (*sqldb.ImportResult, error) {
+	sqlAccess, err := s.NewAccess(true)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlAccess.Close()
+
+	result, err := sqlAccess.ImportEntity(entity, r, options)
+	if err == nil {
+		err = sqlAccess.Commit()
+	}
+	if err != nil && result != nil {
+		// the inserted rows are rolled back
+		result.Inserted = 0
+	}
+
+	return result, err
+}
+
 func (s *Oracle) SelectCount(entity interface{}, filters ...sqldb.SqlFilter) (uint64, error) {
-	sqlAccess, err := s.NewAccess(false)
+	sqlAccess, err := s.readAccess(filters)
 	if err != nil {
 		return 0, err
 	}
@@ -0,0 +1,383 @@
+package sqldb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"time"
+)
+
+// parquet format constants, see https://github.com/apache/parquet-format
+const (
+	parquetMagic        = "PAR1"
+	parquetRowGroupSize = 10000
+	parquetCreatedBy    = "github.com/csby/database"
+
+	parquetBoolean   = 0
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	parquetOptional = 1
+
+	parquetUtf8            = 0
+	parquetTimestampMillis = 9
+
+	parquetPlain = 0
+	parquetRle   = 3
+
+	parquetDataPage = 0
+)
+
+type parquetColumn struct {
+	name   string
+	kind   int
+	values []interface{} // the values of current row group, nil for NULL
+}
+
+type parquetRowGroup struct {
+	columns   [][]byte
+	totalSize int64
+	rows      int64
+}
+
+// parquetExportWriter writes the rows in row groups of parquetRowGroupSize rows,
+// all columns are optional, plain encoded and uncompressed:
+// string as UTF8 BYTE_ARRAY, integer as INT64, float as DOUBLE, bool as BOOLEAN and time as TIMESTAMP_MILLIS.
+type parquetExportWriter struct {
+	w         io.Writer
+	text      *exportText
+	offset    int64
+	columns   []*parquetColumn
+	rowGroups []*parquetRowGroup
+	rows      int64
+}
+
+func newParquetExportWriter(w io.Writer, text *exportText) *parquetExportWriter {
+	return &parquetExportWriter{w: w, text: text}
+}
+
+func (s *parquetExportWriter) Begin(columns []*exportColumn) error {
+	s.columns = make([]*parquetColumn, len(columns))
+	for i, name := range uniqueNames(columns) {
+		s.columns[i] = &parquetColumn{name: name, kind: columns[i].kind}
+	}
+
+	return s.write([]byte(parquetMagic))
+}
+
+func (s *parquetExportWriter) Write(values []interface{}) error {
+	for i, value := range values {
+		column := s.columns[i]
+		if value != nil && column.kind == exportString {
+			if _, ok := value.(string); !ok {
+				value = s.text.format(value)
+			}
+		}
+		column.values = append(column.values, value)
+	}
+	s.rows++
+
+	if len(s.columns) > 0 && len(s.columns[0].values) >= parquetRowGroupSize {
+		return s.flush()
+	}
+
+	return nil
+}
+
+func (s *parquetExportWriter) End() error {
+	err := s.flush()
+	if err != nil {
+		return err
+	}
+
+	footer := s.fileMetaData()
+	err = s.write(footer)
+	if err != nil {
+		return err
+	}
+	size := make([]byte, 4)
+	binary.LittleEndian.PutUint32(size, uint32(len(footer)))
+	err = s.write(size)
+	if err != nil {
+		return err
+	}
+
+	return s.write([]byte(parquetMagic))
+}
+
+func (s *parquetExportWriter) write(data []byte) error {
+	n, err := s.w.Write(data)
+	s.offset += int64(n)
+
+	return err
+}
+
+// flush writes the buffered values as a row group, one data page per column
+func (s *parquetExportWriter) flush() error {
+	if len(s.columns) < 1 || len(s.columns[0].values) < 1 {
+		return nil
+	}
+
+	rowGroup := &parquetRowGroup{
+		columns: make([][]byte, len(s.columns)),
+		rows:    int64(len(s.columns[0].values)),
+	}
+	for i, column := range s.columns {
+		page := column.encodePage()
+		header := parquetPageHeader(len(page), len(column.values))
+		offset := s.offset
+		err := s.write(header)
+		if err != nil {
+			return err
+		}
+		err = s.write(page)
+		if err != nil {
+			return err
+		}
+
+		size := int64(len(header) + len(page))
+		rowGroup.totalSize += size
+		rowGroup.columns[i] = column.columnChunk(offset, size)
+		column.values = column.values[:0]
+	}
+	s.rowGroups = append(s.rowGroups, rowGroup)
+
+	return nil
+}
+
+func (s *parquetColumn) physicalType() int32 {
+	switch s.kind {
+	case exportBool:
+		return parquetBoolean
+	case exportInt, exportTime:
+		return parquetInt64
+	case exportFloat:
+		return parquetDouble
+	default:
+		return parquetByteArray
+	}
+}
+
+// encodePage returns the definition levels (RLE/bit-packed hybrid, bit width 1) and the plain values
+func (s *parquetColumn) encodePage() []byte {
+	count := len(s.values)
+	levels := &bytes.Buffer{}
+	groups := (count + 7) / 8
+	levels.Write(binary.AppendUvarint(nil, uint64(groups<<1|1)))
+	bits := make([]byte, groups)
+	for i, value := range s.values {
+		if value != nil {
+			bits[i/8] |= 1 << (i % 8)
+		}
+	}
+	levels.Write(bits)
+
+	page := &bytes.Buffer{}
+	binary.Write(page, binary.LittleEndian, uint32(levels.Len()))
+	page.Write(levels.Bytes())
+
+	if s.kind == exportBool {
+		packed := make([]byte, 0, count/8+1)
+		n := 0
+		for _, value := range s.values {
+			if value == nil {
+				continue
+			}
+			if n%8 == 0 {
+				packed = append(packed, 0)
+			}
+			if value.(bool) {
+				packed[n/8] |= 1 << (n % 8)
+			}
+			n++
+		}
+		page.Write(packed)
+		return page.Bytes()
+	}
+
+	buf := make([]byte, 8)
+	for _, value := range s.values {
+		switch v := value.(type) {
+		case nil:
+		case int64:
+			binary.LittleEndian.PutUint64(buf, uint64(v))
+			page.Write(buf)
+		case float64:
+			binary.LittleEndian.PutUint64(buf, math.Float64bits(v))
+			page.Write(buf)
+		case time.Time:
+			binary.LittleEndian.PutUint64(buf, uint64(v.UnixMilli()))
+			page.Write(buf)
+		case string:
+			binary.LittleEndian.PutUint32(buf, uint32(len(v)))
+			page.Write(buf[:4])
+			page.WriteString(v)
+		}
+	}
+
+	return page.Bytes()
+}
+
+func (s *parquetColumn) columnChunk(offset, size int64) []byte {
+	w := &thriftWriter{}
+	w.structBegin()
+	w.i64(2, offset) // file_offset
+	w.fieldStruct(3) // meta_data
+	w.i32(1, s.physicalType())
+	w.listBegin(2, thriftI32, 2) // encodings
+	w.zigzag(parquetPlain)
+	w.zigzag(parquetRle)
+	w.listBegin(3, thriftBinary, 1) // path_in_schema
+	w.binaryValue([]byte(s.name))
+	w.i32(4, 0) // codec: UNCOMPRESSED
+	w.i64(5, int64(len(s.values)))
+	w.i64(6, size)
+	w.i64(7, size)
+	w.i64(9, offset) // data_page_offset
+	w.structEnd()
+	w.structEnd()
+
+	return w.buf.Bytes()
+}
+
+func parquetPageHeader(pageSize, count int) []byte {
+	w := &thriftWriter{}
+	w.structBegin()
+	w.i32(1, parquetDataPage)
+	w.i32(2, int32(pageSize))
+	w.i32(3, int32(pageSize))
+	w.fieldStruct(5) // data_page_header
+	w.i32(1, int32(count))
+	w.i32(2, parquetPlain)
+	w.i32(3, parquetRle)
+	w.i32(4, parquetRle)
+	w.structEnd()
+	w.structEnd()
+
+	return w.buf.Bytes()
+}
+
+func (s *parquetExportWriter) fileMetaData() []byte {
+	w := &thriftWriter{}
+	w.structBegin()
+	w.i32(1, 1) // version
+
+	w.listBegin(2, thriftStruct, len(s.columns)+1) // schema
+	w.structBegin()
+	w.binary(4, []byte("schema"))
+	w.i32(5, int32(len(s.columns)))
+	w.structEnd()
+	for _, column := range s.columns {
+		w.structBegin()
+		w.i32(1, column.physicalType())
+		w.i32(3, parquetOptional)
+		w.binary(4, []byte(column.name))
+		switch column.kind {
+		case exportString:
+			w.i32(6, parquetUtf8)
+		case exportTime:
+			w.i32(6, parquetTimestampMillis)
+		}
+		w.structEnd()
+	}
+
+	w.i64(3, s.rows)
+
+	w.listBegin(4, thriftStruct, len(s.rowGroups)) // row_groups
+	for _, rowGroup := range s.rowGroups {
+		w.structBegin()
+		w.listBegin(1, thriftStruct, len(rowGroup.columns))
+		for _, chunk := range rowGroup.columns {
+			w.buf.Write(chunk)
+		}
+		w.i64(2, rowGroup.totalSize)
+		w.i64(3, rowGroup.rows)
+		w.structEnd()
+	}
+
+	w.binary(6, []byte(parquetCreatedBy))
+	w.structEnd()
+
+	return w.buf.Bytes()
+}
+
+// thrift compact protocol, the types used by parquet metadata only
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+type thriftWriter struct {
+	buf  bytes.Buffer
+	last []int16 // the last field id of the structs
+}
+
+func (s *thriftWriter) varint(v uint64) {
+	s.buf.Write(binary.AppendUvarint(nil, v))
+}
+
+func (s *thriftWriter) zigzag(v int64) {
+	s.varint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (s *thriftWriter) fieldHeader(id int16, typ byte) {
+	last := s.last[len(s.last)-1]
+	delta := id - last
+	if delta > 0 && delta <= 15 {
+		s.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		s.buf.WriteByte(typ)
+		s.zigzag(int64(id))
+	}
+	s.last[len(s.last)-1] = id
+}
+
+func (s *thriftWriter) structBegin() {
+	s.last = append(s.last, 0)
+}
+
+func (s *thriftWriter) structEnd() {
+	s.buf.WriteByte(0)
+	s.last = s.last[:len(s.last)-1]
+}
+
+func (s *thriftWriter) fieldStruct(id int16) {
+	s.fieldHeader(id, thriftStruct)
+	s.structBegin()
+}
+
+func (s *thriftWriter) i32(id int16, v int32) {
+	s.fieldHeader(id, thriftI32)
+	s.zigzag(int64(v))
+}
+
+func (s *thriftWriter) i64(id int16, v int64) {
+	s.fieldHeader(id, thriftI64)
+	s.zigzag(v)
+}
+
+func (s *thriftWriter) binary(id int16, v []byte) {
+	s.fieldHeader(id, thriftBinary)
+	s.binaryValue(v)
+}
+
+func (s *thriftWriter) binaryValue(v []byte) {
+	s.varint(uint64(len(v)))
+	s.buf.Write(v)
+}
+
+// listBegin writes the header of list field, the elements follow it without field header
+func (s *thriftWriter) listBegin(id int16, elementType byte, size int) {
+	s.fieldHeader(id, thriftList)
+	if size < 15 {
+		s.buf.WriteByte(byte(size)<<4 | elementType)
+	} else {
+		s.buf.WriteByte(0xf0 | elementType)
+		s.varint(uint64(size))
+	}
+}
@@ -0,0 +1,90 @@
+package sqldb
+
+import (
+	"reflect"
+)
+
+type projection struct {
+	only    bool
+	columns []string
+}
+
+func (s *projection) FieldOr() bool {
+	return false
+}
+
+func (s *projection) GroupOr() bool {
+	return false
+}
+
+func (s *projection) Fields() interface{} {
+	return nil
+}
+
+// Only is the filter selecting the columns (name in sql tag) only in SelectOne, SelectList and SelectPage,
+// e.g. db.SelectList(entity, row, order, sqldb.Only("id", "name")), the other fields of entity are zero.
+func Only(columns ...string) SqlFilter {
+	return &projection{only: true, columns: columns}
+}
+
+// Omit is the filter excluding the columns (name in sql tag) in SelectOne, SelectList and SelectPage,
+// e.g. db.SelectList(entity, row, order, sqldb.Omit("content")), the excluded fields of entity are zero.
+func Omit(columns ...string) SqlFilter {
+	return &projection{only: false, columns: columns}
+}
+
+// project selects the fields scanned by the projection filters (if any),
+// the fields not selected are set to zero, since they are not scanned.
+func (s *entity) project(filters []SqlFilter) error {
+	var selected map[string]bool = nil
+	omitted := make(map[string]bool)
+	for _, f := range filters {
+		p, ok := f.(*projection)
+		if !ok {
+			continue
+		}
+		for _, column := range p.columns {
+			if s.fieldByColumn(column) < 0 {
+				return newError("entity (", s.name, "): column ", column, " not defined")
+			}
+			if !p.only {
+				omitted[column] = true
+			} else if selected == nil {
+				selected = map[string]bool{column: true}
+			} else {
+				selected[column] = true
+			}
+		}
+	}
+	if selected == nil && len(omitted) < 1 {
+		return nil
+	}
+
+	fields := make(fieldCollection, 0)
+	for i, fm := range s.meta.fields {
+		f := s.fields[i]
+		if (selected == nil || selected[fm.column]) && !omitted[fm.column] {
+			fields = append(fields, f)
+			continue
+		}
+
+		v := reflect.ValueOf(f.address).Elem()
+		v.Set(reflect.Zero(v.Type()))
+	}
+	if len(fields) < 1 {
+		return newError("entity (", s.name, "): no column selected")
+	}
+	s.selected = fields
+
+	return nil
+}
+
+func (s *entity) fieldByColumn(column string) int {
+	for i, fm := range s.meta.fields {
+		if fm.column == column {
+			return i
+		}
+	}
+
+	return -1
+}
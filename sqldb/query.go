@@ -0,0 +1,83 @@
+package sqldb
+
+import (
+	"unicode/utf8"
+)
+
+func (s *access) queryResult(sqlAccess SqlAccess, limit uint64, query string, args ...interface{}) (*SqlSelectResult, error) {
+	rows, err := sqlAccess.Query(query, args...)
+	if err != nil {
+		return nil, s.dialect.Error(err)
+	}
+	defer rows.Close()
+
+	var first, last *SqlSelectResult = nil, nil
+	for {
+		columnTypes, err := rows.ColumnTypes()
+		if err != nil {
+			return nil, s.dialect.Error(err)
+		}
+		result := &SqlSelectResult{}
+		result.Init(columnTypes)
+		s.fillResultValuer(result)
+
+		count := uint64(0)
+		for rows.Next() {
+			if limit > 0 && count >= limit {
+				result.Truncated = true
+				break
+			}
+			err = rows.Scan(result.Scans()...)
+			if err != nil {
+				return nil, s.dialect.Error(err)
+			}
+			result.AppendRow(result.Columns)
+			count++
+		}
+
+		if first == nil {
+			first = result
+		} else {
+			last.Next = result
+		}
+		last = result
+
+		if !rows.NextResultSet() {
+			break
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, s.dialect.Error(err)
+	}
+
+	return first, nil
+}
+
+// fillResultValuer converts the values of columns by the dialect (if supported),
+// the bytes of text are converted into string.
+func (s *access) fillResultValuer(result *SqlSelectResult) {
+	valuer, _ := s.dialect.(ResultValuer)
+	for _, column := range result.Columns {
+		column := column
+		getValue := column.getValue
+		if getValue == nil {
+			continue
+		}
+		column.getValue = func() (string, interface{}) {
+			id, value := getValue()
+			if value == nil {
+				return id, nil
+			}
+			if valuer != nil {
+				value = valuer.ResultValue(column, value)
+			}
+			data, ok := value.([]byte)
+			if ok && utf8.Valid(data) {
+				value = string(data)
+			}
+			return id, value
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package sqldb
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	relationHasOne    = "hasone"
+	relationHasMany   = "hasmany"
+	relationBelongsTo = "belongsto"
+
+	// the count of keys in one "IN (...)" query, oracle supports 1000 at most
+	relationBatchSize = 500
+)
+
+// relationMeta is the field tagged by rel, e.g.
+// Items []OrderItem `rel:"hasMany,fk=order_id"`: order_item.order_id references the primary key of order;
+// Detail *OrderDetail `rel:"hasOne,fk=order_id"`: the same as hasMany but only one (the first by primary key);
+// Customer *Customer `rel:"belongsTo,fk=customer_id"`: order.customer_id references the primary key of customer.
+// The referenced column is the primary key by default, or given by "ref=column".
+type relationMeta struct {
+	name    string
+	path    []int
+	kind    string
+	fk      string
+	ref     string
+	typ     reflect.Type // struct type of the related entity
+	pointer bool         // the related entity (or the element of slice) is address
+}
+
+func parseRelationMetas(t reflect.Type, parent []int, relations map[string]*relationMeta) error {
+	n := t.NumField()
+	for i := 0; i < n; i++ {
+		typeField := t.Field(i)
+		if typeField.PkgPath != "" {
+			continue
+		}
+
+		path := make([]int, len(parent)+1)
+		copy(path, parent)
+		path[len(parent)] = i
+
+		if typeField.Anonymous {
+			if typeField.Type.Kind() == reflect.Struct {
+				err := parseRelationMetas(typeField.Type, path, relations)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		tag := typeField.Tag.Get(sqlFieldRelationTagName)
+		if tag == "" {
+			continue
+		}
+
+		relation := &relationMeta{name: typeField.Name, path: path}
+		items := strings.Split(tag, ",")
+		relation.kind = strings.ToLower(strings.TrimSpace(items[0]))
+		for _, item := range items[1:] {
+			kv := strings.SplitN(item, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			switch strings.ToLower(strings.TrimSpace(kv[0])) {
+			case "fk":
+				relation.fk = strings.TrimSpace(kv[1])
+			case "ref":
+				relation.ref = strings.TrimSpace(kv[1])
+			}
+		}
+		if relation.fk == "" {
+			return newError("invalid relation (", t.Name(), ".", typeField.Name, "): fk not defined")
+		}
+
+		rt := typeField.Type
+		switch relation.kind {
+		case relationHasMany:
+			if rt.Kind() != reflect.Slice {
+				return newError("invalid relation (", t.Name(), ".", typeField.Name, "): hasMany should be slice")
+			}
+			rt = rt.Elem()
+		case relationHasOne, relationBelongsTo:
+		default:
+			return newError("invalid relation (", t.Name(), ".", typeField.Name, "): '", items[0], "' not supported")
+		}
+		if rt.Kind() == reflect.Ptr {
+			relation.pointer = true
+			rt = rt.Elem()
+		}
+		if rt.Kind() != reflect.Struct {
+			return newError("invalid relation (", t.Name(), ".", typeField.Name, "): ", rt, " is not struct")
+		}
+		relation.typ = rt
+
+		relations[relation.name] = relation
+	}
+
+	return nil
+}
+
+type preload struct {
+	names []string
+}
+
+func (s *preload) FieldOr() bool {
+	return false
+}
+
+func (s *preload) GroupOr() bool {
+	return false
+}
+
+func (s *preload) Fields() interface{} {
+	return nil
+}
+
+// Preload is the filter loading the related entities of the fields (tagged by rel) by names,
+// one query (per 500 rows) for each relation, e.g. db.SelectList(order, row, nil, sqldb.Preload("Items", "Customer")).
+// The rows are all loaded before the callback of SelectList and SelectPage if preloading.
+func Preload(names ...string) SqlFilter {
+	return &preload{names: names}
+}
+
+func getPreloads(filters []SqlFilter) []string {
+	names := make([]string, 0)
+	for _, f := range filters {
+		p, ok := f.(*preload)
+		if ok {
+			names = append(names, p.names...)
+		}
+	}
+
+	return names
+}
+
+// keyField returns the field of column, or the primary key if column is empty
+func (s *entityMeta) keyField(column string) (*fieldMeta, error) {
+	var key *fieldMeta = nil
+	for _, fm := range s.fields {
+		if column == "" {
+			if fm.primaryKey {
+				if key != nil {
+					return nil, newError("entity (", s.typeName, "): relation to composite primary key not supported")
+				}
+				key = fm
+			}
+		} else if fm.column == column {
+			return fm, nil
+		}
+	}
+	if key == nil {
+		if column == "" {
+			return nil, newError("entity (", s.typeName, "): primary key not defined")
+		}
+		return nil, newError("entity (", s.typeName, "): column ", column, " not defined")
+	}
+
+	return key, nil
+}
+
+func relationKey(v reflect.Value) (string, bool) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", false
+		}
+		v = v.Elem()
+	}
+	if v.IsZero() {
+		return "", false
+	}
+
+	return fmt.Sprint(v.Interface()), true
+}
+
+// preload loads the related entities of names into parents (the addressable values of entity)
+func (s *access) preload(sqlAccess SqlAccess, meta *entityMeta, parents []reflect.Value, names []string) error {
+	if len(parents) < 1 {
+		return nil
+	}
+
+	for _, name := range names {
+		relation, ok := meta.relations[name]
+		if !ok {
+			return newError("entity (", meta.typeName, "): relation ", name, " not defined")
+		}
+		relatedMeta := getEntityMeta(s.dialect, relation.typ, false)
+		if relatedMeta.err != nil {
+			return relatedMeta.err
+		}
+
+		var parentKey, relatedKey *fieldMeta
+		var err error
+		if relation.kind == relationBelongsTo {
+			parentKey, err = meta.keyField(relation.fk)
+			if err == nil {
+				relatedKey, err = relatedMeta.keyField(relation.ref)
+			}
+		} else {
+			parentKey, err = meta.keyField(relation.ref)
+			if err == nil {
+				relatedKey, err = relatedMeta.keyField(relation.fk)
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		keys := make([]interface{}, 0)
+		exists := make(map[string]bool)
+		for _, parent := range parents {
+			v := parent.FieldByIndex(parentKey.path)
+			key, ok := relationKey(v)
+			if !ok || exists[key] {
+				continue
+			}
+			exists[key] = true
+			keys = append(keys, v.Interface())
+		}
+
+		related := make(map[string][]reflect.Value)
+		for start := 0; start < len(keys); start += relationBatchSize {
+			end := start + relationBatchSize
+			if end > len(keys) {
+				end = len(keys)
+			}
+			err = s.selectRelated(sqlAccess, relation.typ, relatedMeta, relatedKey, keys[start:end], related)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, parent := range parents {
+			key, _ := relationKey(parent.FieldByIndex(parentKey.path))
+			values := related[key]
+			field := parent.FieldByIndex(relation.path)
+			if relation.kind == relationHasMany {
+				slice := reflect.MakeSlice(field.Type(), 0, len(values))
+				for _, value := range values {
+					if relation.pointer {
+						slice = reflect.Append(slice, value)
+					} else {
+						slice = reflect.Append(slice, value.Elem())
+					}
+				}
+				field.Set(slice)
+			} else if len(values) > 0 {
+				if relation.pointer {
+					field.Set(values[0])
+				} else {
+					field.Set(values[0].Elem())
+				}
+			} else {
+				field.Set(reflect.Zero(field.Type()))
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *access) selectRelated(sqlAccess SqlAccess, t reflect.Type, meta *entityMeta, key *fieldMeta, keys []interface{}, related map[string][]reflect.Value) error {
+	dbEntity := reflect.New(t)
+	sqlEntity := s.newEntity()
+	err := sqlEntity.Parse(dbEntity.Interface())
+	if err != nil {
+		return err
+	}
+
+	sqlBuilder := s.newBuilder()
+	sqlBuilder.Select(meta.scanFields, false).From(sqlEntity.Name())
+	err = s.fillScopedWhere(sqlBuilder, sqlEntity)
+	if err != nil {
+		return err
+	}
+	placeholders := make([]string, len(keys))
+	for i := range keys {
+		placeholders[i] = s.dialect.Placeholder(len(sqlBuilder.Args()) + i + 1)
+	}
+	sqlBuilder.WhereAnd(fmt.Sprintf("%s IN (%s)", key.name, strings.Join(placeholders, ", ")), keys...)
+	// the related entities are in the order of primary key, e.g. the items of order, and the first one for hasOne
+	sqlBuilder.Order(key.name)
+	for _, fm := range meta.fields {
+		if fm.primaryKey && fm != key {
+			sqlBuilder.Order(fm.name)
+		}
+	}
+
+	rows, err := sqlAccess.Query(sqlBuilder.Query(), sqlBuilder.Args()...)
+	if err != nil {
+		return s.dialect.Error(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		err = rows.Scan(sqlEntity.ScanArgs()...)
+		if err != nil {
+			return s.dialect.Error(err)
+		}
+		err = afterScan(sqlAccess, dbEntity.Interface())
+		if err != nil {
+			return err
+		}
+
+		value := reflect.New(t)
+		value.Elem().Set(dbEntity.Elem())
+		k, ok := relationKey(value.Elem().FieldByIndex(key.path))
+		if ok {
+			related[k] = append(related[k], value)
+		}
+	}
+
+	return s.dialect.Error(rows.Err())
+}
@@ -0,0 +1,302 @@
+package sqldb
+
+import (
+	"context"
+	"fmt"
+	"iter"
+)
+
+// Repository is the typed access of entity T over SqlDatabase,
+// T is the struct (not the address) following the same tags as the untyped api.
+// The context is bound to the access of each method (see SqlAccess.WithContext), so the statements are cancelled with it,
+// and it is also checked between the rows. The user of context (see WithUser) is filled into createdBy and updatedBy fields.
+type Repository[T any] struct {
+	db SqlDatabase
+}
+
+type Page[T any] struct {
+	Total uint64 `json:"total" note:"总记录数"`
+	Count uint64 `json:"count" note:"总页数"`
+	Size  uint64 `json:"size" note:"页大小"`
+	Index uint64 `json:"index" note:"页码, 从1开始"`
+	Items []T    `json:"items" note:"当前页记录"`
+}
+
+func NewRepository[T any](db SqlDatabase) *Repository[T] {
+	return &Repository[T]{db: db}
+}
+
+// Get selects the entity by primary key, the values of pk are in the order of the primary key fields.
+// The error is no rows (see SqlDatabase.IsNoRows) if not exist.
+func (s *Repository[T]) Get(ctx context.Context, pk ...interface{}) (*T, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	dbEntity := new(T)
+	filter, err := s.primaryKeyFilter(dbEntity, pk)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlAccess, err := s.newAccess(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlAccess.Close()
+
+	err = sqlAccess.SelectOne(dbEntity, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return dbEntity, nil
+}
+
+func (s *Repository[T]) List(ctx context.Context, order interface{}, filters ...SqlFilter) ([]T, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	sqlAccess, err := s.newAccess(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlAccess.Close()
+
+	items := make([]T, 0)
+	dbEntity := new(T)
+	err = sqlAccess.SelectList(dbEntity, func(idx uint64, evt SqlEvent) {
+		if err := ctx.Err(); err != nil {
+			evt.Cancel(err)
+			return
+		}
+		items = append(items, *dbEntity)
+	}, order, filters...)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// Page selects the entities of page index (from 1), the index is corrected to the last page if out of range.
+func (s *Repository[T]) Page(ctx context.Context, size, index uint64, order interface{}, filters ...SqlFilter) (Page[T], error) {
+	page := Page[T]{Items: make([]T, 0)}
+	if err := ctx.Err(); err != nil {
+		return page, err
+	}
+
+	sqlAccess, err := s.newAccess(ctx)
+	if err != nil {
+		return page, err
+	}
+	defer sqlAccess.Close()
+
+	dbEntity := new(T)
+	err = sqlAccess.SelectPage(dbEntity, func(total, count, size, index uint64) {
+		page.Total = total
+		page.Count = count
+		page.Size = size
+		page.Index = index
+	}, func(idx uint64, evt SqlEvent) {
+		if err := ctx.Err(); err != nil {
+			evt.Cancel(err)
+			return
+		}
+		page.Items = append(page.Items, *dbEntity)
+	}, size, index, order, filters...)
+	if err != nil {
+		return page, err
+	}
+
+	return page, nil
+}
+
+// Iter yields the entities one by one by SelectRows without loading all of them,
+// the error (if any) is yielded at last with the zero value of T. Preload is not supported, use List instead.
+func (s *Repository[T]) Iter(ctx context.Context, order interface{}, filters ...SqlFilter) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		if err := ctx.Err(); err != nil {
+			yield(zero, err)
+			return
+		}
+
+		sqlAccess, err := s.newAccess(ctx)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer sqlAccess.Close()
+
+		rows, err := sqlAccess.SelectRows(new(T), order, filters...)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer rows.Close()
+
+		dbEntity := new(T)
+		for rows.Next() {
+			if err := ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+			err = rows.Scan(dbEntity)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			if !yield(*dbEntity, nil) {
+				return
+			}
+		}
+		if err = rows.Err(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
+
+// Chan sends the entities into the channel of buffer size by a goroutine, which blocks until they are received,
+// the error channel receives the error (if any) and both channels are closed after the last row.
+// Cancel ctx to stop receiving early, otherwise the goroutine (and the connection) is kept until all rows are sent.
+func (s *Repository[T]) Chan(ctx context.Context, buffer int, order interface{}, filters ...SqlFilter) (<-chan T, <-chan error) {
+	items := make(chan T, buffer)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(items)
+
+		for item, err := range s.Iter(ctx, order, filters...) {
+			if err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case items <- item:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+	}()
+
+	return items, errs
+}
+
+// Insert inserts the entity and sets the auto increment field by the generated value.
+func (s *Repository[T]) Insert(ctx context.Context, entity *T) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	sqlAccess, err := s.newAccess(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.Insert(entity)
+}
+
+// Update updates all the fields of the entity by primary key, except the created ones.
+func (s *Repository[T]) Update(ctx context.Context, entity *T) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	sqlAccess, err := s.newAccess(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.UpdateByPrimaryKey(entity)
+}
+
+// Delete deletes (or marks as deleted) the entity by primary key, the values of pk are in the order of the primary key fields.
+func (s *Repository[T]) Delete(ctx context.Context, pk ...interface{}) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	dbEntity := new(T)
+	filter, err := s.primaryKeyFilter(dbEntity, pk)
+	if err != nil {
+		return 0, err
+	}
+
+	sqlAccess, err := s.newAccess(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.Delete(dbEntity, filter)
+}
+
+// HardDelete deletes the entity by primary key physically, even if the entity defines soft delete field.
+func (s *Repository[T]) HardDelete(ctx context.Context, pk ...interface{}) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	dbEntity := new(T)
+	filter, err := s.primaryKeyFilter(dbEntity, pk)
+	if err != nil {
+		return 0, err
+	}
+
+	sqlAccess, err := s.newAccess(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.HardDelete(dbEntity, filter)
+}
+
+func (s *Repository[T]) newAccess(ctx context.Context) (SqlAccess, error) {
+	sqlAccess, err := s.db.NewAccess(false)
+	if err != nil {
+		return nil, err
+	}
+
+	return sqlAccess.WithContext(ctx), nil
+}
+
+func (s *Repository[T]) primaryKeyFilter(dbEntity *T, pk []interface{}) (SqlFilter, error) {
+	sqlEntity := s.db.NewEntity()
+	err := sqlEntity.Parse(dbEntity)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0)
+	fieldCount := sqlEntity.FieldCount()
+	for i := 0; i < fieldCount; i++ {
+		f := sqlEntity.Field(i)
+		if f.PrimaryKey() {
+			names = append(names, f.Name())
+		}
+	}
+	if len(names) < 1 {
+		return nil, newError("invalid entity (", sqlEntity.Name(), "): primary key not defined")
+	}
+	if len(names) != len(pk) {
+		return nil, fmt.Errorf("invalid primary key of entity (%s): expect %d values, actual %d", sqlEntity.Name(), len(names), len(pk))
+	}
+
+	fields := make([]SqlField, len(names))
+	for i, name := range names {
+		fields[i] = &field{
+			name:   name,
+			value:  pk[i],
+			filter: "=",
+		}
+	}
+
+	return NewFilter(fields, false, false), nil
+}
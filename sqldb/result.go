@@ -16,9 +16,18 @@ const (
 	SqlColKeyName
 )
 
+// DefaultTimeFormat is the format of time values in the json of SqlSelectResult and the exported text
+const DefaultTimeFormat = "2006-01-02 15:04:05.000"
+
 type SqlSelectResult struct {
-	Columns []*SqlSelectColumn `json:"columns"`
-	Rows    []*SqlSelectRow    `json:"rows"`
+	Columns   []*SqlSelectColumn `json:"columns"`
+	Rows      []*SqlSelectRow    `json:"rows"`
+	Truncated bool               `json:"truncated" note:"是否超过最大行数而截断"`
+	Next      *SqlSelectResult   `json:"next,omitempty" note:"下一个结果集, 如批处理语句"`
+
+	// TimeFormat is the format of time values in json, DefaultTimeFormat if empty,
+	// the values are kept as time.Time in rows and formatted on marshaling.
+	TimeFormat string `json:"-"`
 
 	scans []interface{}
 }
@@ -53,6 +62,7 @@ func (s *SqlSelectResult) Init(columnTypes []*sql.ColumnType) {
 		s.Columns = append(s.Columns, column)
 
 		scanType := columnType.ScanType()
+		column.scanType = scanType
 		if scanType == nil {
 			var value *string
 			addr := &value
@@ -109,7 +119,7 @@ func (s *SqlSelectResult) Init(columnTypes []*sql.ColumnType) {
 						if *timeAddr == nil {
 							return columnId, nil
 						} else {
-							return columnId, (*timeAddr).Format("2006-01-02 15:04:05.000")
+							return columnId, **timeAddr
 						}
 					}
 				} else {
@@ -145,7 +155,8 @@ func (s *SqlSelectResult) AppendRowWidthKey(columns []*SqlSelectColumn, key SqlC
 	}
 
 	row := &SqlSelectRow{
-		kv: make(map[string]interface{}),
+		kv:         make(map[string]interface{}),
+		timeFormat: s.TimeFormat,
 	}
 	s.Rows = append(s.Rows, row)
 
@@ -179,6 +190,7 @@ type SqlSelectColumn struct {
 	Scale     int64  `json:"scale" note:"小数位数"`
 
 	getValue func() (id string, value interface{})
+	scanType reflect.Type
 }
 
 func (s SqlSelectColumn) GetValue() (id string, value interface{}) {
@@ -194,7 +206,8 @@ func (s *SqlSelectColumn) SetValue(v func() (id string, value interface{})) {
 }
 
 type SqlSelectRow struct {
-	kv map[string]interface{}
+	kv         map[string]interface{}
+	timeFormat string
 }
 
 func (s *SqlSelectRow) SetValue(k string, v interface{}) {
@@ -205,6 +218,26 @@ func (s *SqlSelectRow) SetValue(k string, v interface{}) {
 	s.kv[k] = v
 }
 
+// Value returns the value of column by key (id or name, see AppendRowWidthKey)
+func (s SqlSelectRow) Value(key string) interface{} {
+	return s.kv[key]
+}
+
 func (s SqlSelectRow) MarshalJSON() ([]byte, error) {
-	return json.Marshal(s.kv)
+	timeFormat := s.timeFormat
+	if timeFormat == "" {
+		timeFormat = DefaultTimeFormat
+	}
+
+	kv := make(map[string]interface{}, len(s.kv))
+	for k, v := range s.kv {
+		t, ok := v.(time.Time)
+		if ok {
+			kv[k] = t.Format(timeFormat)
+		} else {
+			kv[k] = v
+		}
+	}
+
+	return json.Marshal(kv)
 }
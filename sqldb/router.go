@@ -0,0 +1,439 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+const (
+	RoutingRoundRobin   = "round-robin"
+	RoutingLeastLatency = "least-latency"
+
+	routingCheckInterval  = 10
+	routingCheckTimeout   = 5
+	routingReloadInterval = 60
+)
+
+type SqlEndpoint struct {
+	Host string `json:"host" note:"服务器名称或IP"`
+	Port int    `json:"port" note:"服务器端口, 默认与主库相同"`
+}
+
+type SqlRouting struct {
+	Policy        string `json:"policy" note:"只读副本选择策略: round-robin(默认, 轮询), least-latency(延迟最小)"`
+	MaxLag        int    `json:"maxLag" note:"最大复制延迟, 单位秒, 超过时不使用该副本, 0表示不限制"`
+	CheckInterval int    `json:"checkInterval" note:"健康检查间隔, 单位秒, 默认10"`
+	CheckTimeout  int    `json:"checkTimeout" note:"健康检查超时时间, 单位秒, 默认5"`
+}
+
+type readReplica struct {
+}
+
+func (s *readReplica) FieldOr() bool {
+	return false
+}
+
+func (s *readReplica) GroupOr() bool {
+	return false
+}
+
+func (s *readReplica) Fields() interface{} {
+	return nil
+}
+
+// ReadReplica is the filter reading from a healthy replica (see SqlRouter) in the Select* of database,
+// e.g. db.SelectList(entity, row, order, sqldb.ReadReplica()), the primary is read without it.
+// It is ignored by the accesses, use NewClusterAccess(false, true) to read the replica by access.
+func ReadReplica() SqlFilter {
+	return &readReplica{}
+}
+
+// IsReadReplica reports whether the filters contain ReadReplica
+func IsReadReplica(filters []SqlFilter) bool {
+	for _, f := range filters {
+		_, ok := f.(*readReplica)
+		if ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SqlClusterConnection is implemented by the connections of one primary and read replicas
+type SqlClusterConnection interface {
+	SqlConnection
+	// ReplicaSourceNames returns the source names of the read replicas
+	ReplicaSourceNames() []string
+	// RoutingOptions returns the options of routing, nil for defaults
+	RoutingOptions() *SqlRouting
+}
+
+// SqlFailoverConnection is implemented by the connections of multiple hosts for the primary
+type SqlFailoverConnection interface {
+	SqlConnection
+	// FailoverSourceNames returns the source names of the hosts in order of priority, the first is SourceName
+	FailoverSourceNames() []string
+	// RoutingOptions returns the options of routing, nil for defaults
+	RoutingOptions() *SqlRouting
+}
+
+type SqlEndpointStatus struct {
+	Index    int        `json:"index" note:"序号, 主库的0为主服务器, 其它为备用服务器"`
+	Healthy  bool       `json:"healthy" note:"是否可用"`
+	Writable bool       `json:"writable" note:"是否可写(主库角色)"`
+	Latency  int64      `json:"latency" note:"检查延迟, 单位毫秒"`
+	Lag      int64      `json:"lag" note:"复制延迟, 单位秒"`
+	Error    string     `json:"error" note:"检查失败原因"`
+	Checked  *time.Time `json:"checked" note:"检查时间"`
+}
+
+type SqlTopology struct {
+	Primary   int                  `json:"primary" note:"当前主库的序号"`
+	Primaries []*SqlEndpointStatus `json:"primaries" note:"主库的服务器, 按优先顺序, 没有备用服务器时为空"`
+	Replicas  []*SqlEndpointStatus `json:"replicas" note:"只读副本"`
+	Checked   *time.Time           `json:"checked" note:"最近检查时间"`
+}
+
+// SqlRouter routes the accesses to the healthy hosts: the read-only ones to the replicas,
+// and the others to the primary, which fails over to the next healthy and writable host (see PrimaryDetector).
+// The hosts are checked (ping, role and replication lag, see ReplicaLagger) on first routing,
+// then every CheckInterval in background (see Start) or on routing.
+type SqlRouter struct {
+	dialect    Dialect
+	connection SqlConnection
+	routing    SqlRouting
+
+	mutex     sync.Mutex
+	primaries []*sqlEndpoint // empty if no failover
+	primary   int
+	replicas  []*sqlEndpoint
+	next      int
+	checked   time.Time
+	checking  bool
+	reloaded  time.Time
+	first     sync.Once
+	stop      chan struct{}
+}
+
+type sqlEndpoint struct {
+	sourceName string
+	status     SqlEndpointStatus
+}
+
+// NewRouter returns the router of connection, nil if the connection has no replicas or failover hosts
+func NewRouter(dialect Dialect, connection SqlConnection) *SqlRouter {
+	router := &SqlRouter{dialect: dialect, connection: connection}
+	var routing *SqlRouting = nil
+	if cluster, ok := connection.(SqlClusterConnection); ok {
+		router.replicas = newEndpoints(cluster.ReplicaSourceNames())
+		routing = cluster.RoutingOptions()
+	}
+	if failover, ok := connection.(SqlFailoverConnection); ok {
+		sourceNames := failover.FailoverSourceNames()
+		if len(sourceNames) > 1 {
+			router.primaries = newEndpoints(sourceNames)
+		}
+		routing = failover.RoutingOptions()
+	}
+	if len(router.replicas) < 1 && len(router.primaries) < 1 {
+		return nil
+	}
+
+	if routing != nil {
+		router.routing = *routing
+	}
+	if router.routing.CheckInterval < 1 {
+		router.routing.CheckInterval = routingCheckInterval
+	}
+	if router.routing.CheckTimeout < 1 {
+		router.routing.CheckTimeout = routingCheckTimeout
+	}
+
+	return router
+}
+
+func newEndpoints(sourceNames []string) []*sqlEndpoint {
+	endpoints := make([]*sqlEndpoint, len(sourceNames))
+	for i, sourceName := range sourceNames {
+		endpoints[i] = &sqlEndpoint{sourceName: sourceName, status: SqlEndpointStatus{Index: i}}
+	}
+
+	return endpoints
+}
+
+// Failover reports whether the primary has multiple hosts
+func (s *SqlRouter) Failover() bool {
+	return len(s.primaries) > 0
+}
+
+// SourceName returns the source name of a replica if readOnly and available, or the current primary otherwise
+func (s *SqlRouter) SourceName(readOnly bool) string {
+	if !readOnly && !s.Failover() {
+		return s.connection.ClusterSourceName(false)
+	}
+
+	s.mutex.Lock()
+	if s.checked.IsZero() {
+		// the others wait for the first check rather than checking at the same time
+		s.mutex.Unlock()
+		s.first.Do(s.Check)
+		s.mutex.Lock()
+	} else if s.stop == nil && !s.checking && time.Since(s.checked) > time.Duration(s.routing.CheckInterval)*time.Second {
+		s.checking = true
+		go s.Check()
+	}
+	var replica *sqlEndpoint = nil
+	if readOnly {
+		replica = s.pick()
+	}
+	primary := ""
+	if s.Failover() {
+		primary = s.primaries[s.primary].sourceName
+	}
+	s.mutex.Unlock()
+
+	if replica != nil {
+		return replica.sourceName
+	}
+	if primary != "" {
+		return primary
+	}
+
+	return s.connection.ClusterSourceName(readOnly)
+}
+
+// pick returns the replica by the policy from the healthy ones in lag, it is called in lock
+func (s *SqlRouter) pick() *sqlEndpoint {
+	candidates := make([]*sqlEndpoint, 0, len(s.replicas))
+	for _, replica := range s.replicas {
+		if !replica.status.Healthy {
+			continue
+		}
+		if s.routing.MaxLag > 0 && replica.status.Lag > int64(s.routing.MaxLag) {
+			continue
+		}
+		candidates = append(candidates, replica)
+	}
+	if len(candidates) < 1 {
+		return nil
+	}
+
+	if s.routing.Policy == RoutingLeastLatency {
+		picked := candidates[0]
+		for _, replica := range candidates[1:] {
+			if replica.status.Latency < picked.status.Latency {
+				picked = replica
+			}
+		}
+		return picked
+	}
+
+	s.next = (s.next + 1) % len(candidates)
+
+	return candidates[s.next]
+}
+
+// elect returns the index of primary: the current one if writable, or the first writable one in order,
+// the current one is kept if none writable. It is called in lock.
+func (s *SqlRouter) elect() int {
+	current := s.primaries[s.primary].status
+	if current.Healthy && current.Writable {
+		return s.primary
+	}
+	for i, primary := range s.primaries {
+		if primary.status.Healthy && primary.status.Writable {
+			return i
+		}
+	}
+
+	return s.primary
+}
+
+// Check checks all hosts at once, the source names are reloaded from the connection first for the rotated credentials
+// (see reload)
+func (s *SqlRouter) Check() {
+	endpoints := append(append(make([]*sqlEndpoint, 0), s.primaries...), s.replicas...)
+	sourceNames := s.reload(endpoints)
+	statuses := make([]SqlEndpointStatus, len(endpoints))
+	wg := &sync.WaitGroup{}
+	for i := range endpoints {
+		wg.Add(1)
+		go func(index int, sourceName string, primary bool) {
+			defer wg.Done()
+			statuses[index] = s.check(sourceName, primary)
+		}(i, sourceNames[i], i < len(s.primaries))
+	}
+	wg.Wait()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i, endpoint := range endpoints {
+		statuses[i].Index = endpoint.status.Index
+		endpoint.status = statuses[i]
+	}
+	if s.Failover() {
+		s.primary = s.elect()
+	}
+	s.checked = time.Now()
+	s.checking = false
+}
+
+// reload updates the source names of endpoints (the primaries then the replicas) from the connection and returns them,
+// they are reloaded every routingReloadInterval or after any check failed, rather than getting the credential on every check.
+func (s *SqlRouter) reload(endpoints []*sqlEndpoint) []string {
+	sourceNames := make([]string, 0, len(endpoints))
+	s.mutex.Lock()
+	reloading := s.reloaded.IsZero() || time.Since(s.reloaded) > routingReloadInterval*time.Second
+	for _, endpoint := range endpoints {
+		if !reloading && endpoint.status.Checked != nil && !endpoint.status.Healthy {
+			reloading = true
+		}
+		sourceNames = append(sourceNames, endpoint.sourceName)
+	}
+	s.mutex.Unlock()
+	if !reloading {
+		return sourceNames
+	}
+
+	sourceNames = sourceNames[:0]
+	if failover, ok := s.connection.(SqlFailoverConnection); ok && s.Failover() {
+		sourceNames = append(sourceNames, failover.FailoverSourceNames()...)
+	}
+	if cluster, ok := s.connection.(SqlClusterConnection); ok && len(s.replicas) > 0 {
+		sourceNames = append(sourceNames, cluster.ReplicaSourceNames()...)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(sourceNames) != len(endpoints) {
+		sourceNames = sourceNames[:0]
+		for _, endpoint := range endpoints {
+			sourceNames = append(sourceNames, endpoint.sourceName)
+		}
+		return sourceNames
+	}
+	for i, endpoint := range endpoints {
+		endpoint.sourceName = sourceNames[i]
+	}
+	s.reloaded = time.Now()
+
+	return sourceNames
+}
+
+func (s *SqlRouter) check(sourceName string, primary bool) SqlEndpointStatus {
+	now := time.Now()
+	status := SqlEndpointStatus{Checked: &now}
+	db, err := sql.Open(s.connection.DriverName(), sourceName)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	sqlAccess, err := NewAccess(s.dialect, db, false)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	defer sqlAccess.Close()
+
+	// the whole check is bounded by CheckTimeout, including the queries of role and lag
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.routing.CheckTimeout)*time.Second)
+	defer cancel()
+	ctxAccess := sqlAccess.WithContext(ctx)
+	err = db.PingContext(ctx)
+	status.Latency = time.Since(now).Milliseconds()
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+
+	status.Writable = true
+	if detector, ok := s.dialect.(PrimaryDetector); ok {
+		status.Writable, err = detector.IsPrimary(ctxAccess)
+		if err != nil {
+			status.Error = err.Error()
+			return status
+		}
+	}
+	if lagger, ok := s.dialect.(ReplicaLagger); ok && !primary {
+		lag, err := lagger.ReplicaLag(ctxAccess)
+		if err != nil {
+			status.Error = err.Error()
+			return status
+		}
+		status.Lag = int64(lag / time.Second)
+	}
+	status.Healthy = true
+
+	return status
+}
+
+// Start checks the hosts in background every CheckInterval until Stop
+func (s *SqlRouter) Start() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.stop != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	s.stop = stop
+	go func() {
+		ticker := time.NewTicker(time.Duration(s.routing.CheckInterval) * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				s.Check()
+			}
+		}
+	}()
+}
+
+func (s *SqlRouter) Stop() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.stop == nil {
+		return
+	}
+
+	close(s.stop)
+	s.stop = nil
+}
+
+// Topology returns the status of hosts in the last checking
+func (s *SqlRouter) Topology() *SqlTopology {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	topology := &SqlTopology{
+		Primary:   s.primary,
+		Primaries: endpointStatuses(s.primaries),
+		Replicas:  endpointStatuses(s.replicas),
+	}
+	if !s.checked.IsZero() {
+		checked := s.checked
+		topology.Checked = &checked
+	}
+
+	return topology
+}
+
+func endpointStatuses(endpoints []*sqlEndpoint) []*SqlEndpointStatus {
+	statuses := make([]*SqlEndpointStatus, len(endpoints))
+	for i, endpoint := range endpoints {
+		status := endpoint.status
+		statuses[i] = &status
+	}
+
+	return statuses
+}
+
+// SqlRouted is implemented by the databases routing the accesses by SqlRouter
+type SqlRouted interface {
+	Router() *SqlRouter
+}
@@ -0,0 +1,245 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testClusterConnection struct {
+	replicas []string
+	routing  *SqlRouting
+	reloads  int32
+}
+
+func (s *testClusterConnection) DriverName() string {
+	return "test"
+}
+
+func (s *testClusterConnection) SourceName() string {
+	return "primary"
+}
+
+func (s *testClusterConnection) SchemaName() string {
+	return "test"
+}
+
+func (s *testClusterConnection) ClusterSourceName(readOnly bool) string {
+	if readOnly {
+		return "primary-ro"
+	}
+	return "primary"
+}
+
+func (s *testClusterConnection) ReplicaSourceNames() []string {
+	atomic.AddInt32(&s.reloads, 1)
+	return s.replicas
+}
+
+func (s *testClusterConnection) RoutingOptions() *SqlRouting {
+	return s.routing
+}
+
+func TestRouter_SourceName(t *testing.T) {
+	if NewRouter(nil, &testClusterConnection{}) != nil {
+		t.Error("expect no router without replicas")
+	}
+
+	connection := &testClusterConnection{
+		replicas: []string{"r0", "r1", "r2"},
+		routing:  &SqlRouting{MaxLag: 10, CheckInterval: 3600},
+	}
+	router := NewRouter(nil, connection)
+	router.checked = time.Now()
+	router.replicas[0].status = SqlEndpointStatus{Healthy: true, Latency: 30, Lag: 1}
+	router.replicas[1].status = SqlEndpointStatus{Healthy: false, Latency: 1}
+	router.replicas[2].status = SqlEndpointStatus{Healthy: true, Latency: 20, Lag: 2}
+
+	if sourceName := router.SourceName(false); sourceName != "primary" {
+		t.Errorf("writes routed to %s", sourceName)
+	}
+	routed := make(map[string]int)
+	for i := 0; i < 4; i++ {
+		routed[router.SourceName(true)]++
+	}
+	if routed["r0"] != 2 || routed["r2"] != 2 {
+		t.Errorf("unexpected round robin %v", routed)
+	}
+
+	router.routing.Policy = RoutingLeastLatency
+	if sourceName := router.SourceName(true); sourceName != "r2" {
+		t.Errorf("expect least latency r2, actual %s", sourceName)
+	}
+
+	// lagged
+	router.replicas[2].status.Lag = 11
+	if sourceName := router.SourceName(true); sourceName != "r0" {
+		t.Errorf("expect r0 in lag, actual %s", sourceName)
+	}
+	router.replicas[0].status.Healthy = false
+	if sourceName := router.SourceName(true); sourceName != "primary-ro" {
+		t.Errorf("expect fallback to primary, actual %s", sourceName)
+	}
+}
+
+type testFailoverConnection struct {
+	testClusterConnection
+	hosts []string
+}
+
+func (s *testFailoverConnection) FailoverSourceNames() []string {
+	return append([]string{s.SourceName()}, s.hosts...)
+}
+
+func TestRouter_Elect(t *testing.T) {
+	connection := &testFailoverConnection{hosts: []string{"h1", "h2"}}
+	router := NewRouter(nil, connection)
+	if router == nil || !router.Failover() {
+		t.Fatal("expect failover router")
+	}
+	router.checked = time.Now()
+	router.primaries[0].status = SqlEndpointStatus{Healthy: false}
+	router.primaries[1].status = SqlEndpointStatus{Healthy: true, Writable: false}
+	router.primaries[2].status = SqlEndpointStatus{Healthy: true, Writable: true}
+	router.primary = router.elect()
+	if sourceName := router.SourceName(false); sourceName != "h2" {
+		t.Errorf("expect failover to h2, actual %s", sourceName)
+	}
+	if sourceName := router.SourceName(true); sourceName != "h2" {
+		t.Errorf("expect reading from h2 without replicas, actual %s", sourceName)
+	}
+
+	// the current is kept after the first recovered
+	router.primaries[0].status = SqlEndpointStatus{Healthy: true, Writable: true}
+	if router.elect() != 2 {
+		t.Error("expect the current primary kept")
+	}
+	router.primaries[2].status.Healthy = false
+	if router.elect() != 0 {
+		t.Error("expect failover to the first")
+	}
+
+	topology := router.Topology()
+	if topology.Primary != 2 || len(topology.Primaries) != 3 || len(topology.Replicas) != 0 || topology.Checked == nil {
+		t.Errorf("unexpected topology %+v", topology)
+	}
+}
+
+// testRouterDriver pings at once and blocks the queries until the context is done
+type testRouterDriver struct {
+	pings int32
+}
+
+var routerDriver = &testRouterDriver{}
+
+func init() {
+	sql.Register("sqldb-router-test", routerDriver)
+}
+
+func (s *testRouterDriver) Open(name string) (driver.Conn, error) {
+	return &testRouterConn{}, nil
+}
+
+type testRouterConn struct {
+}
+
+func (s *testRouterConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s *testRouterConn) Close() error {
+	return nil
+}
+
+func (s *testRouterConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s *testRouterConn) Ping(ctx context.Context) error {
+	atomic.AddInt32(&routerDriver.pings, 1)
+	return nil
+}
+
+func (s *testRouterConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+type testRouterConnection struct {
+	testClusterConnection
+}
+
+func (s *testRouterConnection) DriverName() string {
+	return "sqldb-router-test"
+}
+
+type testPrimaryDialect struct {
+	testDialect
+}
+
+func (s testPrimaryDialect) IsPrimary(sqlAccess SqlAccess) (bool, error) {
+	primary := false
+	err := sqlAccess.QueryRow("SELECT 1").Scan(&primary)
+
+	return primary, err
+}
+
+func TestRouter_CheckTimeout(t *testing.T) {
+	connection := &testRouterConnection{testClusterConnection{replicas: []string{"r0"}, routing: &SqlRouting{CheckTimeout: 1}}}
+	router := NewRouter(testPrimaryDialect{}, connection)
+
+	start := time.Now()
+	status := router.check("r0", false)
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("check not bounded by the timeout, elapsed %v", elapsed)
+	}
+	if status.Healthy || !strings.Contains(status.Error, context.DeadlineExceeded.Error()) {
+		t.Errorf("expect deadline exceeded, actual %+v", status)
+	}
+}
+
+func TestRouter_FirstCheck(t *testing.T) {
+	connection := &testRouterConnection{testClusterConnection{replicas: []string{"r0"}}}
+	router := NewRouter(testDialect{}, connection)
+
+	atomic.StoreInt32(&routerDriver.pings, 0)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if sourceName := router.SourceName(true); sourceName != "r0" {
+				t.Errorf("expect r0 after the first check, actual %s", sourceName)
+			}
+		}()
+	}
+	wg.Wait()
+	if pings := atomic.LoadInt32(&routerDriver.pings); pings != 1 {
+		t.Errorf("expect the first check once, actual %d pings", pings)
+	}
+}
+
+func TestRouter_Reload(t *testing.T) {
+	connection := &testRouterConnection{testClusterConnection{replicas: []string{"r0"}}}
+	router := NewRouter(testDialect{}, connection)
+	reloads := atomic.LoadInt32(&connection.reloads)
+
+	router.Check()
+	router.Check()
+	if actual := atomic.LoadInt32(&connection.reloads) - reloads; actual != 1 {
+		t.Errorf("expect source names reloaded once in the interval, actual %d", actual)
+	}
+
+	// reloaded at once after failed, e.g. by the rotated password
+	router.replicas[0].status.Healthy = false
+	router.Check()
+	if actual := atomic.LoadInt32(&connection.reloads) - reloads; actual != 2 {
+		t.Errorf("expect source names reloaded after failed, actual %d", actual)
+	}
+}
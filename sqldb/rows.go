@@ -0,0 +1,132 @@
+package sqldb
+
+import (
+	"database/sql"
+	"reflect"
+)
+
+// rows is the SqlRows of entity, each row is scanned into the buffer entity by Next,
+// and copied into the destination by Scan.
+type rows struct {
+	sqlAccess SqlAccess
+	dialect   Dialect
+	rows      *sql.Rows
+	sqlEntity *entity
+	buffer    reflect.Value
+	owner     SqlAccess // closed with the rows if any
+	closed    bool
+	err       error
+}
+
+func (s *rows) Next() bool {
+	if s.closed || s.err != nil {
+		return false
+	}
+
+	if !s.rows.Next() {
+		s.err = s.dialect.Error(s.rows.Err())
+		s.Close()
+		return false
+	}
+
+	err := s.rows.Scan(s.sqlEntity.ScanArgs()...)
+	if err != nil {
+		s.err = s.dialect.Error(err)
+		s.Close()
+		return false
+	}
+	err = afterScan(s.sqlAccess, s.buffer.Interface())
+	if err != nil {
+		s.err = err
+		s.Close()
+		return false
+	}
+
+	return true
+}
+
+func (s *rows) Scan(dbEntity interface{}) error {
+	if dbEntity == nil {
+		return newError("invalid entity: nil")
+	}
+	v := reflect.ValueOf(dbEntity)
+	if v.Type() != s.buffer.Type() {
+		return newError("invalid entity: ", v.Type(), " is not ", s.buffer.Type())
+	}
+	v.Elem().Set(s.buffer.Elem())
+
+	return nil
+}
+
+func (s *rows) Err() error {
+	return s.err
+}
+
+func (s *rows) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	err := s.rows.Close()
+	if s.owner != nil {
+		ownerErr := s.owner.Close()
+		if err == nil {
+			err = ownerErr
+		}
+	}
+
+	return err
+}
+
+// CloseWithRows closes the access when the rows are closed,
+// it is used by SqlDatabase.SelectRows which opens an access for the rows only.
+func CloseWithRows(sqlRows SqlRows, sqlAccess SqlAccess) SqlRows {
+	r, ok := sqlRows.(*rows)
+	if ok {
+		r.owner = sqlAccess
+	}
+
+	return sqlRows
+}
+
+func (s *access) selectRows(sqlAccess SqlAccess, dbEntity interface{}, dbOrder interface{}, sqlFilters ...SqlFilter) (SqlRows, error) {
+	if len(getPreloads(sqlFilters)) > 0 {
+		return nil, newError("preload is not supported by rows, use SelectList instead")
+	}
+	if dbEntity == nil || reflect.TypeOf(dbEntity).Kind() != reflect.Ptr {
+		return nil, newError("invalid entity: not address")
+	}
+
+	buffer := reflect.New(reflect.TypeOf(dbEntity).Elem())
+	sqlEntity := s.newEntity()
+	err := sqlEntity.Parse(buffer.Interface())
+	if err != nil {
+		return nil, err
+	}
+	err = sqlEntity.project(sqlFilters)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlBuilder := s.newBuilder()
+	sqlBuilder.Select(sqlEntity.ScanFields(), false).From(sqlEntity.Name())
+	err = s.fillScopedWhere(sqlBuilder, sqlEntity, sqlFilters...)
+	if err != nil {
+		return nil, err
+	}
+	s.fillOrder(sqlBuilder, dbOrder)
+
+	sqlRows, err := sqlAccess.Query(sqlBuilder.Query(), sqlBuilder.Args()...)
+	if err != nil {
+		return nil, s.dialect.Error(err)
+	}
+
+	return &rows{
+		sqlAccess: sqlAccess,
+		dialect:   s.dialect,
+		rows:      sqlRows,
+		sqlEntity: sqlEntity,
+		buffer:    buffer,
+	}, nil
+}
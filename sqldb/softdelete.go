@@ -0,0 +1,99 @@
+package sqldb
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+const (
+	softDeleteKindTime   = 1 // *time.Time or sql.NullTime: NULL means not deleted, set to the time of deleting
+	softDeleteKindFlag   = 2 // bool: false means not deleted, set to true
+	softDeleteKindNumber = 3 // integer: 0 means not deleted, set to 1
+)
+
+type softDeleteMeta struct {
+	name string
+	kind int
+}
+
+func newSoftDeleteMeta(t reflect.Type, fields []*fieldMeta) (*softDeleteMeta, error) {
+	var meta *softDeleteMeta = nil
+	for _, fm := range fields {
+		if !fm.softDelete {
+			continue
+		}
+		if meta != nil {
+			return nil, newError("invalid entity (", t.Name(), "): more than one soft delete field")
+		}
+
+		meta = &softDeleteMeta{name: fm.name}
+		switch fm.typ.Kind() {
+		case reflect.Bool:
+			meta.kind = softDeleteKindFlag
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			meta.kind = softDeleteKindNumber
+		default:
+			if fm.typ == reflect.TypeOf(&time.Time{}) || fm.typ == reflect.TypeOf(sql.NullTime{}) {
+				meta.kind = softDeleteKindTime
+			} else {
+				return nil, newError("invalid entity (", t.Name(), "): soft delete field ", fm.name, " should be *time.Time, sql.NullTime, bool or integer")
+			}
+		}
+	}
+
+	return meta, nil
+}
+
+func (s *softDeleteMeta) notDeletedCondition() string {
+	if s.kind == softDeleteKindTime {
+		return fmt.Sprintf("%s IS NULL", s.name)
+	}
+
+	return fmt.Sprintf("%s = 0", s.name)
+}
+
+func (s *softDeleteMeta) deletedValue() interface{} {
+	switch s.kind {
+	case softDeleteKindTime:
+		return Now()
+	case softDeleteKindFlag:
+		return true
+	default:
+		return 1
+	}
+}
+
+type unscoped struct {
+}
+
+func (s *unscoped) FieldOr() bool {
+	return false
+}
+
+func (s *unscoped) GroupOr() bool {
+	return false
+}
+
+func (s *unscoped) Fields() interface{} {
+	return nil
+}
+
+// Unscoped is the filter which includes the soft deleted rows in select,
+// and deletes the rows physically in delete, e.g. db.SelectList(entity, row, order, sqldb.Unscoped(), filter)
+func Unscoped() SqlFilter {
+	return &unscoped{}
+}
+
+func isUnscoped(filters []SqlFilter) bool {
+	for _, f := range filters {
+		_, ok := f.(*unscoped)
+		if ok {
+			return true
+		}
+	}
+
+	return false
+}
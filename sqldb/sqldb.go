@@ -1,6 +1,10 @@
 package sqldb
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"io"
+)
 
 type SqlFactory interface {
 	NewDatabase() SqlDatabase
@@ -24,23 +28,44 @@ type SqlDatabase interface {
 
 	NewAccess(transactional bool) (SqlAccess, error)
 	NewClusterAccess(transactional bool, readOnly bool) (SqlAccess, error)
+	NewSnapshotAccess() (SqlAccess, error)
 	NewEntity() SqlEntity
 	NewBuilder() SqlBuilder
 	NewFilter(entity interface{}, fieldOr, groupOr bool) SqlFilter
 
+	QueryResult(query string, args ...interface{}) (*SqlSelectResult, error)
+	// QueryResultLimit is QueryResult keeping limit rows of each result set at most, 0 for no limit,
+	// the rest rows are skipped and the result is marked as truncated
+	QueryResultLimit(limit uint64, query string, args ...interface{}) (*SqlSelectResult, error)
+
 	IsNoRows(err error) bool
 	Insert(entity interface{}) (uint64, error)
 	InsertSelective(entity interface{}) (uint64, error)
 	Delete(entity interface{}, filters ...SqlFilter) (uint64, error)
+	HardDelete(entity interface{}, filters ...SqlFilter) (uint64, error)
 	Update(entity interface{}, filters ...SqlFilter) (uint64, error)
 	UpdateSelective(entity interface{}, filters ...SqlFilter) (uint64, error)
 	UpdateByPrimaryKey(entity interface{}) (uint64, error)
 	UpdateSelectiveByPrimaryKey(entity interface{}) (uint64, error)
+	UpdateFields(entity interface{}, columns []string, filters ...SqlFilter) (uint64, error)
+	UpdateMap(entity interface{}, values map[string]interface{}, filters ...SqlFilter) (uint64, error)
 	SelectCount(entity interface{}, filters ...SqlFilter) (uint64, error)
 	SelectOne(entity interface{}, filters ...SqlFilter) error
 	SelectDistinct(entity interface{}, row func(idx uint64, evt SqlEvent), order interface{}, filters ...SqlFilter) error
 	SelectList(entity interface{}, row func(idx uint64, evt SqlEvent), order interface{}, filters ...SqlFilter) error
 	SelectPage(entity interface{}, page func(total, page, size, index uint64), row func(idx uint64, evt SqlEvent), size, index uint64, order interface{}, filters ...SqlFilter) error
+	SelectRows(entity interface{}, order interface{}, filters ...SqlFilter) (SqlRows, error)
+
+	// ImportTable imports the records into table in one transaction, which is rolled back (none inserted) if any error
+	ImportTable(table *SqlTable, r io.Reader, options *ImportOptions) (*ImportResult, error)
+	// ImportEntity imports the records as entities in one transaction, which is rolled back (none inserted) if any error
+	ImportEntity(entity interface{}, r io.Reader, options *ImportOptions) (*ImportResult, error)
+}
+
+// SqlDefiner is implemented by the databases which generate the definitions (DDL) of tables and views
+type SqlDefiner interface {
+	TableDefinition(table *SqlTable) (string, error)
+	ViewDefinition(viewName string) (string, error)
 }
 
 type SqlInstance interface {
@@ -53,6 +78,7 @@ type SqlAccess interface {
 	Close() error
 	Commit() error
 	Version() int
+	WithContext(ctx context.Context) SqlAccess
 
 	NewFilter(entity interface{}, fieldOr, groupOr bool) SqlFilter
 
@@ -60,26 +86,48 @@ type SqlAccess interface {
 	Prepare(query string) (*sql.Stmt, error)
 	Query(query string, args ...interface{}) (*sql.Rows, error)
 	QueryRow(query string, args ...interface{}) *sql.Row
+	QueryResult(query string, args ...interface{}) (*SqlSelectResult, error)
+	// QueryResultLimit is QueryResult keeping limit rows of each result set at most, 0 for no limit,
+	// the rest rows are skipped and the result is marked as truncated
+	QueryResultLimit(limit uint64, query string, args ...interface{}) (*SqlSelectResult, error)
 
 	IsNoRows(err error) bool
 	Insert(entity interface{}, fields ...SqlField) (uint64, error)
 	InsertSelective(entity interface{}) (uint64, error)
 	Delete(entity interface{}, filters ...SqlFilter) (uint64, error)
+	HardDelete(entity interface{}, filters ...SqlFilter) (uint64, error)
 	Update(entity interface{}, filters ...SqlFilter) (uint64, error)
 	UpdateSelective(entity interface{}, filters ...SqlFilter) (uint64, error)
 	UpdateByPrimaryKey(entity interface{}) (uint64, error)
 	UpdateSelectiveByPrimaryKey(entity interface{}) (uint64, error)
+	UpdateFields(entity interface{}, columns []string, filters ...SqlFilter) (uint64, error)
+	UpdateMap(entity interface{}, values map[string]interface{}, filters ...SqlFilter) (uint64, error)
 	SelectCount(entity interface{}, filters ...SqlFilter) (uint64, error)
 	SelectOne(entity interface{}, filters ...SqlFilter) error
 	SelectDistinct(entity interface{}, row func(idx uint64, evt SqlEvent), order interface{}, filters ...SqlFilter) error
 	SelectList(entity interface{}, row func(idx uint64, evt SqlEvent), order interface{}, filters ...SqlFilter) error
 	SelectPage(entity interface{}, page func(total, page, size, index uint64), row func(idx uint64, evt SqlEvent), size, index uint64, order interface{}, filters ...SqlFilter) error
+	SelectRows(entity interface{}, order interface{}, filters ...SqlFilter) (SqlRows, error)
+
+	ImportTable(table *SqlTable, columns []*SqlColumn, r io.Reader, options *ImportOptions) (*ImportResult, error)
+	ImportEntity(entity interface{}, r io.Reader, options *ImportOptions) (*ImportResult, error)
 }
 
 type SqlEvent interface {
 	Cancel(err error)
 }
 
+// SqlRows iterates the rows of SelectRows, e.g.
+// for rows.Next() { rows.Scan(entity) }; err := rows.Err(); rows.Close()
+type SqlRows interface {
+	// Next scans the next row, false if no more rows or error
+	Next() bool
+	// Scan copies the current row into entity, the address of the same type as SelectRows
+	Scan(entity interface{}) error
+	Err() error
+	Close() error
+}
+
 type SqlField interface {
 	Name() string
 	Value() interface{}
@@ -0,0 +1,157 @@
+package sqlite
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/csby/database/sqldb"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type Connection struct {
+	File    string            `json:"file" note:"数据库文件路径"`
+	Timeout int               `json:"timeout" note:"忙等待超时时间，单位秒，默认5"`
+	Params  map[string]string `json:"params" note:"其它驱动参数, 如_journal_mode, _foreign_keys, 同名时替换默认参数"`
+}
+
+func (s *Connection) DriverName() string {
+	return "sqlite3"
+}
+
+func (s *Connection) SourceName() string {
+	// file:test.db?_busy_timeout=5000&_loc=auto
+	q := url.Values{}
+	timeout := s.Timeout
+	if timeout < 1 {
+		timeout = 5
+	}
+	q.Add("_busy_timeout", fmt.Sprint(timeout*1000))
+	q.Add("_loc", "auto")
+	for key, value := range s.Params {
+		q.Set(key, value)
+	}
+
+	return fmt.Sprintf("file:%s?%s", filepath.ToSlash(s.File), q.Encode())
+}
+
+func (s *Connection) ClusterSourceName(readOnly bool) string {
+	if readOnly {
+		return fmt.Sprintf("%s&mode=ro", s.SourceName())
+	}
+
+	return s.SourceName()
+}
+
+// ParseDSN parses the connection string of driver, e.g. file:test.db?_busy_timeout=5000, or the file path,
+// it is the inverse of SourceName.
+func ParseDSN(dsn string) (*Connection, error) {
+	s := &Connection{File: strings.TrimPrefix(dsn, "file:")}
+	index := strings.Index(s.File, "?")
+	if index < 0 {
+		return s, nil
+	}
+	query, err := url.ParseQuery(s.File[index+1:])
+	if err != nil {
+		return nil, err
+	}
+	s.File = filepath.FromSlash(s.File[:index])
+
+	for key, values := range query {
+		value := ""
+		if len(values) > 0 {
+			value = values[0]
+		}
+		switch {
+		case key == "_busy_timeout":
+			timeout, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid dsn: busy timeout '%s'", value)
+			}
+			s.Timeout = timeout / 1000
+		case key == "_loc" && value == "auto":
+		default:
+			if s.Params == nil {
+				s.Params = make(map[string]string)
+			}
+			s.Params[key] = value
+		}
+	}
+
+	return s, nil
+}
+
+// LoadFromEnv loads the connection from the environment variables with prefix (see sqldb.LoadEnv), e.g. DB_FILE,
+// the connection string in DSN (e.g. DB_DSN) is parsed first if present, then the others override.
+func (s *Connection) LoadFromEnv(prefix string) error {
+	if dsn, ok := os.LookupEnv(sqldb.EnvName(prefix, "dsn")); ok {
+		c, err := ParseDSN(dsn)
+		if err != nil {
+			return err
+		}
+		*s = *c
+	}
+
+	return sqldb.LoadEnv(prefix, s)
+}
+
+func (s *Connection) SchemaName() string {
+	return strings.TrimSuffix(filepath.Base(s.File), filepath.Ext(s.File))
+}
+
+func (s *Connection) SaveToFile(filePath string) error {
+	bytes, err := json.MarshalIndent(s, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	fileFolder := filepath.Dir(filePath)
+	_, err = os.Stat(fileFolder)
+	if os.IsNotExist(err) {
+		os.MkdirAll(fileFolder, 0700)
+	}
+
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	err = file.Chmod(0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprint(file, string(bytes[:]))
+
+	return err
+}
+
+func (s *Connection) LoadFromFile(filePath string) error {
+	bytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(bytes, s)
+}
+
+func (s *Connection) CopyTo(target *Connection) int {
+	if target == nil {
+		return 0
+	}
+
+	count := 0
+	if target.File != s.File {
+		target.File = s.File
+		count++
+	}
+	if target.Timeout != s.Timeout {
+		target.Timeout = s.Timeout
+		count++
+	}
+
+	return count
+}
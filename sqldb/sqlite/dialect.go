@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/csby/database/sqldb"
+
+	driver "github.com/mattn/go-sqlite3"
+)
+
+type dialect struct {
+}
+
+func (s dialect) Quote(name string) string {
+	return fmt.Sprintf("`%s`", name)
+}
+
+func (s dialect) Placeholder(index int) string {
+	return "?"
+}
+
+func (s dialect) Version(sqlAccess sqldb.SqlAccess) int {
+	return 0
+}
+
+func (s dialect) Page(sqlAccess sqldb.SqlAccess, sqlBuilder sqldb.SqlBuilder, paging *sqldb.SqlPaging) {
+	sqlBuilder.Select(paging.Fields, false).From(paging.Table)
+	paging.Where(sqlBuilder)
+	sqlBuilder.Append(paging.Order)
+	sqlBuilder.Append("LIMIT ?, ?", paging.Offset, paging.Size)
+}
+
+func (s dialect) Insert(sqlAccess sqldb.SqlAccess, sqlBuilder sqldb.SqlBuilder, autoField sqldb.SqlField) (uint64, error) {
+	result, err := sqlAccess.Exec(sqlBuilder.Query(), sqlBuilder.Args()...)
+	if err != nil {
+		return 0, err
+	}
+
+	if autoField != nil {
+		id, err := result.LastInsertId()
+		if err != nil {
+			return 0, err
+		}
+		return uint64(id), nil
+	}
+
+	return 0, nil
+}
+
+// BatchLimit returns the limits of multiple rows insert, SQLITE_MAX_VARIABLE_NUMBER is 999 before 3.32.0
+func (s dialect) BatchLimit() (rows, args int) {
+	return 500, 999
+}
+
+func (s dialect) IsNoRows(err error) bool {
+	return errors.Is(err, sql.ErrNoRows)
+}
+
+func (s dialect) Error(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var sqliteErr driver.Error
+	if errors.As(err, &sqliteErr) {
+		if sqliteErr.ExtendedCode == driver.ErrConstraintUnique || sqliteErr.ExtendedCode == driver.ErrConstraintPrimaryKey {
+			return fmt.Errorf("%w: %w", sqldb.ErrDuplicateKey, err)
+		}
+	}
+
+	return err
+}
+
+func (s dialect) ColumnType(t *sqldb.SqlColumnType) string {
+	switch t.Kind {
+	case sqldb.ColumnString:
+		return fmt.Sprintf("varchar(%d)", t.Length)
+	case sqldb.ColumnSmallInt, sqldb.ColumnInt, sqldb.ColumnBigInt:
+		return "integer"
+	case sqldb.ColumnBool:
+		return "boolean"
+	case sqldb.ColumnFloat, sqldb.ColumnDouble:
+		return "real"
+	case sqldb.ColumnDecimal:
+		return fmt.Sprintf("decimal(%d,%d)", t.Precision, t.Scale)
+	case sqldb.ColumnDate:
+		return "date"
+	case sqldb.ColumnTime:
+		return "time"
+	case sqldb.ColumnDateTime:
+		return "datetime"
+	case sqldb.ColumnBinary, sqldb.ColumnBlob:
+		return "blob"
+	case sqldb.ColumnGuid:
+		return "varchar(36)"
+	default:
+		return "text"
+	}
+}
+
+// AutoIncrement declares the primary key in column, AUTOINCREMENT is allowed on INTEGER PRIMARY KEY only
+func (s dialect) AutoIncrement() string {
+	return "PRIMARY KEY AUTOINCREMENT"
+}
+
+// TableName returns the quoted name only, the schema of sqlite is the name of database file
+func (s dialect) TableName(table *sqldb.SqlTable) string {
+	return s.Quote(table.Name)
+}
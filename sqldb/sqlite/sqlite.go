@@ -0,0 +1,520 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/csby/database/sqldb"
+	"io"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type sqlite struct {
+	connection sqldb.SqlConnection
+	router     *sqldb.SqlRouter
+}
+
+func NewDatabase(conn sqldb.SqlConnection) sqldb.SqlDatabase {
+	return &sqlite{connection: conn, router: sqldb.NewRouter(dialect{}, conn)}
+}
+
+func (s *sqlite) Open() (*sql.DB, error) {
+	db, err := sql.Open(s.connection.DriverName(), s.sourceName())
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func (s *sqlite) Instances(host, port string) ([]sqldb.SqlInstance, error) {
+	return nil, fmt.Errorf("not support")
+}
+
+func (s *sqlite) Test() (string, error) {
+	return s.test(s.sourceName())
+}
+
+func (s *sqlite) test(sourceName string) (string, error) {
+	db, err := sql.Open(s.connection.DriverName(), sourceName)
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	err = db.Ping()
+	if err != nil {
+		return "", err
+	}
+
+	dbVer := ""
+	db.QueryRow("SELECT sqlite_version()").Scan(&dbVer)
+
+	return dbVer, nil
+}
+
+func (s *sqlite) ClusterTest(readOnly bool) (string, error) {
+	if s.router == nil {
+		return s.Test()
+	}
+
+	return s.test(s.router.SourceName(readOnly))
+}
+
+func (s *sqlite) Schema() string {
+	return s.connection.SchemaName()
+}
+
+func (s *sqlite) Tables() ([]*sqldb.SqlTable, error) {
+	return s.objects("table")
+}
+
+func (s *sqlite) Views() ([]*sqldb.SqlTable, error) {
+	return s.objects("view")
+}
+
+func (s *sqlite) Columns(table *sqldb.SqlTable) ([]*sqldb.SqlColumn, error) {
+	db, err := sql.Open(s.connection.DriverName(), s.sourceName())
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(`%s`)", table.Name))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make([]*sqldb.SqlColumn, 0)
+	primaryKeyCount := 0
+	columnId := 0
+	columnName := ""
+	columnType := ""
+	notNull := 0
+	primaryKey := 0
+	for rows.Next() {
+		var dataDefault *string = nil
+		err = rows.Scan(&columnId, &columnName, &columnType, &notNull, &dataDefault, &primaryKey)
+		if err != nil {
+			return nil, err
+		}
+
+		column := &sqldb.SqlColumn{
+			Id:          columnId,
+			Name:        columnName,
+			Type:        columnType,
+			DataType:    strings.ToLower(columnType),
+			DataDefault: dataDefault,
+			Nullable:    notNull == 0 && primaryKey == 0,
+			PrimaryKey:  primaryKey > 0,
+		}
+		if index := strings.Index(column.DataType, "("); index > 0 {
+			column.DataType = strings.TrimSpace(column.DataType[:index])
+		}
+		if column.PrimaryKey {
+			primaryKeyCount++
+		}
+		if dataDefault != nil {
+			column.DataDisplay = *dataDefault
+		}
+
+		columns = append(columns, column)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	// a single INTEGER PRIMARY KEY column is an alias of the rowid
+	if primaryKeyCount == 1 {
+		for _, column := range columns {
+			if column.PrimaryKey && column.DataType == "integer" {
+				column.AutoIncrement = true
+			}
+		}
+	}
+
+	return columns, nil
+}
+
+func (s *sqlite) TableDefinition(table *sqldb.SqlTable) (string, error) {
+	if table == nil {
+		return "", fmt.Errorf("table is nil")
+	}
+
+	definition, err := s.definition("table", table.Name)
+	if err != nil {
+		return "", err
+	}
+
+	sb := &strings.Builder{}
+	sb.WriteString(fmt.Sprintf("DROP TABLE IF EXISTS `%s`;", table.Name))
+	sb.WriteString(fmt.Sprintln())
+	sb.WriteString(definition)
+	sb.WriteString(fmt.Sprintln())
+
+	return sb.String(), nil
+}
+
+func (s *sqlite) ViewDefinition(viewName string) (string, error) {
+	return s.definition("view", viewName)
+}
+
+func (s *sqlite) objects(objectType string) ([]*sqldb.SqlTable, error) {
+	db, err := sql.Open(s.connection.DriverName(), s.sourceName())
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	sb := &strings.Builder{}
+	sb.WriteString("select `name` ")
+	sb.WriteString("from `sqlite_master` ")
+	sb.WriteString("where `type` = ? ")
+	sb.WriteString("and `name` not like 'sqlite_%' ")
+	sb.WriteString("order by `name`")
+
+	rows, err := db.Query(sb.String(), objectType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := make([]*sqldb.SqlTable, 0)
+	name := ""
+	for rows.Next() {
+		err = rows.Scan(&name)
+		if err != nil {
+			return nil, err
+		}
+
+		table := &sqldb.SqlTable{
+			Schema: s.connection.SchemaName(),
+			Name:   name,
+		}
+
+		tables = append(tables, table)
+	}
+
+	return tables, nil
+}
+
+func (s *sqlite) definition(objectType, name string) (string, error) {
+	db, err := sql.Open(s.connection.DriverName(), s.sourceName())
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	definition := ""
+	err = db.QueryRow("select `sql` from `sqlite_master` where `type` = ? and `name` = ?", objectType, name).Scan(&definition)
+	if err != nil {
+		return "", err
+	}
+
+	return definition, nil
+}
+
+func (s *sqlite) NewAccess(transactional bool) (sqldb.SqlAccess, error) {
+	db, err := sql.Open(s.connection.DriverName(), s.sourceName())
+	if err != nil {
+		return nil, err
+	}
+
+	return sqldb.NewAccess(dialect{}, db, transactional)
+}
+
+func (s *sqlite) NewSnapshotAccess() (sqldb.SqlAccess, error) {
+	db, err := sql.Open(s.connection.DriverName(), s.sourceName())
+	if err != nil {
+		return nil, err
+	}
+
+	return sqldb.NewSnapshotAccess(dialect{}, db)
+}
+
+func (s *sqlite) NewClusterAccess(transactional bool, readOnly bool) (sqldb.SqlAccess, error) {
+	if s.router == nil {
+		return s.NewAccess(transactional)
+	}
+	// the transactions are routed to the primary
+	db, err := sql.Open(s.connection.DriverName(), s.router.SourceName(readOnly && !transactional))
+	if err != nil {
+		return nil, err
+	}
+
+	return sqldb.NewAccess(dialect{}, db, transactional)
+}
+
+// sourceName returns the source name of the primary, which is the current healthy host if failover
+func (s *sqlite) sourceName() string {
+	if s.router != nil && s.router.Failover() {
+		return s.router.SourceName(false)
+	}
+
+	return s.connection.SourceName()
+}
+
+// Router returns the router of replicas, nil if no replicas
+func (s *sqlite) Router() *sqldb.SqlRouter {
+	return s.router
+}
+
+// readAccess returns the access of a replica if routed and ReadReplica is in filters, or the primary
+func (s *sqlite) readAccess(filters []sqldb.SqlFilter) (sqldb.SqlAccess, error) {
+	if s.router == nil || !sqldb.IsReadReplica(filters) {
+		return s.NewAccess(false)
+	}
+
+	return s.NewClusterAccess(false, true)
+}
+
+func (s *sqlite) NewEntity() sqldb.SqlEntity {
+	return sqldb.NewEntity(dialect{})
+}
+
+func (s *sqlite) NewBuilder() sqldb.SqlBuilder {
+	return sqldb.NewBuilder(dialect{})
+}
+
+func (s *sqlite) NewFilter(entity interface{}, fieldOr, groupOr bool) sqldb.SqlFilter {
+	return sqldb.NewFilter(entity, fieldOr, groupOr)
+}
+
+func (s *sqlite) QueryResult(query string, args ...interface{}) (*sqldb.SqlSelectResult, error) {
+	sqlAccess, err := s.NewAccess(false)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.QueryResult(query, args...)
+}
+
+func (s *sqlite) QueryResultLimit(limit uint64, query string, args ...interface{}) (*sqldb.SqlSelectResult, error) {
+	sqlAccess, err := s.NewAccess(false)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.QueryResultLimit(limit, query, args...)
+}
+
+func (s *sqlite) IsNoRows(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	return dialect{}.IsNoRows(err)
+}
+
+func (s *sqlite) Insert(entity interface{}) (uint64, error) {
+	sqlAccess, err := s.NewAccess(false)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.Insert(entity)
+}
+
+func (s *sqlite) InsertSelective(entity interface{}) (uint64, error) {
+	sqlAccess, err := s.NewAccess(false)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.InsertSelective(entity)
+}
+
+func (s *sqlite) Delete(entity interface{}, filters ...sqldb.SqlFilter) (uint64, error) {
+	sqlAccess, err := s.NewAccess(false)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.Delete(entity, filters...)
+}
+
+func (s *sqlite) HardDelete(entity interface{}, filters ...sqldb.SqlFilter) (uint64, error) {
+	sqlAccess, err := s.NewAccess(false)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.HardDelete(entity, filters...)
+}
+
+func (s *sqlite) Update(entity interface{}, filters ...sqldb.SqlFilter) (uint64, error) {
+	sqlAccess, err := s.NewAccess(false)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.Update(entity, filters...)
+}
+
+func (s *sqlite) UpdateSelective(entity interface{}, filters ...sqldb.SqlFilter) (uint64, error) {
+	sqlAccess, err := s.NewAccess(false)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.UpdateSelective(entity, filters...)
+}
+
+func (s *sqlite) UpdateByPrimaryKey(entity interface{}) (uint64, error) {
+	sqlAccess, err := s.NewAccess(false)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.UpdateByPrimaryKey(entity)
+}
+
+func (s *sqlite) UpdateSelectiveByPrimaryKey(entity interface{}) (uint64, error) {
+	sqlAccess, err := s.NewAccess(false)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.UpdateSelectiveByPrimaryKey(entity)
+}
+
+func (s *sqlite) UpdateFields(entity interface{}, columns []string, filters ...sqldb.SqlFilter) (uint64, error) {
+	sqlAccess, err := s.NewAccess(false)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.UpdateFields(entity, columns, filters...)
+}
+
+func (s *sqlite) UpdateMap(entity interface{}, values map[string]interface{}, filters ...sqldb.SqlFilter) (uint64, error) {
+	sqlAccess, err := s.NewAccess(false)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.UpdateMap(entity, values, filters...)
+}
+
+func (s *sqlite) SelectOne(entity interface{}, filters ...sqldb.SqlFilter) error {
+	sqlAccess, err := s.readAccess(filters)
+	if err != nil {
+		return err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.SelectOne(entity, filters...)
+}
+
+func (s *sqlite) SelectDistinct(entity interface{}, row func(index uint64, evt sqldb.SqlEvent), order interface{}, filters ...sqldb.SqlFilter) error {
+	sqlAccess, err := s.readAccess(filters)
+	if err != nil {
+		return err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.SelectDistinct(entity, row, order, filters...)
+}
+
+func (s *sqlite) SelectList(entity interface{}, row func(index uint64, evt sqldb.SqlEvent), order interface{}, filters ...sqldb.SqlFilter) error {
+	sqlAccess, err := s.readAccess(filters)
+	if err != nil {
+		return err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.SelectList(entity, row, order, filters...)
+}
+
+func (s *sqlite) SelectPage(entity interface{}, page func(total, page, size, index uint64), row func(index uint64, evt sqldb.SqlEvent), size, index uint64, order interface{}, filters ...sqldb.SqlFilter) error {
+	sqlAccess, err := s.readAccess(filters)
+	if err != nil {
+		return err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.SelectPage(entity, page, row, size, index, order, filters...)
+}
+
+// SelectRows opens an access for the rows, which is closed with the rows
+func (s *sqlite) SelectRows(entity interface{}, order interface{}, filters ...sqldb.SqlFilter) (sqldb.SqlRows, error) {
+	sqlAccess, err := s.readAccess(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := sqlAccess.SelectRows(entity, order, filters...)
+	if err != nil {
+		sqlAccess.Close()
+		return nil, err
+	}
+
+	return sqldb.CloseWithRows(rows, sqlAccess), nil
+}
+
+func (s *sqlite) ImportTable(table *sqldb.SqlTable, r io.Reader, options *sqldb.ImportOptions) (*sqldb.ImportResult, error) {
+	columns, err := s.Columns(table)
+	if err != nil {
+		return nil, err
+	}
+	sqlAccess, err := s.NewAccess(true)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlAccess.Close()
+
+	result, err := sqlAccess.ImportTable(table, columns, r, options)
+	if err == nil {
+		err = sqlAccess.Commit()
+	}
+	if err != nil && result != nil {
+		// the inserted rows are rolled back
+		result.Inserted = 0
+	}
+
+	return result, err
+}
+
+func (s *sqlite) ImportEntity(entity interface{}, r io.Reader, options *sqldb.ImportOptions) (*sqldb.ImportResult, error) {
+	sqlAccess, err := s.NewAccess(true)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlAccess.Close()
+
+	result, err := sqlAccess.ImportEntity(entity, r, options)
+	if err == nil {
+		err = sqlAccess.Commit()
+	}
+	if err != nil && result != nil {
+		// the inserted rows are rolled back
+		result.Inserted = 0
+	}
+
+	return result, err
+}
+
+func (s *sqlite) SelectCount(entity interface{}, filters ...sqldb.SqlFilter) (uint64, error) {
+	sqlAccess, err := s.readAccess(filters)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlAccess.Close()
+
+	return sqlAccess.SelectCount(entity, filters...)
+}
@@ -0,0 +1,492 @@
+package sqlite
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/csby/database/sqldb"
+	"github.com/csby/database/sqldb/sqltest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSqlite_Conformance(t *testing.T) {
+	suite := &sqltest.Suite{
+		Database: NewDatabase(testConnection(t)),
+		Setup: []string{
+			"DROP TABLE IF EXISTS `sqltest_item`",
+			"CREATE TABLE `sqltest_item` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT, " +
+				"`code` VARCHAR(32) NOT NULL, " +
+				"`name` VARCHAR(64) NOT NULL DEFAULT '', " +
+				"`score` INTEGER NOT NULL DEFAULT 0, " +
+				"`deleted_at` DATETIME NULL, " +
+				"`version` INTEGER NOT NULL DEFAULT 0, " +
+				"`created_at` DATETIME NULL, " +
+				"`updated_at` DATETIME NULL, " +
+				"`created_by` VARCHAR(32) NOT NULL DEFAULT '', " +
+				"`updated_by` VARCHAR(32) NOT NULL DEFAULT '', " +
+				"`attrs` TEXT NULL)",
+			"DROP TABLE IF EXISTS `sqltest_tag`",
+			"CREATE TABLE `sqltest_tag` (" +
+				"`id` INTEGER PRIMARY KEY AUTOINCREMENT, " +
+				"`item_id` INTEGER NOT NULL, " +
+				"`label` VARCHAR(32) NOT NULL DEFAULT '')",
+		},
+	}
+
+	suite.Run(t)
+}
+
+func TestSqlite_Test(t *testing.T) {
+	db := NewDatabase(testConnection(t))
+
+	dbVer, err := db.Test()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("version: ", dbVer)
+}
+
+func testConnection(t *testing.T) *Connection {
+	return &Connection{
+		File: filepath.Join(t.TempDir(), "sqltest.db"),
+	}
+}
+
+func TestSqlite_CopyTables(t *testing.T) {
+	source := NewDatabase(testConnection(t))
+	target := NewDatabase(&Connection{File: filepath.Join(t.TempDir(), "target.db")})
+
+	sqlAccess, err := source.NewAccess(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlAccess.Close()
+	for _, query := range []string{
+		"CREATE TABLE `product` (" +
+			"`id` INTEGER PRIMARY KEY AUTOINCREMENT, " +
+			"`code` VARCHAR(32) NOT NULL, " +
+			"`price` DECIMAL(10,2) NULL, " +
+			"`weight` REAL NULL, " +
+			"`enabled` BOOLEAN NOT NULL DEFAULT 1, " +
+			"`created_at` DATETIME NULL, " +
+			"`image` BLOB NULL)",
+		"CREATE TABLE `product_log` (`product_id` INTEGER NOT NULL, `message` TEXT NULL)",
+	} {
+		_, err = sqlAccess.Exec(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	at := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	for i := 1; i <= 5; i++ {
+		_, err = sqlAccess.Exec("INSERT INTO `product` (`code`, `price`, `weight`, `enabled`, `created_at`, `image`) VALUES (?, ?, ?, ?, ?, ?)",
+			fmt.Sprintf("P%02d", i), float64(i)+0.25, float64(i)/3, i%2 == 0, at.AddDate(0, 0, i), []byte{0xff, byte(i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = sqlAccess.Exec("INSERT INTO `product_log` (`product_id`, `message`) VALUES (?, ?)", i, fmt.Sprint("created ", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	options := &sqldb.CopyOptions{
+		CreateTable: true,
+		BatchSize:   2,
+		Checkpoint:  filepath.Join(t.TempDir(), "copy.json"),
+		Verify:      true,
+	}
+	results, err := sqldb.CopyTables(source, target, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expect 2 tables, actual %d", len(results))
+	}
+	for _, result := range results {
+		if !result.Created || result.Rows != 5 || !result.Verified || result.TargetRows != 5 {
+			t.Errorf("unexpected result %+v", result)
+		}
+	}
+
+	targetAccess, err := target.NewAccess(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer targetAccess.Close()
+	var code string
+	var image []byte
+	err = targetAccess.QueryRow("SELECT `code`, `image` FROM `product` WHERE `id` = 3").Scan(&code, &image)
+	if err != nil || code != "P03" || len(image) != 2 || image[1] != 3 {
+		t.Errorf("unexpected product: %s %v %v", code, image, err)
+	}
+
+	// resumed from checkpoint, all tables are done
+	results, err = sqldb.CopyTables(source, target, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		if !result.Resumed || result.Created || result.Rows != 0 || !result.Verified {
+			t.Errorf("unexpected resumed result %+v", result)
+		}
+	}
+
+	_, err = targetAccess.Exec("UPDATE `product` SET `code` = 'X03' WHERE `id` = 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = sqldb.CopyTables(source, target, &sqldb.CopyOptions{SkipData: true, Verify: true})
+	if !errors.Is(err, sqldb.ErrCopyVerify) {
+		t.Errorf("ErrCopyVerify expected, actual %v", err)
+	}
+}
+
+func TestSqlite_Dump(t *testing.T) {
+	source := NewDatabase(testConnection(t))
+	sqlAccess, err := source.NewAccess(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlAccess.Close()
+	for _, query := range []string{
+		"CREATE TABLE `product` (" +
+			"`id` INTEGER PRIMARY KEY AUTOINCREMENT, " +
+			"`code` VARCHAR(32) NOT NULL, " +
+			"`price` DECIMAL(10,2) NULL, " +
+			"`created_at` DATETIME NULL, " +
+			"`image` BLOB NULL)",
+		"CREATE TABLE `product_log` (`product_id` INTEGER NOT NULL, `message` TEXT NULL)",
+		"CREATE VIEW `product_view` AS SELECT `id`, `code`, 'a;\nb' AS `mark` FROM `product`",
+	} {
+		_, err = sqlAccess.Exec(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	at := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	for i := 1; i <= 5; i++ {
+		_, err = sqlAccess.Exec("INSERT INTO `product` (`code`, `price`, `created_at`, `image`) VALUES (?, ?, ?, ?)",
+			fmt.Sprintf("P'%02d", i), float64(i)+0.25, at.AddDate(0, 0, i), []byte{0xff, byte(i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = sqlAccess.Exec("INSERT INTO `product_log` (`product_id`, `message`) VALUES (?, ?)", i, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, data := range []string{sqldb.DumpNdjson, sqldb.DumpInsert} {
+		archive := &bytes.Buffer{}
+		err = sqldb.Dump(source, archive, &sqldb.DumpOptions{Data: data, BatchSize: 2, Exclude: []string{"*_LOG"}})
+		if err != nil {
+			t.Fatal(data, err)
+		}
+
+		target := NewDatabase(&Connection{File: filepath.Join(t.TempDir(), "target.db")})
+		err = sqldb.Restore(target, bytes.NewReader(archive.Bytes()), nil)
+		if err != nil {
+			t.Fatal(data, err)
+		}
+		tables, err := target.Tables()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tables) != 1 || tables[0].Name != "product" {
+			t.Errorf("%s: unexpected tables %v", data, tables)
+		}
+
+		targetAccess, err := target.NewAccess(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var count int
+		var code string
+		var image []byte
+		err = targetAccess.QueryRow("SELECT COUNT(*) FROM `product_view`").Scan(&count)
+		if err != nil || count != 5 {
+			t.Errorf("%s: unexpected count %d %v", data, count, err)
+		}
+		err = targetAccess.QueryRow("SELECT `code`, `image` FROM `product` WHERE `id` = 3").Scan(&code, &image)
+		if err != nil || code != "P'03" || len(image) != 2 || image[1] != 3 {
+			t.Errorf("%s: unexpected product: %s %v %v", data, code, image, err)
+		}
+		targetAccess.Close()
+
+		// existing tables
+		err = sqldb.Restore(target, bytes.NewReader(archive.Bytes()), nil)
+		if err == nil || !strings.Contains(err.Error(), "already exists") {
+			t.Errorf("%s: expect already exists error, actual %v", data, err)
+		}
+		err = sqldb.Restore(target, bytes.NewReader(archive.Bytes()), &sqldb.RestoreOptions{DropExisting: true})
+		if err != nil {
+			t.Errorf("%s: restore with drop existing fail: %v", data, err)
+		}
+		targetAccess, err = target.NewAccess(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = targetAccess.QueryRow("SELECT COUNT(*) FROM `product_view`").Scan(&count)
+		if err != nil || count != 5 {
+			t.Errorf("%s: unexpected count %d after drop existing %v", data, count, err)
+		}
+		targetAccess.Close()
+
+		// truncated archive
+		lines := bytes.Split(bytes.TrimSpace(archive.Bytes()), []byte("\n"))
+		truncated := bytes.Join(lines[:len(lines)-1], []byte("\n"))
+		target = NewDatabase(&Connection{File: filepath.Join(t.TempDir(), "truncated.db")})
+		err = sqldb.Restore(target, bytes.NewReader(truncated), nil)
+		if !errors.Is(err, sqldb.ErrDumpTruncated) {
+			t.Errorf("%s: expect truncated error, actual %v", data, err)
+		}
+	}
+}
+
+func TestSqlite_DiffTable(t *testing.T) {
+	source := NewDatabase(testConnection(t))
+	target := NewDatabase(&Connection{File: filepath.Join(t.TempDir(), "target.db")})
+	for _, db := range []sqldb.SqlDatabase{source, target} {
+		sqlAccess, err := db.NewAccess(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = sqlAccess.Exec("CREATE TABLE `product` (`id` INTEGER PRIMARY KEY, `code` VARCHAR(32) NOT NULL, `price` DECIMAL(10,2) NULL)")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 1; i <= 6; i++ {
+			_, err = sqlAccess.Exec("INSERT INTO `product` (`id`, `code`, `price`) VALUES (?, ?, ?)", i, fmt.Sprintf("P%02d", i), float64(i)+0.5)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		sqlAccess.Close()
+	}
+
+	table := &sqldb.SqlTable{Name: "product"}
+	result, err := sqldb.DiffTable(source, target, table, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Equal() || result.SourceRows != 6 || result.TargetRows != 6 {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	targetAccess, err := target.NewAccess(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer targetAccess.Close()
+	for _, query := range []string{
+		"DELETE FROM `product` WHERE `id` = 2",
+		"UPDATE `product` SET `price` = NULL WHERE `id` = 4",
+		"INSERT INTO `product` (`id`, `code`, `price`) VALUES (9, 'P''09', 9)",
+	} {
+		_, err = targetAccess.Exec(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	script := &bytes.Buffer{}
+	result, err = sqldb.DiffTable(source, target, table, &sqldb.DiffOptions{Script: script})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Inserted != 1 || result.Deleted != 1 || result.Changed != 1 || len(result.Rows) != 3 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	for _, row := range result.Rows {
+		switch row.Kind {
+		case sqldb.DiffInsert:
+			if row.Key[0] != int64(2) {
+				t.Errorf("unexpected inserted row %v", row)
+			}
+		case sqldb.DiffDelete:
+			if row.Key[0] != int64(9) {
+				t.Errorf("unexpected deleted row %v", row)
+			}
+		case sqldb.DiffUpdate:
+			if row.Key[0] != int64(4) || len(row.Columns) != 1 || row.Columns[0] != "price" {
+				t.Errorf("unexpected updated row %v", row)
+			}
+		}
+	}
+
+	// reconciled by the script
+	for _, statement := range strings.Split(strings.TrimSpace(script.String()), ";\n") {
+		_, err = targetAccess.Exec(statement)
+		if err != nil {
+			t.Fatal(statement, err)
+		}
+	}
+	result, err = sqldb.DiffTable(source, target, table, &sqldb.DiffOptions{Columns: []string{"Price"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Equal() || len(result.Columns) != 2 {
+		t.Errorf("unexpected result after reconciled %+v", result)
+	}
+}
+
+type testClusterConnection struct {
+	*Connection
+	replicas []string
+}
+
+func (s *testClusterConnection) ReplicaSourceNames() []string {
+	return s.replicas
+}
+
+func (s *testClusterConnection) RoutingOptions() *sqldb.SqlRouting {
+	return nil
+}
+
+func TestSqlite_Router(t *testing.T) {
+	folder := t.TempDir()
+	replica := &Connection{File: filepath.Join(folder, "replica.db")}
+	missing := &Connection{File: filepath.Join(folder, "missing", "replica.db")}
+	connection := &testClusterConnection{
+		Connection: &Connection{File: filepath.Join(folder, "primary.db")},
+		replicas:   []string{replica.ClusterSourceName(true), missing.ClusterSourceName(true)},
+	}
+	for _, c := range []*Connection{connection.Connection, replica} {
+		sqlAccess, err := NewDatabase(c).NewAccess(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = sqlAccess.Exec("CREATE TABLE `product` (`id` INTEGER PRIMARY KEY, `code` VARCHAR(32) NOT NULL)")
+		if err != nil {
+			t.Fatal(err)
+		}
+		sqlAccess.Close()
+	}
+
+	db := NewDatabase(connection)
+	_, err := db.Insert(&testProduct{ID: 1, Code: "P01"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, err := db.SelectCount(&testProduct{})
+	if err != nil || count != 1 {
+		t.Errorf("expect select from the primary by default, actual %d rows %v", count, err)
+	}
+	count, err = db.SelectCount(&testProduct{}, sqldb.ReadReplica())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("expect select from the empty replica, actual %d rows", count)
+	}
+	result, err := db.QueryResult("SELECT `id` FROM `product`")
+	if err != nil || len(result.Rows) != 1 {
+		t.Errorf("expect query result from the primary, actual %v", err)
+	}
+
+	// transactions are routed to the primary
+	sqlAccess, err := db.NewClusterAccess(true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, err = sqlAccess.SelectCount(&testProduct{})
+	sqlAccess.Close()
+	if err != nil || count != 1 {
+		t.Errorf("expect transaction in primary, actual %d rows %v", count, err)
+	}
+
+	status := db.(sqldb.SqlRouted).Router().Topology().Replicas
+	if len(status) != 2 || !status[0].Healthy || status[1].Healthy || status[1].Error == "" {
+		t.Errorf("unexpected status %v %v", status[0], status[1])
+	}
+}
+
+type testProduct struct {
+	testProductBase
+
+	ID   uint64 `sql:"id" primary:"true" index:"1"`
+	Code string `sql:"code" index:"2"`
+}
+
+type testProductBase struct {
+}
+
+func (s testProductBase) TableName() string {
+	return "product"
+}
+
+type testFailoverConnection struct {
+	*Connection
+	hosts []string
+}
+
+func (s *testFailoverConnection) FailoverSourceNames() []string {
+	return append([]string{s.SourceName()}, s.hosts...)
+}
+
+func (s *testFailoverConnection) RoutingOptions() *sqldb.SqlRouting {
+	return &sqldb.SqlRouting{CheckInterval: 1}
+}
+
+func TestSqlite_Failover(t *testing.T) {
+	folder := t.TempDir()
+	backup := &Connection{File: filepath.Join(folder, "backup.db")}
+	connection := &testFailoverConnection{
+		Connection: &Connection{File: filepath.Join(folder, "missing", "primary.db")},
+		hosts:      []string{backup.SourceName()},
+	}
+
+	db := NewDatabase(connection)
+	router := db.(sqldb.SqlRouted).Router()
+	router.Start()
+	defer router.Stop()
+	_, err := db.Test()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlAccess, err := db.NewAccess(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = sqlAccess.Exec("CREATE TABLE `product` (`id` INTEGER PRIMARY KEY, `code` VARCHAR(32) NOT NULL)")
+	sqlAccess.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Insert(&testProduct{ID: 1, Code: "P01"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := NewDatabase(backup).SelectCount(&testProduct{})
+	if err != nil || count != 1 {
+		t.Errorf("expect failover to backup, actual %d rows %v", count, err)
+	}
+	topology := router.Topology()
+	if topology.Primary != 1 || len(topology.Primaries) != 2 || topology.Primaries[0].Healthy || !topology.Primaries[1].Writable {
+		t.Errorf("unexpected topology %+v", topology)
+	}
+}
+
+func TestConnection_ParseDSN(t *testing.T) {
+	connection := &Connection{
+		File:    filepath.Join("data", "test.db"),
+		Timeout: 3,
+		Params:  map[string]string{"_foreign_keys": "1"},
+	}
+
+	parsed, err := ParseDSN(connection.SourceName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, connection) {
+		t.Errorf("expect %+v, actual %+v", connection, parsed)
+	}
+}
@@ -0,0 +1,76 @@
+package sqltest
+
+import (
+	"context"
+	"github.com/csby/database/sqldb"
+	"testing"
+	"time"
+)
+
+func (s *Suite) testAudit(t *testing.T) {
+	s.reset(t)
+
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)
+	now := created
+	sqldb.Now = func() time.Time {
+		return now
+	}
+	defer func() {
+		sqldb.Now = time.Now
+	}()
+
+	repository := sqldb.NewRepository[AuditedItem](s.Database)
+	item := &AuditedItem{Item: Item{Code: "D01", Name: "date"}}
+	id, err := repository.Insert(sqldb.WithUser(context.Background(), "alice"), item)
+	if err != nil {
+		t.Fatal("insert fail:", err)
+	}
+	if item.CreatedAt == nil || !item.CreatedAt.Equal(created) || item.UpdatedAt == nil || !item.UpdatedAt.Equal(created) {
+		t.Errorf("insert: expect created and updated time=%v, actual=%v, %v", created, item.CreatedAt, item.UpdatedAt)
+	}
+	if item.CreatedBy != "alice" || item.UpdatedBy != "alice" {
+		t.Errorf("insert: expect created and updated by alice, actual=%s, %s", item.CreatedBy, item.UpdatedBy)
+	}
+
+	now = created.Add(time.Hour)
+	item.Score = 10
+	item.CreatedAt = nil
+	item.CreatedBy = ""
+	_, err = repository.Update(sqldb.WithUser(context.Background(), "bob"), item)
+	if err != nil {
+		t.Fatal("update fail:", err)
+	}
+	actual, err := repository.Get(context.Background(), id)
+	if err != nil {
+		t.Fatal("get fail:", err)
+	}
+	if actual.CreatedAt == nil || actual.CreatedAt.Unix() != created.Unix() || actual.CreatedBy != "alice" {
+		t.Errorf("update: created should be kept, actual=%v, %s", actual.CreatedAt, actual.CreatedBy)
+	}
+	if actual.UpdatedAt == nil || actual.UpdatedAt.Unix() != now.Unix() || actual.UpdatedBy != "bob" {
+		t.Errorf("update: expect updated time=%v by bob, actual=%v by %s", now, actual.UpdatedAt, actual.UpdatedBy)
+	}
+
+	now = created.Add(2 * time.Hour)
+	selective := &AuditedItem{Item: Item{ID: id, Score: 20}}
+	_, err = s.Database.UpdateSelectiveByPrimaryKey(selective)
+	if err != nil {
+		t.Fatal("update selective fail:", err)
+	}
+	actual, err = repository.Get(context.Background(), id)
+	if err != nil {
+		t.Fatal("get fail:", err)
+	}
+	if actual.UpdatedAt == nil || actual.UpdatedAt.Unix() != now.Unix() || actual.UpdatedBy != "bob" || actual.Code != "D01" {
+		t.Errorf("update selective: expect updated time=%v by bob, actual=%+v", now, actual)
+	}
+
+	imported := &AuditedItem{Item: Item{Code: "D02"}, CreatedAt: &created, CreatedBy: "carol"}
+	_, err = repository.Insert(sqldb.WithUser(context.Background(), "alice"), imported)
+	if err != nil {
+		t.Fatal("insert imported fail:", err)
+	}
+	if !imported.CreatedAt.Equal(created) || imported.CreatedBy != "carol" || imported.UpdatedBy != "alice" {
+		t.Errorf("insert imported: created should be kept, actual=%+v", imported)
+	}
+}
@@ -0,0 +1,67 @@
+package sqltest
+
+import (
+	"github.com/csby/database/sqldb"
+	"reflect"
+	"testing"
+)
+
+func (s *Suite) testConverter(t *testing.T) {
+	s.reset(t)
+
+	item := &ConvertedItem{
+		Code:  "D01",
+		Words: []string{"dragon", "fruit"},
+		Level: "middle",
+		Attrs: map[string]string{"color": "red"},
+	}
+	id, err := s.Database.Insert(item)
+	if err != nil {
+		t.Fatal("insert fail:", err)
+	}
+	item.ID = id
+
+	raw := s.get(t, id)
+	if raw == nil || raw.Name != "dragon fruit" || raw.Score != 75 {
+		t.Errorf("insert: expect name='dragon fruit', score=75, actual=%+v", raw)
+	}
+
+	actual := &ConvertedItem{}
+	err = s.Database.SelectOne(actual, s.Database.NewFilter(&ItemIdFilter{ID: id}, false, false))
+	if err != nil {
+		t.Fatal("select one fail:", err)
+	}
+	if !reflect.DeepEqual(item, actual) {
+		t.Errorf("select one: expect=%+v, actual=%+v", item, actual)
+	}
+
+	levels := make(map[string]string)
+	dbEntity := &ConvertedItem{}
+	err = s.Database.SelectList(dbEntity, func(index uint64, evt sqldb.SqlEvent) {
+		levels[dbEntity.Code] = dbEntity.Level
+		if dbEntity.Code != "D01" && dbEntity.Attrs != nil {
+			t.Errorf("select list: attributes of %s should be nil, actual=%v", dbEntity.Code, dbEntity.Attrs)
+		}
+	}, nil, s.Database.NewFilter(&ItemCodeFilter{Code: "B01"}, false, true), s.Database.NewFilter(&ItemIdFilter{ID: id}, false, true))
+	if err != nil {
+		t.Fatal("select list fail:", err)
+	}
+	if levels["B01"] != "high" || levels["D01"] != "middle" {
+		t.Errorf("select list: unexpected levels %v", levels)
+	}
+
+	// the values of filter are converted as written
+	count, err := s.Database.SelectCount(&ConvertedItem{}, s.Database.NewFilter(&ItemLevelFilter{Level: "middle"}, false, false))
+	if err != nil || count != 2 {
+		t.Errorf("select count by level: expect 2 (A02 and D01), actual=%d, %v", count, err)
+	}
+	_, err = s.Database.SelectCount(&ConvertedItem{}, s.Database.NewFilter(&ItemLevelFilter{Level: "unknown"}, false, false))
+	if err == nil {
+		t.Error("filter by invalid enum should be error")
+	}
+
+	_, err = s.Database.Insert(&ConvertedItem{Code: "D02", Level: "unknown"})
+	if err == nil {
+		t.Error("insert invalid enum should be error")
+	}
+}
@@ -0,0 +1,73 @@
+package sqltest
+
+import (
+	"github.com/csby/database/sqldb"
+	"strings"
+	"testing"
+)
+
+func (s *Suite) testEncrypt(t *testing.T) {
+	s.reset(t)
+
+	provider := &sqldb.StaticKeyProvider{
+		Current: "k1",
+		Keys: map[string][]byte{
+			"k1": []byte("0123456789abcdef0123456789abcdef"),
+		},
+	}
+	sqldb.SetKeyProvider(provider)
+	defer sqldb.SetKeyProvider(nil)
+
+	item := &EncryptedItem{Code: "D01", Secret: "id-110101199001011234"}
+	id, err := s.Database.Insert(item)
+	if err != nil {
+		t.Fatal("insert fail:", err)
+	}
+	filter := s.Database.NewFilter(&ItemIdFilter{ID: id}, false, false)
+
+	raw := &ItemSecret{}
+	err = s.Database.SelectOne(raw, filter)
+	if err != nil {
+		t.Fatal("select one fail:", err)
+	}
+	if !strings.HasPrefix(raw.Secret, "k1:") || strings.Contains(raw.Secret, item.Secret) {
+		t.Errorf("saved secret should be encrypted, actual=%s", raw.Secret)
+	}
+
+	provider.Current = "k2"
+	provider.Keys["k2"] = []byte("fedcba9876543210")
+
+	actual := &EncryptedItem{}
+	err = s.Database.SelectOne(actual, filter)
+	if err != nil {
+		t.Fatal("select one fail:", err)
+	}
+	if actual.Secret != item.Secret {
+		t.Errorf("decrypted: expect=%s, actual=%s", item.Secret, actual.Secret)
+	}
+
+	_, err = s.Database.UpdateByPrimaryKey(actual)
+	if err != nil {
+		t.Fatal("update fail:", err)
+	}
+	err = s.Database.SelectOne(raw, filter)
+	if err != nil {
+		t.Fatal("select one fail:", err)
+	}
+	if !strings.HasPrefix(raw.Secret, "k2:") {
+		t.Errorf("updated secret should be encrypted by k2, actual=%s", raw.Secret)
+	}
+
+	// the encrypted values are not comparable, as the nonces are random
+	_, err = s.Database.SelectCount(&EncryptedItem{}, s.Database.NewFilter(&ItemSecretFilter{Secret: item.Secret}, false, false))
+	if err == nil {
+		t.Error("filter by encrypted field should be error")
+	}
+
+	// the values are encrypted on writing only, so reading by the entity holding a secret needs no key
+	sqldb.SetKeyProvider(nil)
+	count, err := s.Database.SelectCount(actual, filter)
+	if err != nil || count != 1 {
+		t.Errorf("select count without key provider: expect 1, actual=%d, %v", count, err)
+	}
+}
@@ -0,0 +1,205 @@
+package sqltest
+
+import (
+	"errors"
+	"fmt"
+	"github.com/csby/database/sqldb"
+	"strings"
+	"time"
+)
+
+const (
+	TableName    = "sqltest_item"
+	TagTableName = "sqltest_tag"
+)
+
+var (
+	ErrCodeEmpty = errors.New("code is empty")
+)
+
+type ItemBase struct {
+}
+
+func (s ItemBase) TableName() string {
+	return TableName
+}
+
+type Item struct {
+	ItemBase
+
+	ID    uint64 `sql:"id" auto:"true" primary:"true" index:"1"`
+	Code  string `sql:"code" index:"2"`
+	Name  string `sql:"name" index:"3"`
+	Score int64  `sql:"score" index:"4"`
+}
+
+// SoftItem is the soft deleted view of Item, deleted_at is NULL unless deleted
+type SoftItem struct {
+	Item
+
+	DeletedAt *time.Time `sql:"deleted_at" softdelete:"true" index:"5"`
+}
+
+// VersionedItem is the optimistic locking view of Item
+type VersionedItem struct {
+	Item
+
+	Version int64 `sql:"version" version:"true" index:"6"`
+}
+
+// AuditedItem is the audit view of Item
+type AuditedItem struct {
+	Item
+
+	CreatedAt *time.Time `sql:"created_at" created:"true" index:"7"`
+	UpdatedAt *time.Time `sql:"updated_at" updated:"true" index:"8"`
+	CreatedBy string     `sql:"created_by" createdBy:"true" index:"9"`
+	UpdatedBy string     `sql:"updated_by" updatedBy:"true" index:"10"`
+}
+
+// HookedItem records the calls of the lifecycle hooks, and rejects empty code on inserting
+type HookedItem struct {
+	Item
+
+	Label      string `sql:"-"`
+	calls      []string
+	insertedID uint64 // the id seen by AfterInsert
+}
+
+func (s *HookedItem) BeforeInsert(sqlAccess sqldb.SqlAccess) error {
+	s.calls = append(s.calls, "BeforeInsert")
+	if s.Code == "" {
+		return ErrCodeEmpty
+	}
+	if s.Name == "" {
+		s.Name = strings.ToLower(s.Code)
+	}
+
+	return nil
+}
+
+func (s *HookedItem) AfterInsert(sqlAccess sqldb.SqlAccess) error {
+	s.calls = append(s.calls, "AfterInsert")
+	s.insertedID = s.ID
+	return nil
+}
+
+func (s *HookedItem) BeforeUpdate(sqlAccess sqldb.SqlAccess) error {
+	s.calls = append(s.calls, "BeforeUpdate")
+	return nil
+}
+
+func (s *HookedItem) AfterUpdate(sqlAccess sqldb.SqlAccess) error {
+	s.calls = append(s.calls, "AfterUpdate")
+	return nil
+}
+
+func (s *HookedItem) BeforeDelete(sqlAccess sqldb.SqlAccess) error {
+	s.calls = append(s.calls, "BeforeDelete")
+	return nil
+}
+
+func (s *HookedItem) AfterScan(sqlAccess sqldb.SqlAccess) error {
+	s.calls = append(s.calls, "AfterScan")
+	s.Label = fmt.Sprintf("%s:%s", s.Code, s.Name)
+	return nil
+}
+
+// ConvertedItem saves the name as csv, the score as enum and the attributes as json
+type ConvertedItem struct {
+	ItemBase
+
+	ID    uint64            `sql:"id" auto:"true" primary:"true" index:"1"`
+	Code  string            `sql:"code" index:"2"`
+	Words []string          `sql:"name" conv:"csv: " index:"3"`
+	Level string            `sql:"score" conv:"enum:low=60,middle=75,high=90" index:"4"`
+	Attrs map[string]string `sql:"attrs" conv:"json" index:"5"`
+}
+
+// EncryptedItem saves the secret into attrs encrypted
+type EncryptedItem struct {
+	ItemBase
+
+	ID     uint64 `sql:"id" auto:"true" primary:"true" index:"1"`
+	Code   string `sql:"code" index:"2"`
+	Secret string `sql:"attrs" encrypt:"aes-gcm" index:"3"`
+}
+
+type ItemSecret struct {
+	ItemBase
+
+	ID     uint64 `sql:"id" auto:"true" primary:"true" index:"1"`
+	Secret string `sql:"attrs" index:"2"`
+}
+
+type ItemScore struct {
+	ItemBase
+
+	Score int64 `sql:"score"`
+}
+
+type Tag struct {
+	ID     uint64 `sql:"id" auto:"true" primary:"true" index:"1"`
+	ItemID uint64 `sql:"item_id" index:"2"`
+	Label  string `sql:"label" index:"3"`
+}
+
+func (s Tag) TableName() string {
+	return TagTableName
+}
+
+// ItemWithTags is Item with the tags referencing it
+type ItemWithTags struct {
+	Item
+
+	Tags []Tag `rel:"hasMany,fk=item_id"`
+	Tag  *Tag  `rel:"hasOne,fk=item_id"`
+}
+
+// TagWithItem is Tag with the item it belongs to
+type TagWithItem struct {
+	Tag
+
+	Item *Item `rel:"belongsTo,fk=item_id"`
+}
+
+type ItemIdFilter struct {
+	ID uint64 `sql:"id"`
+}
+
+type ItemIdsFilter struct {
+	ID []uint64 `sql:"id" filter:"in"`
+}
+
+type ItemCodeFilter struct {
+	Code string `sql:"code"`
+}
+
+type ItemNameFilter struct {
+	Name string `sql:"name" filter:"like"`
+}
+
+type ItemScoreFilter struct {
+	MinScore int64 `sql:"score" filter:">="`
+}
+
+// ItemLevelFilter filters the score by the enum of ConvertedItem
+type ItemLevelFilter struct {
+	Level string `sql:"score" conv:"enum:low=60,middle=75,high=90"`
+}
+
+type ItemSecretFilter struct {
+	Secret string `sql:"attrs" encrypt:"aes-gcm"`
+}
+
+type ItemCodeOrNameFilter struct {
+	Code string `sql:"code"`
+	Name string `sql:"name"`
+}
+
+type ItemOrder struct {
+	ItemBase
+
+	Score int64  `sql:"score" order:"DESC" index:"1"`
+	ID    uint64 `sql:"id" order:"ASC" index:"2"`
+}
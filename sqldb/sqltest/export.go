@@ -0,0 +1,44 @@
+package sqltest
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/csby/database/sqldb"
+	"strings"
+	"testing"
+)
+
+func (s *Suite) testExport(t *testing.T) {
+	s.reset(t)
+
+	rows, err := s.Database.SelectRows(&Item{}, &ItemOrder{}, sqldb.Only("code", "score"), s.Database.NewFilter(&ItemScoreFilter{MinScore: 85}, false, false))
+	if err != nil {
+		t.Fatal("select rows fail:", err)
+	}
+	buf := &bytes.Buffer{}
+	err = sqldb.ExportRows(buf, rows, &sqldb.ExportOptions{Format: sqldb.ExportCsv})
+	rows.Close()
+	if err != nil {
+		t.Fatal("export rows fail:", err)
+	}
+	expect := "code,score\nA01,90\nB01,90\nC01,85\n"
+	if buf.String() != expect {
+		t.Errorf("export rows: expect=%q, actual=%q", expect, buf.String())
+	}
+
+	sqlAccess, err := s.Database.NewAccess(false)
+	if err != nil {
+		t.Fatal("new access fail:", err)
+	}
+	defer sqlAccess.Close()
+	buf.Reset()
+	query := fmt.Sprintf("SELECT code, name FROM %s WHERE score < 80 ORDER BY code", TableName)
+	err = sqldb.ExportQuery(buf, sqlAccess, &sqldb.ExportOptions{Format: sqldb.ExportNdjson}, query)
+	if err != nil {
+		t.Fatal("export query fail:", err)
+	}
+	lines := strings.Split(strings.ToLower(strings.TrimSpace(buf.String())), "\n")
+	if len(lines) != 2 || lines[0] != `{"code":"a02","name":"apricot"}` || lines[1] != `{"code":"b02","name":"blueberry"}` {
+		t.Errorf("export query: unexpected lines %v", lines)
+	}
+}
@@ -0,0 +1,70 @@
+package sqltest
+
+import (
+	"errors"
+	"github.com/csby/database/sqldb"
+	"strings"
+	"testing"
+)
+
+func (s *Suite) testHook(t *testing.T) {
+	s.reset(t)
+
+	item := &HookedItem{}
+	_, err := s.Database.Insert(item)
+	if !errors.Is(err, ErrCodeEmpty) {
+		t.Errorf("insert rejected: expect %v, actual=%v", ErrCodeEmpty, err)
+	}
+
+	item = &HookedItem{Item: Item{Code: "D01"}}
+	id, err := s.Database.Insert(item)
+	if err != nil {
+		t.Fatal("insert fail:", err)
+	}
+	if strings.Join(item.calls, ",") != "BeforeInsert,AfterInsert" {
+		t.Errorf("insert: unexpected calls %v", item.calls)
+	}
+	if item.insertedID != id {
+		t.Errorf("after insert: expect id=%d, actual=%d", id, item.insertedID)
+	}
+
+	item = &HookedItem{}
+	err = s.Database.SelectOne(item, s.Database.NewFilter(&ItemIdFilter{ID: id}, false, false))
+	if err != nil {
+		t.Fatal("select one fail:", err)
+	}
+	if item.Label != "D01:d01" {
+		t.Errorf("select one: expect label=D01:d01, actual=%s", item.Label)
+	}
+
+	item.calls = nil
+	item.Score = 1
+	_, err = s.Database.UpdateByPrimaryKey(item)
+	if err != nil {
+		t.Fatal("update fail:", err)
+	}
+	if strings.Join(item.calls, ",") != "BeforeUpdate,AfterUpdate" {
+		t.Errorf("update: unexpected calls %v", item.calls)
+	}
+
+	labels := make([]string, 0)
+	dbEntity := &HookedItem{}
+	err = s.Database.SelectList(dbEntity, func(index uint64, evt sqldb.SqlEvent) {
+		labels = append(labels, dbEntity.Label)
+	}, &ItemOrder{}, s.Database.NewFilter(&ItemScoreFilter{MinScore: 90}, false, false))
+	if err != nil {
+		t.Fatal("select list fail:", err)
+	}
+	if strings.Join(labels, ",") != "A01:apple,B01:banana" {
+		t.Errorf("select list: unexpected labels %v", labels)
+	}
+
+	dbEntity.calls = nil
+	_, err = s.Database.Delete(dbEntity, s.Database.NewFilter(&ItemIdFilter{ID: id}, false, false))
+	if err != nil {
+		t.Fatal("delete fail:", err)
+	}
+	if strings.Join(dbEntity.calls, ",") != "BeforeDelete" {
+		t.Errorf("delete: unexpected calls %v", dbEntity.calls)
+	}
+}
@@ -0,0 +1,106 @@
+package sqltest
+
+import (
+	"errors"
+	"github.com/csby/database/sqldb"
+	"strings"
+	"testing"
+)
+
+func (s *Suite) testImport(t *testing.T) {
+	s.reset(t)
+	table := &sqldb.SqlTable{Name: TableName}
+
+	source := "code,name,score\nD01,date,70\nD02,,\nD03,durian,abc\n"
+	result, err := s.Database.ImportTable(table, strings.NewReader(source), nil)
+	if !errors.Is(err, sqldb.ErrImportFailed) {
+		t.Fatal("import table: ErrImportFailed expected, actual", err)
+	}
+	if result.Rows != 3 || len(result.Errors) != 2 || result.Errors[1].Row != 3 || result.Errors[1].Column != "score" {
+		t.Errorf("import table: unexpected result %d rows, errors %v", result.Rows, result.Errors)
+	}
+	if count := len(s.list(t, &ItemOrder{})); count != 5 {
+		t.Errorf("import table: rolled back expected, actual %d items", count)
+	}
+
+	// the inserted rows before too many errors are rolled back
+	result, err = s.Database.ImportTable(table, strings.NewReader(source), &sqldb.ImportOptions{SkipErrors: true, BatchSize: 1, MaxErrors: 1})
+	if !errors.Is(err, sqldb.ErrImportFailed) || result.Inserted != 0 {
+		t.Errorf("import table (max errors): ErrImportFailed and none inserted expected, actual %d inserted, %v", result.Inserted, err)
+	}
+	if count := len(s.list(t, &ItemOrder{})); count != 5 {
+		t.Errorf("import table (max errors): rolled back expected, actual %d items", count)
+	}
+
+	result, err = s.Database.ImportTable(table, strings.NewReader(source), &sqldb.ImportOptions{SkipErrors: true, BatchSize: 1})
+	if err != nil {
+		t.Fatal("import table (skip errors) fail:", err)
+	}
+	if result.Inserted != 1 || len(result.Errors) != 2 {
+		t.Errorf("import table (skip errors): expect 1 inserted and 2 errors, actual %d and %v", result.Inserted, result.Errors)
+	}
+	if !strings.EqualFold(result.Errors[0].Column, "score") || result.Errors[0].Message != "NULL not allowed" {
+		t.Errorf("import table (skip errors): NULL not allowed for the empty score expected, actual %v", result.Errors[0])
+	}
+	items := importedItems(s.list(t, &ItemOrder{}))
+	if len(items) != 6 || items["D01"].Score != 70 {
+		t.Errorf("import table (skip errors): unexpected items %v", items)
+	}
+
+	result, err = s.Database.ImportTable(table, strings.NewReader("label\nfig\n"), &sqldb.ImportOptions{Mapping: map[string]string{"label": "name"}})
+	if err == nil || len(result.Errors) != 1 || !strings.EqualFold(result.Errors[0].Column, "code") {
+		t.Errorf("import table: code required expected, actual %v", err)
+	}
+
+	result, err = s.Database.ImportTable(table, strings.NewReader("CODE;SCORE\nF01;1\nF02;2\nF03;3\n"), &sqldb.ImportOptions{Separator: ";"})
+	if err != nil {
+		t.Fatal("import table (batch) fail:", err)
+	}
+	if result.Inserted != 3 {
+		t.Errorf("import table (batch): expect 3 inserted, actual %d", result.Inserted)
+	}
+	items = importedItems(s.list(t, &ItemOrder{}))
+	if items["F01"].Name != "" || items["F01"].Score != 1 {
+		t.Errorf("import table (batch): default of the absent name expected, actual %v", items["F01"])
+	}
+
+	source = `{"code":"E01","label":"elderberry","score":88}` + "\n" + `{"code":"E02","score":"x"}` + "\n"
+	result, err = s.Database.ImportEntity(&Item{}, strings.NewReader(source), &sqldb.ImportOptions{
+		Format:     sqldb.ImportNdjson,
+		Mapping:    map[string]string{"label": "name"},
+		SkipErrors: true,
+	})
+	if err != nil {
+		t.Fatal("import entity fail:", err)
+	}
+	if result.Rows != 2 || result.Inserted != 1 || len(result.Errors) != 1 || result.Errors[0].Row != 2 {
+		t.Errorf("import entity: unexpected result %d rows, %d inserted, errors %v", result.Rows, result.Inserted, result.Errors)
+	}
+	items = importedItems(s.list(t, &ItemOrder{}))
+	if len(items) != 10 || items["E01"].Name != "elderberry" || items["E01"].Score != 88 {
+		t.Errorf("import entity: unexpected items %v", items)
+	}
+
+	// the malformed record of csv is an error of row, the others are imported
+	source = "code,name,score\nG01,g\"rape,1\nG02,guava,2\n"
+	result, err = s.Database.ImportTable(table, strings.NewReader(source), &sqldb.ImportOptions{SkipErrors: true})
+	if err != nil {
+		t.Fatal("import table (malformed) fail:", err)
+	}
+	if result.Rows != 2 || result.Inserted != 1 || len(result.Errors) != 1 || result.Errors[0].Row != 1 || !strings.Contains(result.Errors[0].Message, "line 2") {
+		t.Errorf("import table (malformed): unexpected result %d rows, %d inserted, errors %v", result.Rows, result.Inserted, result.Errors)
+	}
+	items = importedItems(s.list(t, &ItemOrder{}))
+	if _, ok := items["G01"]; ok || items["G02"].Name != "guava" {
+		t.Errorf("import table (malformed): unexpected items %v", items)
+	}
+}
+
+func importedItems(items []Item) map[string]Item {
+	codes := make(map[string]Item, len(items))
+	for _, item := range items {
+		codes[item.Code] = item
+	}
+
+	return codes
+}
@@ -0,0 +1,107 @@
+package sqltest
+
+import (
+	"github.com/csby/database/sqldb"
+	"strings"
+	"testing"
+)
+
+func (s *Suite) testPreload(t *testing.T) {
+	items := s.reset(t)
+
+	tags := []*Tag{
+		{ItemID: items[0].ID, Label: "sweet"},
+		{ItemID: items[2].ID, Label: "yellow"},
+		{ItemID: items[0].ID, Label: "red"},
+	}
+	for _, tag := range tags {
+		id, err := s.Database.Insert(tag)
+		if err != nil {
+			t.Fatal("insert tag fail:", err)
+		}
+		tag.ID = id
+	}
+
+	labels := make(map[string][]string)
+	firsts := make(map[string]string)
+	dbEntity := &ItemWithTags{}
+	err := s.Database.SelectList(dbEntity, func(index uint64, evt sqldb.SqlEvent) {
+		if dbEntity.Tags == nil {
+			t.Errorf("select list: tags of %s should not be nil", dbEntity.Code)
+		}
+		for _, tag := range dbEntity.Tags {
+			labels[dbEntity.Code] = append(labels[dbEntity.Code], tag.Label)
+		}
+		if dbEntity.Tag != nil {
+			firsts[dbEntity.Code] = dbEntity.Tag.Label
+		}
+	}, &ItemOrder{}, sqldb.Preload("Tags", "Tag"))
+	if err != nil {
+		t.Fatal("select list fail:", err)
+	}
+	// the tags are in the order of primary key
+	if len(labels) != 2 || strings.Join(labels["A01"], ",") != "sweet,red" || strings.Join(labels["B01"], ",") != "yellow" {
+		t.Errorf("select list: unexpected tags %v", labels)
+	}
+	if len(firsts) != 2 || firsts["A01"] != "sweet" || firsts["B01"] != "yellow" {
+		t.Errorf("select list: unexpected first tags %v", firsts)
+	}
+
+	pageCount := 0
+	err = s.Database.SelectPage(dbEntity, nil, func(index uint64, evt sqldb.SqlEvent) {
+		pageCount++
+		if dbEntity.Code == "A01" && len(dbEntity.Tags) != 2 {
+			t.Errorf("select page: expect 2 tags of A01, actual=%v", dbEntity.Tags)
+		}
+	}, 2, 1, &ItemOrder{}, sqldb.Preload("Tags"))
+	if err != nil {
+		t.Fatal("select page fail:", err)
+	}
+	if pageCount != 2 {
+		t.Errorf("select page: expect 2 rows, actual=%d", pageCount)
+	}
+
+	one := &ItemWithTags{}
+	err = s.Database.SelectOne(one, s.Database.NewFilter(&ItemIdFilter{ID: items[1].ID}, false, false), sqldb.Preload("Tags", "Tag"))
+	if err != nil {
+		t.Fatal("select one fail:", err)
+	}
+	if one.Tags == nil || len(one.Tags) != 0 || one.Tag != nil {
+		t.Errorf("select one: expect no tags, actual=%v, %v", one.Tags, one.Tag)
+	}
+
+	owners := make(map[string]string)
+	tag := &TagWithItem{}
+	err = s.Database.SelectList(tag, func(index uint64, evt sqldb.SqlEvent) {
+		if tag.Item != nil {
+			owners[tag.Label] = tag.Item.Code
+		}
+	}, nil, sqldb.Preload("Item"))
+	if err != nil {
+		t.Fatal("select list fail:", err)
+	}
+	if len(owners) != 3 || owners["red"] != "A01" || owners["sweet"] != "A01" || owners["yellow"] != "B01" {
+		t.Errorf("belongs to: unexpected items %v", owners)
+	}
+
+	sqlAccess, err := s.Database.NewAccess(true)
+	if err != nil {
+		t.Fatal("new access fail:", err)
+	}
+	defer sqlAccess.Close()
+	count := 0
+	err = sqlAccess.SelectList(dbEntity, func(index uint64, evt sqldb.SqlEvent) {
+		count += len(dbEntity.Tags)
+	}, nil, sqldb.Preload("Tags"))
+	if err != nil {
+		t.Fatal("select list in transaction fail:", err)
+	}
+	if count != 3 {
+		t.Errorf("select list in transaction: expect 3 tags, actual=%d", count)
+	}
+
+	err = s.Database.SelectOne(&ItemWithTags{}, sqldb.Preload("Unknown"))
+	if err == nil {
+		t.Error("preload unknown relation should be error")
+	}
+}
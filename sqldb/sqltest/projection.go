@@ -0,0 +1,58 @@
+package sqltest
+
+import (
+	"github.com/csby/database/sqldb"
+	"testing"
+)
+
+func (s *Suite) testProjection(t *testing.T) {
+	items := s.reset(t)
+
+	one := &Item{Code: "X", Name: "x", Score: 1}
+	err := s.Database.SelectOne(one, s.Database.NewFilter(&ItemIdFilter{ID: items[0].ID}, false, false), sqldb.Only("id", "name"))
+	if err != nil {
+		t.Fatal("select one fail:", err)
+	}
+	expect := Item{ID: items[0].ID, Name: items[0].Name}
+	if *one != expect {
+		t.Errorf("only: expect=%+v, actual=%+v", expect, *one)
+	}
+
+	results := make([]Item, 0)
+	dbEntity := &Item{}
+	err = s.Database.SelectList(dbEntity, func(index uint64, evt sqldb.SqlEvent) {
+		results = append(results, *dbEntity)
+	}, &ItemOrder{}, sqldb.Omit("name"), s.Database.NewFilter(&ItemScoreFilter{MinScore: 90}, false, false))
+	if err != nil {
+		t.Fatal("select list fail:", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("omit: expect 2 rows, actual=%d", len(results))
+	}
+	for i, result := range results {
+		expect = *items[i*2]
+		expect.Name = ""
+		if result != expect {
+			t.Errorf("omit: expect=%+v, actual=%+v", expect, result)
+		}
+	}
+
+	codes := make([]string, 0)
+	err = s.Database.SelectPage(dbEntity, nil, func(index uint64, evt sqldb.SqlEvent) {
+		if dbEntity.ID != 0 || dbEntity.Name != "" {
+			t.Errorf("select page: only code expected, actual=%+v", *dbEntity)
+		}
+		codes = append(codes, dbEntity.Code)
+	}, 2, 1, &ItemOrder{}, sqldb.Only("code"))
+	if err != nil {
+		t.Fatal("select page fail:", err)
+	}
+	if len(codes) != 2 || codes[0] != "A01" || codes[1] != "B01" {
+		t.Errorf("select page: unexpected codes %v", codes)
+	}
+
+	err = s.Database.SelectOne(&Item{}, sqldb.Only("unknown"))
+	if err == nil {
+		t.Error("select unknown column should be error")
+	}
+}
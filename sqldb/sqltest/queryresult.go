@@ -0,0 +1,61 @@
+package sqltest
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/csby/database/sqldb"
+	"strings"
+	"testing"
+)
+
+func (s *Suite) testQueryResult(t *testing.T) {
+	s.reset(t)
+
+	query := fmt.Sprintf("SELECT code, score FROM %s WHERE score >= 80 ORDER BY code", TableName)
+	result, err := s.Database.QueryResult(query)
+	if err != nil {
+		t.Fatal("query result fail:", err)
+	}
+	if len(result.Columns) != 2 || !strings.EqualFold(result.Columns[0].Name, "code") || !strings.EqualFold(result.Columns[1].Name, "score") {
+		t.Fatalf("query result: unexpected columns %+v", result.Columns)
+	}
+	rows := resultRows(t, result)
+	if len(rows) != 3 || result.Truncated || result.Next != nil {
+		t.Fatalf("query result: expect 3 rows, actual=%v, truncated=%v", rows, result.Truncated)
+	}
+	expects := [][]string{{"A01", "90"}, {"B01", "90"}, {"C01", "85"}}
+	for i, expect := range expects {
+		code := fmt.Sprint(rows[i][result.Columns[0].Id])
+		score := fmt.Sprint(rows[i][result.Columns[1].Id])
+		if code != expect[0] || score != expect[1] {
+			t.Errorf("query result: row %d expect=%v, actual=[%s %s]", i, expect, code, score)
+		}
+	}
+
+	result, err = s.Database.QueryResultLimit(2, query)
+	if err != nil {
+		t.Fatal("query result with max rows fail:", err)
+	}
+	if len(resultRows(t, result)) != 2 || !result.Truncated {
+		t.Errorf("query result: expect 2 rows and truncated, actual=%d, %v", len(result.Rows), result.Truncated)
+	}
+
+	_, err = s.Database.QueryResult("SELECT unknown FROM " + TableName)
+	if err == nil {
+		t.Error("query unknown column should be error")
+	}
+}
+
+func resultRows(t *testing.T, result *sqldb.SqlSelectResult) []map[string]interface{} {
+	data, err := json.Marshal(result.Rows)
+	if err != nil {
+		t.Fatal("marshal rows fail:", err)
+	}
+	rows := make([]map[string]interface{}, 0)
+	err = json.Unmarshal(data, &rows)
+	if err != nil {
+		t.Fatal("unmarshal rows fail:", err)
+	}
+
+	return rows
+}
@@ -0,0 +1,102 @@
+package sqltest
+
+import (
+	"context"
+	"github.com/csby/database/sqldb"
+	"testing"
+)
+
+func (s *Suite) testRepository(t *testing.T) {
+	items := s.reset(t)
+	ctx := context.Background()
+	repository := sqldb.NewRepository[Item](s.Database)
+
+	item, err := repository.Get(ctx, items[2].ID)
+	if err != nil {
+		t.Fatal("get fail:", err)
+	}
+	if item.Code != "B01" || item.Name != "banana" {
+		t.Errorf("get: expect=%+v, actual=%+v", items[2], item)
+	}
+	_, err = repository.Get(ctx, uint64(0))
+	if !s.Database.IsNoRows(err) {
+		t.Errorf("get missing: expect no rows, actual=%v", err)
+	}
+	_, err = repository.Get(ctx, items[0].ID, items[1].ID)
+	if err == nil {
+		t.Error("get with 2 values of 1 primary key should be error")
+	}
+
+	list, err := repository.List(ctx, &ItemOrder{}, s.Database.NewFilter(&ItemScoreFilter{MinScore: 80}, false, false))
+	if err != nil {
+		t.Fatal("list fail:", err)
+	}
+	codes := make([]string, 0)
+	for _, v := range list {
+		codes = append(codes, v.Code)
+	}
+	if !sameCodes([]string{"A01", "B01", "C01"}, codes, true) {
+		t.Errorf("list: expect=[A01 B01 C01], actual=%v", codes)
+	}
+
+	page, err := repository.Page(ctx, 2, 2, &ItemOrder{})
+	if err != nil {
+		t.Fatal("page fail:", err)
+	}
+	if page.Total != 5 || page.Count != 3 || page.Size != 2 || page.Index != 2 || len(page.Items) != 2 {
+		t.Errorf("page: unexpected %+v", page)
+	} else if page.Items[0].Code != "C01" || page.Items[1].Code != "A02" {
+		t.Errorf("page: expect=[C01 A02], actual=[%s %s]", page.Items[0].Code, page.Items[1].Code)
+	}
+
+	count := 0
+	for v, err := range repository.Iter(ctx, &ItemOrder{}) {
+		if err != nil {
+			t.Fatal("iter fail:", err)
+		}
+		if count == 0 && v.Code != "A01" {
+			t.Errorf("iter: first expect=A01, actual=%s", v.Code)
+		}
+		count++
+		if count == 2 {
+			break
+		}
+	}
+	if count != 2 {
+		t.Errorf("iter break: expect 2 rows, actual=%d", count)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = repository.List(canceled, nil)
+	if err != context.Canceled {
+		t.Errorf("list canceled: expect %v, actual=%v", context.Canceled, err)
+	}
+
+	newItem := &Item{Code: "D01", Name: "date", Score: 70}
+	id, err := repository.Insert(ctx, newItem)
+	if err != nil {
+		t.Fatal("insert fail:", err)
+	}
+	if newItem.ID != id || id <= items[4].ID {
+		t.Errorf("insert: id expect=%d (> %d), actual=%d", id, items[4].ID, newItem.ID)
+	}
+
+	newItem.Score = 72
+	_, err = repository.Update(ctx, newItem)
+	if err != nil {
+		t.Fatal("update fail:", err)
+	}
+	item = s.get(t, id)
+	if item == nil || item.Score != 72 {
+		t.Errorf("update: expect score=72, actual=%+v", item)
+	}
+
+	_, err = repository.Delete(ctx, id)
+	if err != nil {
+		t.Fatal("delete fail:", err)
+	}
+	if s.get(t, id) != nil {
+		t.Error("delete: item should not exist")
+	}
+}
@@ -0,0 +1,81 @@
+package sqltest
+
+import (
+	"context"
+	"github.com/csby/database/sqldb"
+	"testing"
+)
+
+func (s *Suite) testRows(t *testing.T) {
+	items := s.reset(t)
+
+	rows, err := s.Database.SelectRows(&Item{}, &ItemOrder{}, s.Database.NewFilter(&ItemScoreFilter{MinScore: 80}, false, false))
+	if err != nil {
+		t.Fatal("select rows fail:", err)
+	}
+	codes := make([]string, 0)
+	for rows.Next() {
+		item := &Item{}
+		err = rows.Scan(item)
+		if err != nil {
+			t.Fatal("scan fail:", err)
+		}
+		codes = append(codes, item.Code)
+	}
+	if rows.Err() != nil {
+		t.Error("rows fail:", rows.Err())
+	}
+	if err = rows.Close(); err != nil {
+		t.Error("close rows fail:", err)
+	}
+	if !sameCodes([]string{"A01", "B01", "C01"}, codes, true) {
+		t.Errorf("rows: expect=[A01 B01 C01], actual=%v", codes)
+	}
+
+	rows, err = s.Database.SelectRows(&Item{}, nil)
+	if err != nil {
+		t.Fatal("select rows fail:", err)
+	}
+	if !rows.Next() {
+		t.Fatal("rows: expect first row, actual none:", rows.Err())
+	}
+	if err = rows.Scan(&ItemScore{}); err == nil {
+		t.Error("scan into other type should be error")
+	}
+	if err = rows.Close(); err != nil {
+		t.Error("close rows early fail:", err)
+	}
+	if rows.Next() {
+		t.Error("rows: expect no row after close")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	repository := sqldb.NewRepository[Item](s.Database)
+	received, errs := repository.Chan(ctx, 0, &ItemOrder{})
+	count := 0
+	for item := range received {
+		if item.Code == "" {
+			t.Errorf("chan: unexpected item %+v", item)
+		}
+		count++
+	}
+	if err = <-errs; err != nil {
+		t.Error("chan fail:", err)
+	}
+	if count != len(items) {
+		t.Errorf("chan: expect %d items, actual=%d", len(items), count)
+	}
+
+	received, errs = repository.Chan(ctx, 1, &ItemOrder{})
+	first := <-received
+	cancel()
+	for range received {
+	}
+	if first.Code != "A01" {
+		t.Errorf("chan: first expect=A01, actual=%s", first.Code)
+	}
+	if err = <-errs; err != context.Canceled {
+		t.Errorf("chan canceled: expect %v, actual=%v", context.Canceled, err)
+	}
+}
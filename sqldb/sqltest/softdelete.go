@@ -0,0 +1,124 @@
+package sqltest
+
+import (
+	"context"
+	"github.com/csby/database/sqldb"
+	"testing"
+)
+
+func (s *Suite) softList(t *testing.T, filters ...sqldb.SqlFilter) []string {
+	codes := make([]string, 0)
+	dbEntity := &SoftItem{}
+	err := s.Database.SelectList(dbEntity, func(index uint64, evt sqldb.SqlEvent) {
+		codes = append(codes, dbEntity.Code)
+	}, &ItemOrder{}, filters...)
+	if err != nil {
+		t.Fatal("select list fail:", err)
+	}
+
+	return codes
+}
+
+func (s *Suite) testSoftDelete(t *testing.T) {
+	items := s.reset(t)
+
+	count, err := s.Database.Delete(&SoftItem{}, s.Database.NewFilter(&ItemCodeFilter{Code: "A02"}, false, false))
+	if err != nil {
+		t.Fatal("soft delete fail:", err)
+	}
+	if count != 1 {
+		t.Errorf("soft delete: expect 1 row, actual=%d", count)
+	}
+	count, err = s.Database.Delete(&SoftItem{}, s.Database.NewFilter(&ItemCodeFilter{Code: "A02"}, false, false))
+	if err != nil {
+		t.Fatal("soft delete again fail:", err)
+	}
+	if count != 0 {
+		t.Errorf("soft delete again: expect 0 row, actual=%d", count)
+	}
+
+	count, err = s.Database.SelectCount(&SoftItem{})
+	if err != nil {
+		t.Fatal("select count fail:", err)
+	}
+	if count != 4 {
+		t.Errorf("count: expect=4, actual=%d", count)
+	}
+	count, err = s.Database.SelectCount(&Item{})
+	if err != nil {
+		t.Fatal("select count fail:", err)
+	}
+	if count != 5 {
+		t.Errorf("count of entity without soft delete: expect=5, actual=%d", count)
+	}
+
+	codes := s.softList(t)
+	if !sameCodes([]string{"A01", "B01", "C01", "B02"}, codes, true) {
+		t.Errorf("list: expect=[A01 B01 C01 B02], actual=%v", codes)
+	}
+	codes = s.softList(t, s.Database.NewFilter(&ItemCodeOrNameFilter{Code: "A02", Name: "banana"}, true, false))
+	if !sameCodes([]string{"B01"}, codes, true) {
+		t.Errorf("list with or: expect=[B01], actual=%v", codes)
+	}
+	codes = s.softList(t,
+		s.Database.NewFilter(&ItemCodeFilter{Code: "A02"}, false, false),
+		s.Database.NewFilter(&ItemCodeFilter{Code: "B02"}, false, true))
+	if !sameCodes([]string{"B02"}, codes, true) {
+		t.Errorf("list with group or: expect=[B02], actual=%v", codes)
+	}
+	codes = s.softList(t, sqldb.Unscoped())
+	if len(codes) != 5 {
+		t.Errorf("list unscoped: expect 5 rows, actual=%v", codes)
+	}
+
+	dbEntity := &SoftItem{}
+	err = s.Database.SelectOne(dbEntity, s.Database.NewFilter(&ItemCodeFilter{Code: "A02"}, false, false))
+	if !s.Database.IsNoRows(err) {
+		t.Errorf("select one deleted: expect no rows, actual=%v", err)
+	}
+	err = s.Database.SelectOne(dbEntity, sqldb.Unscoped(), s.Database.NewFilter(&ItemCodeFilter{Code: "A02"}, false, false))
+	if err != nil {
+		t.Fatal("select one unscoped fail:", err)
+	}
+	if dbEntity.DeletedAt == nil {
+		t.Error("select one unscoped: deleted time should not be nil")
+	}
+
+	var total uint64
+	err = s.Database.SelectPage(&SoftItem{}, func(t, p, s, i uint64) {
+		total = t
+	}, nil, 2, 1, &ItemOrder{})
+	if err != nil {
+		t.Fatal("select page fail:", err)
+	}
+	if total != 4 {
+		t.Errorf("page: expect total=4, actual=%d", total)
+	}
+
+	_, err = sqldb.NewRepository[SoftItem](s.Database).Get(context.Background(), items[1].ID)
+	if !s.Database.IsNoRows(err) {
+		t.Errorf("repository get deleted: expect no rows, actual=%v", err)
+	}
+
+	count, err = s.Database.HardDelete(&SoftItem{}, s.Database.NewFilter(&ItemCodeFilter{Code: "A02"}, false, false))
+	if err != nil {
+		t.Fatal("hard delete fail:", err)
+	}
+	if count != 1 {
+		t.Errorf("hard delete: expect 1 row, actual=%d", count)
+	}
+	count, err = s.Database.Delete(&SoftItem{}, sqldb.Unscoped(), s.Database.NewFilter(&ItemCodeFilter{Code: "C01"}, false, false))
+	if err != nil {
+		t.Fatal("unscoped delete fail:", err)
+	}
+	if count != 1 {
+		t.Errorf("unscoped delete: expect 1 row, actual=%d", count)
+	}
+	count, err = s.Database.SelectCount(&Item{})
+	if err != nil {
+		t.Fatal("select count fail:", err)
+	}
+	if count != 3 {
+		t.Errorf("count after hard delete: expect=3, actual=%d", count)
+	}
+}
@@ -0,0 +1,599 @@
+package sqltest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/csby/database/sqldb"
+	"strings"
+	"testing"
+)
+
+// Suite is a dialect-agnostic conformance suite which every sqldb.SqlDatabase
+// implementation should pass.
+// Setup contains the statements (re)creating the tables of Item and Tag,
+// they are executed before each case, so the cases are independent of each other.
+type Suite struct {
+	Database sqldb.SqlDatabase
+	Setup    []string
+}
+
+func (s *Suite) Run(t *testing.T) {
+	if s.Database == nil {
+		t.Fatal("invalid suite: database is nil")
+	}
+
+	t.Run("Insert", s.testInsert)
+	t.Run("SelectOne", s.testSelectOne)
+	t.Run("Update", s.testUpdate)
+	t.Run("UpdateByPrimaryKey", s.testUpdateByPrimaryKey)
+	t.Run("UpdateFields", s.testUpdateFields)
+	t.Run("Delete", s.testDelete)
+	t.Run("Filter", s.testFilter)
+	t.Run("Order", s.testOrder)
+	t.Run("Distinct", s.testDistinct)
+	t.Run("Page", s.testPage)
+	t.Run("Cancel", s.testCancel)
+	t.Run("Rows", s.testRows)
+	t.Run("Transaction", s.testTransaction)
+	t.Run("Repository", s.testRepository)
+	t.Run("SoftDelete", s.testSoftDelete)
+	t.Run("Version", s.testVersion)
+	t.Run("Audit", s.testAudit)
+	t.Run("Hook", s.testHook)
+	t.Run("Converter", s.testConverter)
+	t.Run("Encrypt", s.testEncrypt)
+	t.Run("Preload", s.testPreload)
+	t.Run("Projection", s.testProjection)
+	t.Run("QueryResult", s.testQueryResult)
+	t.Run("Export", s.testExport)
+	t.Run("Import", s.testImport)
+	t.Run("Introspection", s.testIntrospection)
+}
+
+// Items is the data inserted by each case, ordered by insertion.
+func Items() []*Item {
+	return []*Item{
+		{Code: "A01", Name: "apple", Score: 90},
+		{Code: "A02", Name: "apricot", Score: 75},
+		{Code: "B01", Name: "banana", Score: 90},
+		{Code: "B02", Name: "blueberry", Score: 60},
+		{Code: "C01", Name: "cherry", Score: 85},
+	}
+}
+
+func (s *Suite) reset(t *testing.T) []*Item {
+	sqlAccess, err := s.Database.NewAccess(false)
+	if err != nil {
+		t.Fatal("new access fail:", err)
+	}
+	defer sqlAccess.Close()
+
+	for _, statement := range s.Setup {
+		_, err = sqlAccess.Exec(statement)
+		if err != nil {
+			t.Fatalf("setup '%s' fail: %v", statement, err)
+		}
+	}
+
+	items := Items()
+	for _, item := range items {
+		id, err := sqlAccess.Insert(item)
+		if err != nil {
+			t.Fatal("insert fail:", err)
+		}
+		item.ID = id
+	}
+
+	return items
+}
+
+func (s *Suite) list(t *testing.T, order interface{}, filters ...sqldb.SqlFilter) []Item {
+	results := make([]Item, 0)
+	dbEntity := &Item{}
+	err := s.Database.SelectList(dbEntity, func(index uint64, evt sqldb.SqlEvent) {
+		results = append(results, *dbEntity)
+	}, order, filters...)
+	if err != nil {
+		t.Fatal("select list fail:", err)
+	}
+
+	return results
+}
+
+func (s *Suite) get(t *testing.T, id uint64) *Item {
+	dbEntity := &Item{}
+	err := s.Database.SelectOne(dbEntity, s.Database.NewFilter(&ItemIdFilter{ID: id}, false, false))
+	if err != nil {
+		if s.Database.IsNoRows(err) {
+			return nil
+		}
+		t.Fatal("select one fail:", err)
+	}
+
+	return dbEntity
+}
+
+func (s *Suite) testInsert(t *testing.T) {
+	items := s.reset(t)
+	for i := 1; i < len(items); i++ {
+		if items[i].ID <= items[i-1].ID {
+			t.Errorf("auto increment id: expect > %d, actual=%d", items[i-1].ID, items[i].ID)
+		}
+	}
+
+	dbEntity := &Item{Code: "D01"}
+	id, err := s.Database.InsertSelective(dbEntity)
+	if err != nil {
+		t.Fatal("insert selective fail:", err)
+	}
+	item := s.get(t, id)
+	if item == nil {
+		t.Fatal("inserted item not found: id=", id)
+	}
+	if item.Code != "D01" || item.Name != "" || item.Score != 0 {
+		t.Errorf("insert selective: unexpected %+v", item)
+	}
+
+	count, err := s.Database.SelectCount(&Item{})
+	if err != nil {
+		t.Fatal("select count fail:", err)
+	}
+	if count != uint64(len(items)+1) {
+		t.Errorf("count: expect=%d, actual=%d", len(items)+1, count)
+	}
+}
+
+func (s *Suite) testSelectOne(t *testing.T) {
+	items := s.reset(t)
+
+	dbEntity := &Item{}
+	err := s.Database.SelectOne(dbEntity, s.Database.NewFilter(&ItemCodeFilter{Code: "B02"}, false, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dbEntity.ID != items[3].ID || dbEntity.Name != "blueberry" || dbEntity.Score != 60 {
+		t.Errorf("select one: expect=%+v, actual=%+v", items[3], dbEntity)
+	}
+
+	err = s.Database.SelectOne(dbEntity, s.Database.NewFilter(&ItemCodeFilter{Code: "Z99"}, false, false))
+	if !s.Database.IsNoRows(err) {
+		t.Errorf("select one missing: expect no rows, actual=%v", err)
+	}
+	if s.Database.IsNoRows(nil) {
+		t.Error("nil error should not be no rows")
+	}
+}
+
+func (s *Suite) testUpdate(t *testing.T) {
+	s.reset(t)
+
+	count, err := s.Database.Update(&Item{Code: "X", Name: "", Score: 1},
+		s.Database.NewFilter(&ItemCodeFilter{Code: "A01"}, false, false))
+	if err != nil {
+		t.Fatal("update fail:", err)
+	}
+	if count != 1 {
+		t.Errorf("update: rows affected expect=1, actual=%d", count)
+	}
+	rows := s.list(t, nil, s.Database.NewFilter(&ItemCodeFilter{Code: "X"}, false, false))
+	if len(rows) != 1 || rows[0].Name != "" || rows[0].Score != 1 {
+		t.Errorf("update: unexpected %+v", rows)
+	}
+
+	count, err = s.Database.UpdateSelective(&Item{Score: 50},
+		s.Database.NewFilter(&ItemNameFilter{Name: "b%"}, false, false))
+	if err != nil {
+		t.Fatal("update selective fail:", err)
+	}
+	if count != 2 {
+		t.Errorf("update selective: rows affected expect=2, actual=%d", count)
+	}
+	rows = s.list(t, nil, s.Database.NewFilter(&ItemNameFilter{Name: "b%"}, false, false))
+	for _, row := range rows {
+		if row.Score != 50 || row.Code == "" || row.Name == "" {
+			t.Errorf("update selective: unexpected %+v", row)
+		}
+	}
+}
+
+func (s *Suite) testUpdateByPrimaryKey(t *testing.T) {
+	items := s.reset(t)
+
+	item := *items[1]
+	item.Name = "apricot2"
+	item.Score = 76
+	count, err := s.Database.UpdateByPrimaryKey(&item)
+	if err != nil {
+		t.Fatal("update by primary key fail:", err)
+	}
+	if count != 1 {
+		t.Errorf("update by primary key: rows affected expect=1, actual=%d", count)
+	}
+	actual := s.get(t, item.ID)
+	if actual == nil || *actual != item {
+		t.Errorf("update by primary key: expect=%+v, actual=%+v", item, actual)
+	}
+
+	// nothing changed, but the row exists
+	count, err = s.Database.UpdateByPrimaryKey(&item)
+	if err != nil {
+		t.Fatal("update by primary key fail:", err)
+	}
+	if count != 1 {
+		t.Errorf("update by primary key unchanged: rows affected expect=1, actual=%d", count)
+	}
+
+	count, err = s.Database.UpdateSelectiveByPrimaryKey(&Item{ID: item.ID, Score: 77})
+	if err != nil {
+		t.Fatal("update selective by primary key fail:", err)
+	}
+	if count != 1 {
+		t.Errorf("update selective by primary key: rows affected expect=1, actual=%d", count)
+	}
+	actual = s.get(t, item.ID)
+	if actual == nil || actual.Score != 77 || actual.Name != "apricot2" || actual.Code != "A02" {
+		t.Errorf("update selective by primary key: unexpected %+v", actual)
+	}
+
+	count, err = s.Database.UpdateByPrimaryKey(&Item{ID: items[len(items)-1].ID + 100, Code: "Z"})
+	if err != nil {
+		t.Fatal("update by primary key missing fail:", err)
+	}
+	if count != 0 {
+		t.Errorf("update by primary key missing: rows affected expect=0, actual=%d", count)
+	}
+}
+
+func (s *Suite) testDelete(t *testing.T) {
+	items := s.reset(t)
+
+	count, err := s.Database.Delete(&Item{}, s.Database.NewFilter(&ItemNameFilter{Name: "a%"}, false, false))
+	if err != nil {
+		t.Fatal("delete fail:", err)
+	}
+	if count != 2 {
+		t.Errorf("delete: rows affected expect=2, actual=%d", count)
+	}
+	if s.get(t, items[0].ID) != nil {
+		t.Error("delete: item still exists")
+	}
+
+	count, err = s.Database.SelectCount(&Item{})
+	if err != nil {
+		t.Fatal("select count fail:", err)
+	}
+	if count != uint64(len(items)-2) {
+		t.Errorf("delete: remain count expect=%d, actual=%d", len(items)-2, count)
+	}
+}
+
+func (s *Suite) testFilter(t *testing.T) {
+	items := s.reset(t)
+
+	cases := []struct {
+		name    string
+		filters []sqldb.SqlFilter
+		codes   []string
+	}{
+		{
+			name:    "equal",
+			filters: []sqldb.SqlFilter{s.Database.NewFilter(&ItemCodeFilter{Code: "C01"}, false, false)},
+			codes:   []string{"C01"},
+		},
+		{
+			name:    "empty field ignored",
+			filters: []sqldb.SqlFilter{s.Database.NewFilter(&ItemCodeFilter{}, false, false)},
+			codes:   []string{"A01", "A02", "B01", "B02", "C01"},
+		},
+		{
+			name:    "like",
+			filters: []sqldb.SqlFilter{s.Database.NewFilter(&ItemNameFilter{Name: "b%"}, false, false)},
+			codes:   []string{"B01", "B02"},
+		},
+		{
+			name:    "compare",
+			filters: []sqldb.SqlFilter{s.Database.NewFilter(&ItemScoreFilter{MinScore: 85}, false, false)},
+			codes:   []string{"A01", "B01", "C01"},
+		},
+		{
+			name:    "in",
+			filters: []sqldb.SqlFilter{s.Database.NewFilter(&ItemIdsFilter{ID: []uint64{items[1].ID, items[3].ID}}, false, false)},
+			codes:   []string{"A02", "B02"},
+		},
+		{
+			name:    "field and",
+			filters: []sqldb.SqlFilter{s.Database.NewFilter(&ItemCodeOrNameFilter{Code: "A01", Name: "banana"}, false, false)},
+			codes:   []string{},
+		},
+		{
+			name:    "field or",
+			filters: []sqldb.SqlFilter{s.Database.NewFilter(&ItemCodeOrNameFilter{Code: "A01", Name: "banana"}, true, false)},
+			codes:   []string{"A01", "B01"},
+		},
+		{
+			name: "group and",
+			filters: []sqldb.SqlFilter{
+				s.Database.NewFilter(&ItemScoreFilter{MinScore: 85}, false, false),
+				s.Database.NewFilter(&ItemNameFilter{Name: "b%"}, false, false),
+			},
+			codes: []string{"B01"},
+		},
+		{
+			name: "group or",
+			filters: []sqldb.SqlFilter{
+				s.Database.NewFilter(&ItemScoreFilter{MinScore: 85}, false, false),
+				s.Database.NewFilter(&ItemNameFilter{Name: "b%"}, false, true),
+			},
+			codes: []string{"A01", "B01", "B02", "C01"},
+		},
+	}
+
+	for _, c := range cases {
+		rows := s.list(t, nil, c.filters...)
+		actual := make([]string, 0)
+		for _, row := range rows {
+			actual = append(actual, row.Code)
+		}
+		if !sameCodes(c.codes, actual, false) {
+			t.Errorf("filter %s: expect=%v, actual=%v", c.name, c.codes, actual)
+		}
+
+		count, err := s.Database.SelectCount(&Item{}, c.filters...)
+		if err != nil {
+			t.Fatalf("filter %s: select count fail: %v", c.name, err)
+		}
+		if count != uint64(len(c.codes)) {
+			t.Errorf("filter %s: count expect=%d, actual=%d", c.name, len(c.codes), count)
+		}
+	}
+}
+
+func (s *Suite) testOrder(t *testing.T) {
+	s.reset(t)
+
+	rows := s.list(t, &ItemOrder{})
+	actual := make([]string, 0)
+	for _, row := range rows {
+		actual = append(actual, row.Code)
+	}
+	expect := []string{"A01", "B01", "C01", "A02", "B02"}
+	if !sameCodes(expect, actual, true) {
+		t.Errorf("order: expect=%v, actual=%v", expect, actual)
+	}
+}
+
+func (s *Suite) testDistinct(t *testing.T) {
+	s.reset(t)
+
+	scores := make(map[int64]int)
+	dbEntity := &ItemScore{}
+	err := s.Database.SelectDistinct(dbEntity, func(index uint64, evt sqldb.SqlEvent) {
+		scores[dbEntity.Score]++
+	}, nil)
+	if err != nil {
+		t.Fatal("select distinct fail:", err)
+	}
+	if len(scores) != 4 {
+		t.Errorf("distinct: expect 4 scores, actual=%v", scores)
+	}
+	for score, count := range scores {
+		if count != 1 {
+			t.Errorf("distinct: score %d returned %d times", score, count)
+		}
+	}
+}
+
+func (s *Suite) testPage(t *testing.T) {
+	s.reset(t)
+
+	cases := []struct {
+		size, index uint64
+		pageIndex   uint64
+		codes       []string
+	}{
+		{size: 2, index: 1, pageIndex: 1, codes: []string{"A01", "B01"}},
+		{size: 2, index: 2, pageIndex: 2, codes: []string{"C01", "A02"}},
+		{size: 2, index: 3, pageIndex: 3, codes: []string{"B02"}},
+		{size: 2, index: 9, pageIndex: 3, codes: []string{"B02"}},
+		{size: 2, index: 0, pageIndex: 1, codes: []string{"A01", "B01"}},
+		{size: 10, index: 1, pageIndex: 1, codes: []string{"A01", "B01", "C01", "A02", "B02"}},
+	}
+
+	for _, c := range cases {
+		var total, pageCount, pageSize, pageIndex uint64
+		actual := make([]string, 0)
+		dbEntity := &Item{}
+		err := s.Database.SelectPage(dbEntity, func(t, p, s, i uint64) {
+			total, pageCount, pageSize, pageIndex = t, p, s, i
+		}, func(index uint64, evt sqldb.SqlEvent) {
+			actual = append(actual, dbEntity.Code)
+		}, c.size, c.index, &ItemOrder{})
+		if err != nil {
+			t.Fatalf("page(%d, %d): select page fail: %v", c.size, c.index, err)
+		}
+
+		expectPageCount := (5 + c.size - 1) / c.size
+		if total != 5 || pageCount != expectPageCount || pageSize != c.size || pageIndex != c.pageIndex {
+			t.Errorf("page(%d, %d): expect total=5, page=%d, size=%d, index=%d; actual total=%d, page=%d, size=%d, index=%d",
+				c.size, c.index, expectPageCount, c.size, c.pageIndex, total, pageCount, pageSize, pageIndex)
+		}
+		if !sameCodes(c.codes, actual, true) {
+			t.Errorf("page(%d, %d): expect=%v, actual=%v", c.size, c.index, c.codes, actual)
+		}
+	}
+
+	called := false
+	err := s.Database.SelectPage(&Item{}, func(total, page, size, index uint64) {
+		if total != 0 {
+			t.Errorf("empty page: total expect=0, actual=%d", total)
+		}
+	}, func(index uint64, evt sqldb.SqlEvent) {
+		called = true
+	}, 2, 1, &ItemOrder{}, s.Database.NewFilter(&ItemCodeFilter{Code: "Z99"}, false, false))
+	if err != nil {
+		t.Fatal("empty page: select page fail:", err)
+	}
+	if called {
+		t.Error("empty page: row should not be called")
+	}
+}
+
+func (s *Suite) testCancel(t *testing.T) {
+	s.reset(t)
+
+	canceled := errors.New("canceled")
+	count := 0
+	err := s.Database.SelectList(&Item{}, func(index uint64, evt sqldb.SqlEvent) {
+		count++
+		if index == 1 {
+			evt.Cancel(canceled)
+		}
+	}, &ItemOrder{})
+	if err != canceled {
+		t.Errorf("cancel: expect error %v, actual=%v", canceled, err)
+	}
+	if count != 2 {
+		t.Errorf("cancel: expect 2 rows, actual=%d", count)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, transactional := range []bool{false, true} {
+		sqlAccess, err := s.Database.NewAccess(transactional)
+		if err != nil {
+			t.Fatal("new access fail:", err)
+		}
+		ctxAccess := sqlAccess.WithContext(ctx)
+		_, err = ctxAccess.Query("SELECT 1")
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("query with canceled context (transactional=%v): expect %v, actual=%v", transactional, context.Canceled, err)
+		}
+		_, err = ctxAccess.SelectCount(&Item{})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("select with canceled context (transactional=%v): expect %v, actual=%v", transactional, context.Canceled, err)
+		}
+		_, err = ctxAccess.Insert(&Item{Code: "X01", Name: "canceled"})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("insert with canceled context (transactional=%v): expect %v, actual=%v", transactional, context.Canceled, err)
+		}
+		sqlAccess.Close()
+	}
+}
+
+func (s *Suite) testTransaction(t *testing.T) {
+	items := s.reset(t)
+
+	sqlAccess, err := s.Database.NewAccess(true)
+	if err != nil {
+		t.Fatal("new transactional access fail:", err)
+	}
+	_, err = sqlAccess.Insert(&Item{Code: "T01", Name: "rollback"})
+	if err != nil {
+		sqlAccess.Close()
+		t.Fatal("insert in transaction fail:", err)
+	}
+	_, err = sqlAccess.Delete(&Item{}, sqlAccess.NewFilter(&ItemIdFilter{ID: items[0].ID}, false, false))
+	if err != nil {
+		sqlAccess.Close()
+		t.Fatal("delete in transaction fail:", err)
+	}
+	count, err := sqlAccess.SelectCount(&Item{})
+	if err != nil {
+		sqlAccess.Close()
+		t.Fatal("select count in transaction fail:", err)
+	}
+	if count != uint64(len(items)) {
+		t.Errorf("transaction: count inside expect=%d, actual=%d", len(items), count)
+	}
+	sqlAccess.Close()
+
+	if s.get(t, items[0].ID) == nil {
+		t.Error("transaction: delete should be rolled back on close")
+	}
+	if len(s.list(t, nil, s.Database.NewFilter(&ItemCodeFilter{Code: "T01"}, false, false))) != 0 {
+		t.Error("transaction: insert should be rolled back on close")
+	}
+
+	sqlAccess, err = s.Database.NewAccess(true)
+	if err != nil {
+		t.Fatal("new transactional access fail:", err)
+	}
+	defer sqlAccess.Close()
+	_, err = sqlAccess.Insert(&Item{Code: "T02", Name: "commit"})
+	if err != nil {
+		t.Fatal("insert in transaction fail:", err)
+	}
+	err = sqlAccess.Commit()
+	if err != nil {
+		t.Fatal("commit fail:", err)
+	}
+	if len(s.list(t, nil, s.Database.NewFilter(&ItemCodeFilter{Code: "T02"}, false, false))) != 1 {
+		t.Error("transaction: insert should be committed")
+	}
+}
+
+func (s *Suite) testIntrospection(t *testing.T) {
+	s.reset(t)
+
+	tables, err := s.Database.Tables()
+	if err != nil {
+		t.Fatal("tables fail:", err)
+	}
+	var table *sqldb.SqlTable
+	for _, item := range tables {
+		if strings.EqualFold(item.Name, TableName) {
+			table = item
+			break
+		}
+	}
+	if table == nil {
+		t.Fatalf("tables: '%s' not found in %d tables", TableName, len(tables))
+	}
+
+	columns, err := s.Database.Columns(table)
+	if err != nil {
+		t.Fatal("columns fail:", err)
+	}
+	names := make(map[string]*sqldb.SqlColumn)
+	for _, column := range columns {
+		names[strings.ToLower(column.Name)] = column
+	}
+	for _, name := range []string{"id", "code", "name", "score"} {
+		if _, ok := names[name]; !ok {
+			t.Errorf("columns: '%s' not found", name)
+		}
+	}
+	if id, ok := names["id"]; ok {
+		if !id.PrimaryKey {
+			t.Error("columns: 'id' should be primary key")
+		}
+		if id.Nullable {
+			t.Error("columns: 'id' should not be nullable")
+		}
+	}
+}
+
+func sameCodes(expect, actual []string, ordered bool) bool {
+	if len(expect) != len(actual) {
+		return false
+	}
+	if ordered {
+		return fmt.Sprint(expect) == fmt.Sprint(actual)
+	}
+
+	counts := make(map[string]int)
+	for _, code := range expect {
+		counts[code]++
+	}
+	for _, code := range actual {
+		counts[code]--
+	}
+	for _, count := range counts {
+		if count != 0 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,56 @@
+package sqltest
+
+import (
+	"github.com/csby/database/sqldb"
+	"testing"
+)
+
+func (s *Suite) testUpdateFields(t *testing.T) {
+	items := s.reset(t)
+
+	dbEntity := &Item{Code: "changed", Name: "", Score: 0}
+	count, err := s.Database.UpdateFields(dbEntity, []string{"name", "score"}, s.Database.NewFilter(&ItemIdFilter{ID: items[0].ID}, false, false))
+	if err != nil {
+		t.Fatal("update fields fail:", err)
+	}
+	if count != 1 {
+		t.Errorf("update fields: expect 1 row affected, actual=%d", count)
+	}
+	actual := s.get(t, items[0].ID)
+	expect := Item{ID: items[0].ID, Code: items[0].Code}
+	if actual == nil || *actual != expect {
+		t.Errorf("update fields: expect=%+v, actual=%+v", expect, actual)
+	}
+
+	values := map[string]interface{}{
+		"score": sqldb.Expr("score + ?", 5),
+		"name":  "fruit",
+	}
+	count, err = s.Database.UpdateMap(&Item{}, values, s.Database.NewFilter(&ItemScoreFilter{MinScore: 85}, false, false))
+	if err != nil {
+		t.Fatal("update map fail:", err)
+	}
+	if count != 2 {
+		t.Errorf("update map: expect 2 rows affected, actual=%d", count)
+	}
+	for _, item := range items[1:] {
+		actual = s.get(t, item.ID)
+		expect = *item
+		if item.Score >= 85 {
+			expect.Score += 5
+			expect.Name = "fruit"
+		}
+		if actual == nil || *actual != expect {
+			t.Errorf("update map: expect=%+v, actual=%+v", expect, actual)
+		}
+	}
+
+	_, err = s.Database.UpdateFields(&Item{}, []string{"unknown"})
+	if err == nil {
+		t.Error("update unknown column should be error")
+	}
+	_, err = s.Database.UpdateMap(&Item{}, map[string]interface{}{})
+	if err == nil {
+		t.Error("update empty map should be error")
+	}
+}
@@ -0,0 +1,57 @@
+package sqltest
+
+import (
+	"errors"
+	"github.com/csby/database/sqldb"
+	"testing"
+)
+
+func (s *Suite) testVersion(t *testing.T) {
+	s.reset(t)
+
+	filter := s.Database.NewFilter(&ItemCodeFilter{Code: "B01"}, false, false)
+	editor1 := &VersionedItem{}
+	err := s.Database.SelectOne(editor1, filter)
+	if err != nil {
+		t.Fatal("select one fail:", err)
+	}
+	editor2 := &VersionedItem{}
+	err = s.Database.SelectOne(editor2, filter)
+	if err != nil {
+		t.Fatal("select one fail:", err)
+	}
+
+	editor1.Score = 91
+	count, err := s.Database.UpdateByPrimaryKey(editor1)
+	if err != nil {
+		t.Fatal("update fail:", err)
+	}
+	if count != 1 || editor1.Version != 1 {
+		t.Errorf("update: expect 1 row and version=1, actual %d rows and version=%d", count, editor1.Version)
+	}
+
+	editor2.Score = 92
+	_, err = s.Database.UpdateByPrimaryKey(editor2)
+	if !errors.Is(err, sqldb.ErrStaleEntity) {
+		t.Errorf("update stale: expect %v, actual=%v", sqldb.ErrStaleEntity, err)
+	}
+	if editor2.Version != 0 {
+		t.Errorf("update stale: version should not be changed, actual=%d", editor2.Version)
+	}
+
+	editor1.Name = ""
+	editor1.Score = 93
+	_, err = s.Database.UpdateSelectiveByPrimaryKey(editor1)
+	if err != nil {
+		t.Fatal("update selective fail:", err)
+	}
+
+	actual := &VersionedItem{}
+	err = s.Database.SelectOne(actual, filter)
+	if err != nil {
+		t.Fatal("select one fail:", err)
+	}
+	if actual.Version != 2 || actual.Score != 93 || actual.Name != "banana" {
+		t.Errorf("update selective: expect version=2, score=93, name=banana, actual=%+v", actual)
+	}
+}
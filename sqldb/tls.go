@@ -0,0 +1,15 @@
+package sqldb
+
+import (
+	"github.com/csby/database/tlsconf"
+)
+
+const (
+	TlsDisable    = tlsconf.Disable
+	TlsRequire    = tlsconf.Require
+	TlsVerifyCA   = tlsconf.VerifyCA
+	TlsVerifyFull = tlsconf.VerifyFull
+)
+
+// SqlTls is the TLS settings of connection, Wallet is used by oracle only
+type SqlTls = tlsconf.Settings
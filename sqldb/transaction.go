@@ -0,0 +1,164 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"io"
+)
+
+type transaction struct {
+	access
+
+	db *sql.DB
+	tx *sql.Tx
+}
+
+func (s *transaction) Close() error {
+	defer s.db.Close()
+
+	return s.tx.Rollback()
+}
+
+func (s *transaction) Commit() error {
+	return s.tx.Commit()
+}
+
+func (s *transaction) Rollback() error {
+	return s.tx.Rollback()
+}
+
+// WithContext returns the access bound to ctx which shares the same connection (or transaction)
+func (s *transaction) WithContext(ctx context.Context) SqlAccess {
+	instance := *s
+	instance.ctx = ctx
+
+	return &instance
+}
+
+func (s *transaction) Version() int {
+	return s.dialect.Version(s)
+}
+
+func (s *transaction) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return s.tx.ExecContext(s.ctx, query, args...)
+}
+
+func (s *transaction) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return s.tx.ExecContext(ctx, query, args...)
+}
+
+func (s *transaction) Prepare(query string) (*sql.Stmt, error) {
+	return s.tx.PrepareContext(s.ctx, query)
+}
+
+func (s *transaction) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return s.tx.PrepareContext(ctx, query)
+}
+
+func (s *transaction) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return s.tx.QueryContext(s.ctx, query, args...)
+}
+
+func (s *transaction) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return s.tx.QueryContext(ctx, query, args...)
+}
+
+func (s *transaction) QueryRow(query string, args ...interface{}) *sql.Row {
+	return s.tx.QueryRowContext(s.ctx, query, args...)
+}
+
+func (s *transaction) QueryResult(query string, args ...interface{}) (*SqlSelectResult, error) {
+	return s.queryResult(s, 0, query, args...)
+}
+
+func (s *transaction) QueryResultLimit(limit uint64, query string, args ...interface{}) (*SqlSelectResult, error) {
+	return s.queryResult(s, limit, query, args...)
+}
+
+func (s *transaction) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return s.tx.QueryRowContext(ctx, query, args...)
+}
+
+func (s *transaction) Stmt(stmt *sql.Stmt) *sql.Stmt {
+	return s.tx.Stmt(stmt)
+}
+
+func (s *transaction) StmtContext(ctx context.Context, stmt *sql.Stmt) *sql.Stmt {
+	return s.tx.StmtContext(ctx, stmt)
+}
+
+func (s *transaction) IsNoRows(err error) bool {
+	return s.isNoRows(err)
+}
+
+func (s *transaction) Insert(entity interface{}, fields ...SqlField) (uint64, error) {
+	return s.insert(s, false, entity, fields...)
+}
+
+func (s *transaction) InsertSelective(entity interface{}) (uint64, error) {
+	return s.insert(s, true, entity)
+}
+
+func (s *transaction) Delete(entity interface{}, filters ...SqlFilter) (uint64, error) {
+	return s.delete(s, false, entity, filters...)
+}
+
+func (s *transaction) HardDelete(entity interface{}, filters ...SqlFilter) (uint64, error) {
+	return s.delete(s, true, entity, filters...)
+}
+
+func (s *transaction) Update(entity interface{}, filters ...SqlFilter) (uint64, error) {
+	return s.update(s, false, entity, filters...)
+}
+
+func (s *transaction) UpdateSelective(entity interface{}, filters ...SqlFilter) (uint64, error) {
+	return s.update(s, true, entity, filters...)
+}
+
+func (s *transaction) UpdateByPrimaryKey(entity interface{}) (uint64, error) {
+	return s.updateByPrimaryKey(s, false, entity)
+}
+
+func (s *transaction) UpdateSelectiveByPrimaryKey(entity interface{}) (uint64, error) {
+	return s.updateByPrimaryKey(s, true, entity)
+}
+
+func (s *transaction) UpdateFields(entity interface{}, columns []string, filters ...SqlFilter) (uint64, error) {
+	return s.updateFields(s, entity, columns, filters...)
+}
+
+func (s *transaction) UpdateMap(entity interface{}, values map[string]interface{}, filters ...SqlFilter) (uint64, error) {
+	return s.updateMap(s, entity, values, filters...)
+}
+
+func (s *transaction) SelectOne(entity interface{}, filters ...SqlFilter) error {
+	return s.selectOne(s, entity, filters...)
+}
+
+func (s *transaction) SelectDistinct(entity interface{}, row func(index uint64, evt SqlEvent), order interface{}, filters ...SqlFilter) error {
+	return s.selectList(s, true, entity, row, order, filters...)
+}
+
+func (s *transaction) SelectList(entity interface{}, row func(index uint64, evt SqlEvent), order interface{}, filters ...SqlFilter) error {
+	return s.selectList(s, false, entity, row, order, filters...)
+}
+
+func (s *transaction) SelectPage(entity interface{}, page func(total, page, size, index uint64), row func(index uint64, evt SqlEvent), size, index uint64, order interface{}, filters ...SqlFilter) error {
+	return s.selectPage(s, entity, page, row, size, index, order, filters...)
+}
+
+func (s *transaction) SelectRows(entity interface{}, order interface{}, filters ...SqlFilter) (SqlRows, error) {
+	return s.selectRows(s, entity, order, filters...)
+}
+
+func (s *transaction) SelectCount(entity interface{}, filters ...SqlFilter) (uint64, error) {
+	return s.selectCount(s, entity, filters...)
+}
+
+func (s *transaction) ImportTable(table *SqlTable, columns []*SqlColumn, r io.Reader, options *ImportOptions) (*ImportResult, error) {
+	return s.importTable(s, table, columns, r, options)
+}
+
+func (s *transaction) ImportEntity(entity interface{}, r io.Reader, options *ImportOptions) (*ImportResult, error) {
+	return s.importEntity(s, entity, r, options)
+}
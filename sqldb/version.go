@@ -0,0 +1,45 @@
+package sqldb
+
+import (
+	"reflect"
+)
+
+func newVersionMeta(t reflect.Type, fields []*fieldMeta) (*fieldMeta, error) {
+	var meta *fieldMeta = nil
+	for _, fm := range fields {
+		if !fm.version {
+			continue
+		}
+		if meta != nil {
+			return nil, newError("invalid entity (", t.Name(), "): more than one version field")
+		}
+
+		switch fm.typ.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			meta = fm
+		default:
+			return nil, newError("invalid entity (", t.Name(), "): version field ", fm.name, " should be integer")
+		}
+	}
+
+	return meta, nil
+}
+
+// nextVersion returns the value of version field plus 1, in the same type
+func nextVersion(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	next := reflect.New(v.Type()).Elem()
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		next.SetInt(v.Int() + 1)
+	default:
+		next.SetUint(v.Uint() + 1)
+	}
+
+	return next.Interface()
+}
+
+func setVersion(address interface{}, value interface{}) {
+	reflect.ValueOf(address).Elem().Set(reflect.ValueOf(value))
+}
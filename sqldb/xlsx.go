@@ -0,0 +1,202 @@
+package sqldb
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	xlsxMaxRows      = 1048576
+	xlsxMaxSheetName = 31
+
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+	xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`
+	xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+		`</styleSheet>`
+	xlsxSheetBegin = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+)
+
+// xlsxExportWriter writes one sheet with the header (bold) in the first row,
+// the numbers and booleans are typed cells, the times are text of the time format.
+type xlsxExportWriter struct {
+	zip       *zip.Writer
+	sheet     *bufio.Writer
+	text      *exportText
+	sheetName string
+	columns   []string // cell reference letters of columns
+	row       int
+}
+
+func newXlsxExportWriter(w io.Writer, text *exportText, sheetName string) *xlsxExportWriter {
+	return &xlsxExportWriter{
+		zip:       zip.NewWriter(w),
+		text:      text,
+		sheetName: xlsxSheetName(sheetName),
+	}
+}
+
+func (s *xlsxExportWriter) Begin(columns []*exportColumn) error {
+	sheetName := &strings.Builder{}
+	xml.EscapeText(sheetName, []byte(s.sheetName))
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, sheetName.String())},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, file := range files {
+		fw, err := s.zip.Create(file.name)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(fw, file.content)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the sheet is the last file, so that it is written row by row
+	fw, err := s.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	s.sheet = bufio.NewWriter(fw)
+	_, err = s.sheet.WriteString(xlsxSheetBegin)
+	if err != nil {
+		return err
+	}
+
+	s.columns = make([]string, len(columns))
+	values := make([]interface{}, len(columns))
+	for i, name := range uniqueNames(columns) {
+		s.columns[i] = xlsxColumnName(i)
+		values[i] = name
+	}
+
+	return s.writeRow(values, ` s="1"`)
+}
+
+func (s *xlsxExportWriter) Write(values []interface{}) error {
+	return s.writeRow(values, "")
+}
+
+func (s *xlsxExportWriter) End() error {
+	_, err := s.sheet.WriteString(xlsxSheetEnd)
+	if err != nil {
+		return err
+	}
+	err = s.sheet.Flush()
+	if err != nil {
+		return err
+	}
+
+	return s.zip.Close()
+}
+
+func (s *xlsxExportWriter) writeRow(values []interface{}, style string) error {
+	if s.row >= xlsxMaxRows {
+		return fmt.Errorf("xlsx: rows exceed the limit %d", xlsxMaxRows)
+	}
+	s.row++
+
+	w := s.sheet
+	fmt.Fprintf(w, `<row r="%d">`, s.row)
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		ref := s.columns[i] + strconv.Itoa(s.row)
+		switch v := value.(type) {
+		case int64:
+			fmt.Fprintf(w, `<c r="%s"%s><v>%d</v></c>`, ref, style, v)
+		case float64:
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				s.writeText(ref, style, s.text.format(v))
+			} else {
+				fmt.Fprintf(w, `<c r="%s"%s><v>%s</v></c>`, ref, style, s.text.format(v))
+			}
+		case bool:
+			n := 0
+			if v {
+				n = 1
+			}
+			fmt.Fprintf(w, `<c r="%s"%s t="b"><v>%d</v></c>`, ref, style, n)
+		default:
+			s.writeText(ref, style, s.text.format(v))
+		}
+	}
+	_, err := w.WriteString(`</row>`)
+
+	return err
+}
+
+func (s *xlsxExportWriter) writeText(ref, style, text string) {
+	fmt.Fprintf(s.sheet, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">`, ref, style)
+	xml.EscapeText(s.sheet, []byte(text))
+	s.sheet.WriteString(`</t></is></c>`)
+}
+
+// xlsxColumnName returns the letters of column index (from 0), e.g. A, Z, AA
+func xlsxColumnName(index int) string {
+	name := ""
+	for n := index + 1; n > 0; n = (n - 1) / 26 {
+		name = string(rune('A'+(n-1)%26)) + name
+	}
+
+	return name
+}
+
+func xlsxSheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		return "Sheet1"
+	}
+	runes := []rune(name)
+	if len(runes) > xlsxMaxSheetName {
+		name = string(runes[:xlsxMaxSheetName])
+	}
+
+	return name
+}
@@ -0,0 +1,104 @@
+package tlsconf
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+const (
+	Disable    = "disable"
+	Require    = "require"
+	VerifyCA   = "verify-ca"
+	VerifyFull = "verify-full"
+)
+
+// Settings is the TLS settings of connections, shared by sqldb (SqlTls) and mqdb (MqTls)
+type Settings struct {
+	Mode       string `json:"mode" note:"加密模式: disable(不加密), require(加密但不验证服务器证书), verify-ca(验证证书链), verify-full(默认, 验证证书链和服务器名称)"`
+	CA         string `json:"ca" note:"CA证书文件路径(PEM), 为空时使用系统证书"`
+	Cert       string `json:"cert" note:"客户端证书文件路径(PEM), 双向认证时使用"`
+	Key        string `json:"key" note:"客户端私钥文件路径(PEM), 双向认证时使用"`
+	ServerName string `json:"serverName" note:"验证的服务器名称, 为空时使用连接的服务器名称"`
+	Wallet     string `json:"wallet" note:"Oracle钱包目录, 包含CA及客户端证书, 仅用于Oracle"`
+}
+
+// Enabled reports whether the connection is encrypted, nil is not
+func (s *Settings) Enabled() bool {
+	return s != nil && s.Mode != Disable
+}
+
+// Verified reports whether the certificate of server is verified
+func (s *Settings) Verified() bool {
+	return s.Enabled() && s.Mode != Require
+}
+
+// Config returns the config of crypto/tls, nil if not enabled.
+// The chain of certificate is verified by CA without the server name for verify-ca.
+func (s *Settings) Config() (*tls.Config, error) {
+	if !s.Enabled() {
+		return nil, nil
+	}
+	switch s.Mode {
+	case "", Require, VerifyCA, VerifyFull:
+	default:
+		return nil, fmt.Errorf("tls mode '%s' not supported", s.Mode)
+	}
+
+	config := &tls.Config{
+		ServerName:         s.ServerName,
+		InsecureSkipVerify: s.Mode == Require || s.Mode == VerifyCA,
+	}
+	if s.CA != "" {
+		pem, err := ioutil.ReadFile(s.CA)
+		if err != nil {
+			return nil, fmt.Errorf("read ca: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("invalid ca: no certificates in %s", s.CA)
+		}
+	}
+	if s.Cert != "" || s.Key != "" {
+		certificate, err := tls.LoadX509KeyPair(s.Cert, s.Key)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+	if s.Mode == VerifyCA {
+		roots := config.RootCAs
+		config.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifyChain(rawCerts, roots)
+		}
+	}
+
+	return config, nil
+}
+
+// verifyChain verifies the certificates of server by roots, nil for the system ones, without the server name
+func verifyChain(rawCerts [][]byte, roots *x509.CertPool) error {
+	if len(rawCerts) < 1 {
+		return errors.New("tls: no certificates from server")
+	}
+	certificates := make([]*x509.Certificate, len(rawCerts))
+	for i, rawCert := range rawCerts {
+		certificate, err := x509.ParseCertificate(rawCert)
+		if err != nil {
+			return err
+		}
+		certificates[i] = certificate
+	}
+	options := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, certificate := range certificates[1:] {
+		options.Intermediates.AddCert(certificate)
+	}
+	_, err := certificates[0].Verify(options)
+
+	return err
+}
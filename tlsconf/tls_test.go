@@ -0,0 +1,111 @@
+package tlsconf
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSettings_Config(t *testing.T) {
+	config, err := (*Settings)(nil).Config()
+	if err != nil || config != nil {
+		t.Errorf("nil: unexpected %v, %v", config, err)
+	}
+	config, err = (&Settings{Mode: Disable}).Config()
+	if err != nil || config != nil {
+		t.Errorf("disable: unexpected %v, %v", config, err)
+	}
+	_, err = (&Settings{Mode: "prefer"}).Config()
+	if err == nil {
+		t.Error("unsupported mode: error expected")
+	}
+	_, err = (&Settings{CA: filepath.Join(t.TempDir(), "missing.pem")}).Config()
+	if err == nil {
+		t.Error("missing ca: error expected")
+	}
+
+	certificate, caPath := testCertificate(t, "db.internal")
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{certificate}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	tests := []struct {
+		settings *Settings
+		success  bool
+	}{
+		{&Settings{Mode: Require}, true},
+		{&Settings{Mode: VerifyCA}, false},
+		{&Settings{Mode: VerifyCA, CA: caPath}, true},
+		{&Settings{Mode: VerifyFull, CA: caPath}, false},
+		{&Settings{Mode: VerifyFull, CA: caPath, ServerName: "db.internal"}, true},
+		{&Settings{CA: caPath, ServerName: "other.internal"}, false},
+	}
+	for _, test := range tests {
+		config, err := test.settings.Config()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config.ServerName == "" {
+			config.ServerName = "127.0.0.1"
+		}
+		conn, err := tls.Dial("tcp", listener.Addr().String(), config)
+		if err == nil {
+			conn.Close()
+		}
+		if (err == nil) != test.success {
+			t.Errorf("%+v: expect success %v, actual error %v", test.settings, test.success, err)
+		}
+	}
+}
+
+// testCertificate returns the self-signed certificate of host and the path of its PEM file
+func testCertificate(t *testing.T, host string) (tls.Certificate, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: host},
+		DNSNames:              []string{host},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, path
+}